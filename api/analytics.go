@@ -0,0 +1,54 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+// ParseAnalyticsQuery - from, to (YYYY-MM-DD, standart: oxirgi 30 kun), bucket (day/week/month),
+// group (user/department), rollup (1 - yuqori bo'limlarga yig'ish)
+func ParseAnalyticsQuery(r *http.Request) models.AnalyticsQuery {
+	q := r.URL.Query()
+	today := time.Now().Truncate(24 * time.Hour)
+	aq := models.AnalyticsQuery{
+		From:    today.AddDate(0, 0, -29),
+		To:      today.AddDate(0, 0, 1),
+		Bucket:  q.Get("bucket"),
+		GroupBy: q.Get("group"),
+		Rollup:  q.Get("rollup") == "1" || q.Get("rollup") == "true",
+	}
+	if t, err := time.Parse("2006-01-02", q.Get("from")); err == nil {
+		aq.From = t
+	}
+	if t, err := time.Parse("2006-01-02", q.Get("to")); err == nil {
+		aq.To = t.AddDate(0, 0, 1)
+	}
+	return aq
+}
+
+func handleCallStats(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		aq := ParseAnalyticsQuery(r)
+		if !aq.To.After(aq.From) {
+			writeError(w, http.StatusBadRequest, "to sanasi from dan keyin bo'lishi kerak")
+			return
+		}
+		stats, err := storage.CallStats(r.Context(), db, p.MemberID, aq)
+		if errors.Is(err, storage.ErrAnalyticsQuery) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			// SQL/drayver matni mijozga chiqmaydi
+			apiLog.Error("CallStats xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "statistikani olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, stats)
+	}
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bitrix/logging"
+	"bitrix/models"
+	"bitrix/service"
+	"bitrix/storage"
+)
+
+var apiLog = logging.For("api")
+
+// RegisterRoutes - barcha /api/ va Bitrix24 placement marshrutlarini ulash
+// (sched - sinxronizatsiya jadvali va "hozir sinxronlash" uchun, publicURL - eksportdagi havolalar uchun)
+func RegisterRoutes(mux *http.ServeMux, db *sql.DB, sched *service.Scheduler, publicURL string) {
+	mux.HandleFunc("POST /bitrix/app", handleFrameOpen(db))
+
+	mux.HandleFunc("GET /api/me", withAuth(db, "", handleMe))
+	mux.HandleFunc("GET /api/sync-status", withAuth(db, models.ScopeCallsRead, handleSyncStatus(db)))
+	mux.HandleFunc("POST /api/sync", withAuth(db, models.ScopeAdmin, handleSyncNow(db, sched)))
+	mux.HandleFunc("GET /api/calls", withAuth(db, models.ScopeCallsRead, handleListCalls(db)))
+	mux.HandleFunc("GET /api/calls/{id}", withAuth(db, models.ScopeCallsRead, handleGetCall(db)))
+	mux.HandleFunc("GET /api/calls/{id}/transcript", withAuth(db, models.ScopeCallsRead, handleTranscript(db)))
+	mux.HandleFunc("GET /api/calls/{id}/recording", withAuth(db, models.ScopeRecordingsRead, handleRecording(db)))
+	mux.HandleFunc("DELETE /api/calls/{id}/share", withAuth(db, models.ScopeAdmin, handleRevokeCallShares(db)))
+	mux.HandleFunc("GET /api/calls/{id}/recording/meta", withAuth(db, models.ScopeCallsRead, handleRecordingMeta(db)))
+	mux.HandleFunc("GET /api/calls/{id}/waveform", withAuth(db, models.ScopeCallsRead, handleWaveform(db)))
+	mux.HandleFunc("GET /api/calls/{id}/talk", withAuth(db, models.ScopeCallsRead, handleTalkStats(db)))
+	mux.HandleFunc("GET /api/recordings/problems", withAuth(db, models.ScopeCallsRead, handleRecordingProblems(db)))
+	mux.HandleFunc("GET /api/calls/{id}/compliance", withAuth(db, models.ScopeCallsRead, handleCallCompliance(db)))
+	mux.HandleFunc("POST /api/calls/{id}/compliance", withAuth(db, models.ScopeAdmin, handleEvaluateCompliance(db)))
+	mux.HandleFunc("GET /api/search", withAuth(db, models.ScopeCallsRead, handleSearch(db)))
+	mux.HandleFunc("GET /api/backfill", withAuth(db, models.ScopeCallsRead, handleListBackfill(db)))
+	mux.HandleFunc("GET /api/backfill/{id}", withAuth(db, models.ScopeCallsRead, handleGetBackfill(db)))
+	mux.HandleFunc("POST /api/backfill", withAuth(db, models.ScopeAdmin, handleCreateBackfill(db)))
+	mux.HandleFunc("POST /api/backfill/{id}/pause", withAuth(db, models.ScopeAdmin,
+		handleBackfillStatus(db, models.BackfillPaused, models.BackfillQueued, models.BackfillRunning)))
+	mux.HandleFunc("POST /api/backfill/{id}/resume", withAuth(db, models.ScopeAdmin,
+		handleBackfillStatus(db, models.BackfillQueued, models.BackfillPaused, models.BackfillFailed)))
+	mux.HandleFunc("DELETE /api/backfill/{id}", withAuth(db, models.ScopeAdmin,
+		handleBackfillStatus(db, models.BackfillCancelled, models.BackfillQueued, models.BackfillRunning, models.BackfillPaused, models.BackfillFailed)))
+	mux.HandleFunc("GET /api/departments", withAuth(db, models.ScopeCallsRead, handleListDepartments(db)))
+	mux.HandleFunc("GET /api/users/{id}/history", withAuth(db, models.ScopeCallsRead, handleUserHistory(db)))
+	mux.HandleFunc("GET /api/analytics/calls", withAuth(db, models.ScopeCallsRead, handleCallStats(db)))
+	mux.HandleFunc("GET /api/export/calls.csv", withAuth(db, models.ScopeCallsRead, handleExportCalls(db, "csv", publicURL)))
+	mux.HandleFunc("GET /api/export/calls.xlsx", withAuth(db, models.ScopeCallsRead, handleExportCalls(db, "xlsx", publicURL)))
+	mux.HandleFunc("GET /api/settings/timeline", withAuth(db, models.ScopeAdmin, handleGetTimelineSetting(db)))
+	mux.HandleFunc("PUT /api/settings/timeline", withAuth(db, models.ScopeAdmin, handleSetTimelineSetting(db)))
+	mux.HandleFunc("GET /api/settings/sync", withAuth(db, models.ScopeAdmin, handleGetSyncSettings(db, sched)))
+	mux.HandleFunc("PUT /api/settings/sync", withAuth(db, models.ScopeAdmin, handleSetSyncSettings(db, sched)))
+	mux.HandleFunc("GET /api/settings/telegram", withAuth(db, models.ScopeAdmin, handleGetTelegramSetting(db)))
+	mux.HandleFunc("PUT /api/settings/telegram", withAuth(db, models.ScopeAdmin, handleSetTelegramSetting(db)))
+	mux.HandleFunc("GET /api/compliance/rules", withAuth(db, models.ScopeCallsRead, handleListRules(db)))
+	mux.HandleFunc("POST /api/compliance/rules", withAuth(db, models.ScopeAdmin, handleCreateRule(db)))
+	mux.HandleFunc("PUT /api/compliance/rules/{id}", withAuth(db, models.ScopeAdmin, handleUpdateRule(db)))
+	mux.HandleFunc("DELETE /api/compliance/rules/{id}", withAuth(db, models.ScopeAdmin, handleDeleteRule(db)))
+	mux.HandleFunc("GET /api/keys", withAuth(db, models.ScopeAdmin, handleListKeys(db)))
+	mux.HandleFunc("POST /api/keys", withAuth(db, models.ScopeAdmin, handleCreateKey(db)))
+	mux.HandleFunc("DELETE /api/keys/{id}", withAuth(db, models.ScopeAdmin, handleRevokeKey(db)))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		apiLog.Error("JSON javob yozishda xatolik", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// handleFrameOpen - Bitrix24 ilovani iframe ichida ochganda POST qiladi (AUTH_ID, member_id, DOMAIN)
+func handleFrameOpen(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Noto'g'ri so'rov", http.StatusBadRequest)
+			return
+		}
+		memberID := r.FormValue("member_id")
+		authID := r.FormValue("AUTH_ID")
+		domain := r.FormValue("DOMAIN")
+
+		session, err := service.VerifyFrameAuth(r.Context(), db, memberID, domain, authID)
+		if err != nil {
+			apiLog.Warn("frame autentifikatsiya xatolik", "member_id", memberID, "err", err)
+			http.Error(w, "Bitrix24 sessiyasini tasdiqlab bo'lmadi", http.StatusUnauthorized)
+			return
+		}
+		token, err := service.CreateFrameSession(r.Context(), db, session)
+		if err != nil {
+			apiLog.Error("frame sessiya xatolik", "member_id", memberID, "err", err)
+			http.Error(w, "Sessiya yaratib bo'lmadi", http.StatusInternalServerError)
+			return
+		}
+
+		// iframe ichida cookie ishlashi uchun SameSite=None va Secure kerak
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Value:    token,
+			Path:     "/",
+			Expires:  session.ExpiresAt,
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteNoneMode,
+		})
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	}
+}
+
+func handleMe(w http.ResponseWriter, r *http.Request, p *Principal) {
+	writeJSON(w, http.StatusOK, p)
+}
+
+func handleListKeys(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		keys, err := storage.ListAPIKeys(r.Context(), db, p.MemberID)
+		if err != nil {
+			apiLog.Error("ListAPIKeys xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "kalitlarni olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, keys)
+	}
+}
+
+func handleCreateKey(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		var req struct {
+			Name   string   `json:"name"`
+			Scopes []string `json:"scopes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "JSON noto'g'ri")
+			return
+		}
+
+		// Kalit faqat so'rov egasining portali uchun yaratiladi
+		raw, key, err := service.GenerateAPIKey(r.Context(), db, p.MemberID, req.Name, req.Scopes)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"key":     raw,
+			"api_key": key,
+		})
+	}
+}
+
+func handleRevokeKey(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "id noto'g'ri")
+			return
+		}
+		ok, err := storage.RevokeAPIKey(r.Context(), db, p.MemberID, id)
+		if err != nil {
+			apiLog.Error("RevokeAPIKey xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "kalitni bekor qilishda xatolik")
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusNotFound, "kalit topilmadi")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func handleUserHistory(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		history, err := storage.GetUserHistory(r.Context(), db, p.MemberID, r.PathValue("id"))
+		if err != nil {
+			apiLog.Error("GetUserHistory xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "tarixni olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, history)
+	}
+}
+
+func handleListDepartments(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		departments, err := storage.ListDepartments(r.Context(), db, p.MemberID)
+		if err != nil {
+			apiLog.Error("ListDepartments xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "bo'limlarni olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, departments)
+	}
+}
+
+func handleGetTimelineSetting(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		enabled, err := storage.IsTimelineEnabled(r.Context(), db, p.MemberID)
+		if err != nil {
+			apiLog.Error("IsTimelineEnabled xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "sozlamani olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]bool{"enabled": enabled})
+	}
+}
+
+func handleSetTimelineSetting(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		var req struct {
+			Enabled bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "JSON noto'g'ri")
+			return
+		}
+		if err := storage.SetTimelineEnabled(r.Context(), db, p.MemberID, req.Enabled); err != nil {
+			apiLog.Error("SetTimelineEnabled xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "sozlamani saqlashda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]bool{"enabled": req.Enabled})
+	}
+}
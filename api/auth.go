@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"bitrix/service"
+)
+
+const sessionCookie = "bx_session"
+
+// Principal - so'rov kimning nomidan kelgani: har doim bitta portal (member_id) bilan cheklangan
+type Principal struct {
+	MemberID string   `json:"member_id"`
+	UserID   string   `json:"user_id,omitempty"`
+	KeyID    int      `json:"key_id,omitempty"`
+	Scopes   []string `json:"scopes"`
+}
+
+type principalKey struct{}
+
+// Authenticate - API kalit (Authorization: Bearer / X-API-Key) yoki Bitrix24 frame sessiyasi orqali
+func Authenticate(db *sql.DB, r *http.Request) *Principal {
+	raw := r.Header.Get("X-API-Key")
+	if raw == "" {
+		if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+			raw = strings.TrimPrefix(h, "Bearer ")
+		}
+	}
+	if raw != "" {
+		key, err := service.AuthenticateAPIKey(r.Context(), db, raw)
+		if err != nil {
+			if err != sql.ErrNoRows {
+				apiLog.Error("API kalit tekshirishda xatolik", "err", err)
+			}
+			return nil
+		}
+		return &Principal{MemberID: key.MemberID, KeyID: key.ID, Scopes: key.Scopes}
+	}
+
+	if c, err := r.Cookie(sessionCookie); err == nil && c.Value != "" {
+		s, err := service.AuthenticateFrameSession(r.Context(), db, c.Value)
+		if err != nil {
+			if err != sql.ErrNoRows {
+				apiLog.Error("sessiya tekshirishda xatolik", "err", err)
+			}
+			return nil
+		}
+		return &Principal{MemberID: s.MemberID, UserID: s.UserID, Scopes: s.Scopes}
+	}
+	return nil
+}
+
+// withAuth - markaziy middleware: autentifikatsiya + scope tekshiruvi.
+// Handler faqat Principal orqali portalni biladi, shuning uchun boshqa portal ma'lumotiga yetib bo'lmaydi.
+func withAuth(db *sql.DB, scope string, next func(http.ResponseWriter, *http.Request, *Principal)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := Authenticate(db, r)
+		if p == nil {
+			writeError(w, http.StatusUnauthorized, "autentifikatsiya talab qilinadi")
+			return
+		}
+		if scope != "" && !service.HasScope(p.Scopes, scope) {
+			writeError(w, http.StatusForbidden, "ruxsat yo'q: "+scope)
+			return
+		}
+		ctx := context.WithValue(r.Context(), principalKey{}, p)
+		next(w, r.WithContext(ctx), p)
+	}
+}
+
+// PrincipalFrom - kontekstdan joriy Principal ni olish
+func PrincipalFrom(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bitrix/models"
+	"bitrix/service"
+	"bitrix/storage"
+)
+
+const backfillListLimit = 20
+
+func handleListBackfill(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		jobs, err := storage.ListBackfillJobs(r.Context(), db, p.MemberID, backfillListLimit)
+		if err != nil {
+			apiLog.Error("ListBackfillJobs xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "vazifalarni olishda xatolik")
+			return
+		}
+		if jobs == nil {
+			jobs = []models.BackfillJob{}
+		}
+		writeJSON(w, http.StatusOK, jobs)
+	}
+}
+
+func handleGetBackfill(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "id noto'g'ri")
+			return
+		}
+		job, err := storage.GetBackfillJob(r.Context(), db, p.MemberID, id)
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "vazifa topilmadi")
+			return
+		}
+		if err != nil {
+			apiLog.Error("GetBackfillJob xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "vazifani olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	}
+}
+
+// handleCreateBackfill - {"since": "2024-01-01", "until": "2024-06-30"}: vazifa navbatga qo'yiladi,
+// server ichidagi backfill worker bajaradi
+func handleCreateBackfill(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		var req struct {
+			Since string `json:"since"`
+			Until string `json:"until"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "JSON noto'g'ri")
+			return
+		}
+		since, err := time.ParseInLocation("2006-01-02", req.Since, time.Local)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "since: YYYY-MM-DD")
+			return
+		}
+		var until time.Time
+		if req.Until != "" {
+			if until, err = time.ParseInLocation("2006-01-02", req.Until, time.Local); err != nil {
+				writeError(w, http.StatusBadRequest, "until: YYYY-MM-DD")
+				return
+			}
+			// until kuni ham kiradi
+			until = until.AddDate(0, 0, 1)
+		}
+
+		if _, err := storage.GetActiveBackfillJob(r.Context(), db, p.MemberID); err == nil {
+			writeError(w, http.StatusConflict, "portalda tugallanmagan backfill bor")
+			return
+		} else if err != sql.ErrNoRows {
+			apiLog.Error("GetActiveBackfillJob xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "vazifani yaratishda xatolik")
+			return
+		}
+
+		job, err := service.NewBackfillJob(r.Context(), db, p.MemberID, since, until)
+		if err != nil {
+			apiLog.Error("NewBackfillJob xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, job)
+	}
+}
+
+// handleBackfillStatus - pauza (keyingi sahifadan keyin to'xtaydi), davom ettirish yoki bekor qilish
+func handleBackfillStatus(db *sql.DB, status string, from ...string) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "id noto'g'ri")
+			return
+		}
+		ok, err := storage.SetBackfillStatus(r.Context(), db, p.MemberID, id, status, from...)
+		if err != nil {
+			apiLog.Error("SetBackfillStatus xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "holatni o'zgartirishda xatolik")
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusConflict, "vazifa topilmadi yoki bu holatda o'zgartirib bo'lmaydi")
+			return
+		}
+		job, err := storage.GetBackfillJob(r.Context(), db, p.MemberID, id)
+		if err != nil {
+			apiLog.Error("GetBackfillJob xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "vazifani olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	}
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"bitrix/models"
+	"bitrix/service"
+	"bitrix/storage"
+)
+
+// ParseCallFilter - query parametrlaridan filtr: from, to (YYYY-MM-DD), user, phone, type, limit, offset
+func ParseCallFilter(r *http.Request) models.CallFilter {
+	q := r.URL.Query()
+	f := models.CallFilter{
+		UserID: q.Get("user"),
+		Phone:  q.Get("phone"),
+	}
+	f.CallType, _ = strconv.Atoi(q.Get("type"))
+	f.MinOverlapSec, _ = strconv.Atoi(q.Get("overlap"))
+	if t, err := time.Parse("2006-01-02", q.Get("from")); err == nil {
+		f.From = t
+	}
+	if t, err := time.Parse("2006-01-02", q.Get("to")); err == nil {
+		// "to" kuni ham kiradi
+		f.To = t.AddDate(0, 0, 1)
+	}
+	f.Limit, _ = strconv.Atoi(q.Get("limit"))
+	f.Offset, _ = strconv.Atoi(q.Get("offset"))
+	return f
+}
+
+func handleListCalls(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		calls, err := storage.ListCalls(r.Context(), db, p.MemberID, ParseCallFilter(r))
+		if err != nil {
+			apiLog.Error("ListCalls xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "qo'ng'iroqlarni olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, calls)
+	}
+}
+
+func handleGetCall(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		call, err := storage.GetCall(r.Context(), db, p.MemberID, r.PathValue("id"))
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "qo'ng'iroq topilmadi")
+			return
+		}
+		if err != nil {
+			apiLog.Error("GetCall xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "qo'ng'iroqni olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, call)
+	}
+}
+
+// handleRecording - audio faylni stream qilish (Range so'rovlari bilan, player uchun)
+func handleRecording(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		call, err := storage.GetCall(r.Context(), db, p.MemberID, r.PathValue("id"))
+		if err == sql.ErrNoRows || (err == nil && call.AudioPath == "") {
+			writeError(w, http.StatusNotFound, "yozuv topilmadi")
+			return
+		}
+		if err != nil {
+			apiLog.Error("GetCall xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "yozuvni olishda xatolik")
+			return
+		}
+
+		ServeRecording(w, r, p.MemberID, call.AudioPath)
+	}
+}
+
+// handleRevokeCallShares - qo'ng'iroqning ulashilgan havolalarini bekor qilish (CRM va Telegram dagi havolalar ochilmaydi;
+// keyingi izoh yoki ogohlantirish yangi havola oladi)
+func handleRevokeCallShares(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		n, err := storage.RevokeCallShares(r.Context(), db, p.MemberID, r.PathValue("id"))
+		if err != nil {
+			apiLog.Error("RevokeCallShares xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "havolani bekor qilishda xatolik")
+			return
+		}
+		if n == 0 {
+			writeError(w, http.StatusNotFound, "amaldagi havola topilmadi")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ServeRecording - audio faylni berish (Range so'rovlari bilan); faqat portalning downloads/<member_id> papkasidagi
+// fayllar (boshqa portalning yoki hali ko'chirilmagan umumiy fayl berilmaydi)
+func ServeRecording(w http.ResponseWriter, r *http.Request, memberID, audioPath string) {
+	if !service.RecordingOwnedBy(memberID, audioPath) {
+		writeError(w, http.StatusNotFound, "yozuv topilmadi")
+		return
+	}
+	http.ServeFile(w, r, filepath.Clean(audioPath))
+}
+
+func handleSyncStatus(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		status, err := storage.GetSyncStatus(r.Context(), db, p.MemberID)
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "portal topilmadi")
+			return
+		}
+		if err != nil {
+			apiLog.Error("GetSyncStatus xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "holatni olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, status)
+	}
+}
+
+func handleTranscript(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		t, err := storage.GetTranscript(r.Context(), db, p.MemberID, r.PathValue("id"))
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "transkript topilmadi")
+			return
+		}
+		if err != nil {
+			apiLog.Error("GetTranscript xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "transkriptni olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, t)
+	}
+}
+
+func handleRecordingMeta(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		m, err := storage.GetRecordingMeta(r.Context(), db, p.MemberID, r.PathValue("id"))
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "yozuv tekshirilmagan")
+			return
+		}
+		if err != nil {
+			apiLog.Error("GetRecordingMeta xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "yozuv ma'lumotlarini olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, m)
+	}
+}
+
+// handleRecordingProblems - bo'sh, buzilgan, topilmagan yoki davomiyligi mos kelmagan yozuvlar
+func handleRecordingProblems(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		list, err := storage.ListRecordingProblems(r.Context(), db, p.MemberID, limit)
+		if err != nil {
+			apiLog.Error("ListRecordingProblems xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "yozuvlarni olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+	}
+}
+
+func handleTalkStats(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		s, err := storage.GetTalkStats(r.Context(), db, p.MemberID, r.PathValue("id"))
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "yozuv tahlil qilinmagan")
+			return
+		}
+		if err != nil {
+			apiLog.Error("GetTalkStats xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "tahlilni olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, s)
+	}
+}
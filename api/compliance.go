@@ -0,0 +1,205 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bitrix/models"
+	"bitrix/service"
+	"bitrix/storage"
+)
+
+// decodeRule - so'rovdagi qoidani o'qish va tekshirish; xatolik matni foydalanuvchiga qaytariladi
+func decodeRule(r *http.Request) (*models.ComplianceRule, string) {
+	rule := models.ComplianceRule{Weight: 10, Alert: true, Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		return nil, "JSON noto'g'ri"
+	}
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return nil, "name kerak"
+	}
+	switch rule.Kind {
+	case models.RuleRequired, models.RuleForbidden, models.RuleCompetitor:
+	default:
+		return nil, "kind: required, forbidden yoki competitor"
+	}
+	phrases := rule.Phrases[:0]
+	for _, p := range rule.Phrases {
+		if p = strings.TrimSpace(p); p != "" {
+			phrases = append(phrases, p)
+		}
+	}
+	if len(phrases) == 0 {
+		return nil, "phrases bo'sh bo'lmasligi kerak"
+	}
+	rule.Phrases = phrases
+	if rule.Channel != nil && *rule.Channel != 0 && *rule.Channel != 1 {
+		return nil, "channel: 0 (operator) yoki 1 (mijoz)"
+	}
+	if rule.WithinSeconds != nil && *rule.WithinSeconds <= 0 {
+		return nil, "within_seconds musbat bo'lishi kerak"
+	}
+	if rule.Weight <= 0 {
+		return nil, "weight musbat bo'lishi kerak"
+	}
+	return &rule, ""
+}
+
+func handleListRules(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		rules, err := storage.ListComplianceRules(r.Context(), db, p.MemberID, false)
+		if err != nil {
+			apiLog.Error("ListComplianceRules xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "qoidalarni olishda xatolik")
+			return
+		}
+		if rules == nil {
+			rules = []models.ComplianceRule{}
+		}
+		writeJSON(w, http.StatusOK, rules)
+	}
+}
+
+func handleCreateRule(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		rule, msg := decodeRule(r)
+		if rule == nil {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+		rule.MemberID = p.MemberID
+		if err := storage.InsertComplianceRule(r.Context(), db, rule); err != nil {
+			apiLog.Error("InsertComplianceRule xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "qoidani saqlashda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusCreated, rule)
+	}
+}
+
+func handleUpdateRule(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "id noto'g'ri")
+			return
+		}
+		rule, msg := decodeRule(r)
+		if rule == nil {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+		rule.ID = id
+		rule.MemberID = p.MemberID
+		ok, err := storage.UpdateComplianceRule(r.Context(), db, rule)
+		if err != nil {
+			apiLog.Error("UpdateComplianceRule xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "qoidani saqlashda xatolik")
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusNotFound, "qoida topilmadi")
+			return
+		}
+		writeJSON(w, http.StatusOK, rule)
+	}
+}
+
+func handleDeleteRule(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "id noto'g'ri")
+			return
+		}
+		ok, err := storage.DeleteComplianceRule(r.Context(), db, p.MemberID, id)
+		if err != nil {
+			apiLog.Error("DeleteComplianceRule xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "qoidani o'chirishda xatolik")
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusNotFound, "qoida topilmadi")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func handleCallCompliance(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		c, err := storage.GetCallCompliance(r.Context(), db, p.MemberID, r.PathValue("id"))
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "baho topilmadi")
+			return
+		}
+		if err != nil {
+			apiLog.Error("GetCallCompliance xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "bahoni olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, c)
+	}
+}
+
+// handleEvaluateCompliance - qoidalar o'zgargandan keyin qo'ng'iroqni qayta baholash (ogohlantirishsiz)
+func handleEvaluateCompliance(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		t, err := storage.GetTranscript(r.Context(), db, p.MemberID, r.PathValue("id"))
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "transkript topilmadi")
+			return
+		}
+		if err != nil {
+			apiLog.Error("GetTranscript xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "transkriptni olishda xatolik")
+			return
+		}
+		c, err := service.EvaluateCompliance(r.Context(), db, t, nil)
+		if err != nil {
+			apiLog.Error("EvaluateCompliance xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "baholashda xatolik")
+			return
+		}
+		if c == nil {
+			writeError(w, http.StatusConflict, "portalda yoqilgan qoida yo'q")
+			return
+		}
+		writeJSON(w, http.StatusOK, c)
+	}
+}
+
+func handleGetTelegramSetting(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		chatID, err := storage.GetTelegramChatID(r.Context(), db, p.MemberID)
+		if err != nil {
+			apiLog.Error("GetTelegramChatID xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "sozlamani olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"chat_id": chatID})
+	}
+}
+
+func handleSetTelegramSetting(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		var req struct {
+			ChatID string `json:"chat_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "JSON noto'g'ri")
+			return
+		}
+		req.ChatID = strings.TrimSpace(req.ChatID)
+		if err := storage.SetTelegramChatID(r.Context(), db, p.MemberID, req.ChatID); err != nil {
+			apiLog.Error("SetTelegramChatID xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "sozlamani saqlashda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"chat_id": req.ChatID})
+	}
+}
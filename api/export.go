@@ -0,0 +1,30 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"bitrix/export"
+	"bitrix/service"
+)
+
+// handleExportCalls - GET /api/export/calls.{csv,xlsx}?from=...&to=... (ListCalls bilan bir xil filtrlar).
+// Yozuv havolalari server.public_url dan quriladi: Host va X-Forwarded-Proto ni mijoz o'zi beradi.
+func handleExportCalls(db *sql.DB, format, publicURL string) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		filter := ParseCallFilter(r)
+		filter.Limit, filter.Offset = 0, 0
+
+		fileName := fmt.Sprintf("calls-%s.%s", time.Now().Format("20060102-150405"), format)
+		w.Header().Set("Content-Type", export.ContentType(format))
+		w.Header().Set("Content-Disposition", `attachment; filename="`+fileName+`"`)
+
+		// Javob allaqachon boshlangan bo'lishi mumkin, shuning uchun xatolik faqat logga yoziladi
+		n, err := service.ExportCalls(r.Context(), db, p.MemberID, filter, format, publicURL, w)
+		if err != nil {
+			apiLog.Error("eksport xatolik", "member_id", p.MemberID, "rows", n, "err", err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"bitrix/metrics"
+	"bitrix/storage"
+)
+
+// DB dan so'rov paytida hisoblanadigan metrikalar (CLI orqali bajarilgan sinxronizatsiyalar ham ko'rinadi)
+var (
+	transcriptionQueue = metrics.NewGauge("bitrix_transcription_queue_depth",
+		"Matnga o'girish navbati: queued va running vazifalar", "status")
+	portalLastSync = metrics.NewGauge("bitrix_portal_last_sync_success_timestamp_seconds",
+		"Yoqilgan portalning oxirgi muvaffaqiyatli sinxronizatsiyasi (unix vaqt, hali bo'lmagan bo'lsa 0)", "member_id")
+)
+
+// MetricsHandler - GET /metrics (Prometheus). token berilgan bo'lsa "Authorization: Bearer <token>" talab qilinadi;
+// API kalitlari portal doirasida bo'lgani uchun bu yerda ishlatilmaydi.
+func MetricsHandler(db *sql.DB, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				writeError(w, http.StatusUnauthorized, "metrics token noto'g'ri")
+				return
+			}
+		}
+
+		if counts, err := storage.CountTranscriptionJobs(r.Context(), db); err != nil {
+			apiLog.Error("CountTranscriptionJobs xatolik", "err", err)
+		} else {
+			for status, n := range counts {
+				transcriptionQueue.Set(float64(n), status)
+			}
+		}
+		if syncs, err := storage.LastSuccessfulSyncs(r.Context(), db); err != nil {
+			apiLog.Error("LastSuccessfulSyncs xatolik", "err", err)
+		} else {
+			// O'chirilgan yoki olib tashlangan portallar qolib ketmasin
+			portalLastSync.Reset()
+			for memberID, at := range syncs {
+				var ts float64
+				if at != nil {
+					ts = float64(at.Unix())
+				}
+				portalLastSync.Set(ts, memberID)
+			}
+		}
+
+		w.Header().Set("Content-Type", metrics.ContentType)
+		if err := metrics.Write(w); err != nil {
+			apiLog.Error("metrics.Write xatolik", "err", err)
+		}
+	})
+}
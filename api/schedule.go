@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"bitrix/models"
+	"bitrix/service"
+	"bitrix/storage"
+)
+
+// syncSettings - portal jadvali va keyingi rejalashtirilgan vaqt
+func syncSettings(ctx context.Context, db *sql.DB, sched *service.Scheduler, memberID string) (*models.SyncSettings, error) {
+	p, err := storage.GetPortalSchedule(ctx, db, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Paused && !p.Disabled {
+		if next, err := sched.NextRun(p, time.Now()); err == nil {
+			p.NextRunAt = &next
+		}
+	}
+	return &p.SyncSettings, nil
+}
+
+func handleGetSyncSettings(db *sql.DB, sched *service.Scheduler) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		s, err := syncSettings(r.Context(), db, sched, p.MemberID)
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "portal topilmadi")
+			return
+		}
+		if err != nil {
+			apiLog.Error("GetPortalSchedule xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "sozlamani olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, s)
+	}
+}
+
+// handleSetSyncSettings - {"schedule": ["*/5 9-18 * * 1-5", "0 19-23,0-8 * * *"], "timezone": "Asia/Tashkent", "paused": false}
+func handleSetSyncSettings(db *sql.DB, sched *service.Scheduler) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		var req models.SyncSettings
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "JSON noto'g'ri")
+			return
+		}
+		schedule := make([]string, 0, len(req.Schedule))
+		for _, e := range req.Schedule {
+			if e = strings.TrimSpace(e); e != "" {
+				schedule = append(schedule, e)
+			}
+		}
+		req.Schedule = schedule
+		req.TimeZone = strings.TrimSpace(req.TimeZone)
+		if err := service.ValidateSyncSettings(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ok, err := storage.UpdateSyncSettings(r.Context(), db, p.MemberID, &req)
+		if err != nil {
+			apiLog.Error("UpdateSyncSettings xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "sozlamani saqlashda xatolik")
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusNotFound, "portal topilmadi")
+			return
+		}
+		s, err := syncSettings(r.Context(), db, sched, p.MemberID)
+		if err != nil {
+			apiLog.Error("GetPortalSchedule xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "sozlamani olishda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, s)
+	}
+}
+
+// handleSyncNow - "hozir sinxronlash" (pauzada ham); joriy sinxronizatsiya bajarilayotgan bo'lsa, u tugagach
+func handleSyncNow(db *sql.DB, sched *service.Scheduler) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		s, err := storage.GetPortalSchedule(r.Context(), db, p.MemberID)
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "portal topilmadi")
+			return
+		}
+		if err != nil {
+			apiLog.Error("GetPortalSchedule xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "so'rovda xatolik")
+			return
+		}
+		if s.Disabled {
+			writeError(w, http.StatusConflict, "portal o'chirilgan")
+			return
+		}
+		if s.FolderID == "" {
+			writeError(w, http.StatusConflict, "yozuvlar papkasi (folder_id) belgilanmagan")
+			return
+		}
+
+		at, err := storage.RequestSync(r.Context(), db, p.MemberID)
+		if err != nil {
+			apiLog.Error("RequestSync xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "so'rovda xatolik")
+			return
+		}
+		sched.Wake()
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{
+			"requested_at": at,
+			"running":      s.Running,
+		})
+	}
+}
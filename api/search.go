@@ -0,0 +1,31 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bitrix/storage"
+)
+
+// handleSearch - GET /api/search?q=...&limit=&offset= (transkript va izohlar bo'yicha)
+func handleSearch(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		if q == "" {
+			writeError(w, http.StatusBadRequest, "q parametri kerak")
+			return
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		results, err := storage.SearchCalls(r.Context(), db, p.MemberID, q, limit, offset)
+		if err != nil {
+			apiLog.Error("SearchCalls xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "qidiruvda xatolik")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"query": q, "results": results})
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bitrix/models"
+	"bitrix/service"
+	"bitrix/storage"
+)
+
+// loadWaveform - saqlangan to'lqin shakli; bo'lmasa (eski yozuvlar) shu yerda yaratiladi
+func loadWaveform(ctx context.Context, db *sql.DB, memberID, callID string) (*models.Waveform, error) {
+	w, err := storage.GetWaveform(ctx, db, memberID, callID)
+	if err != sql.ErrNoRows {
+		return w, err
+	}
+	meta, err := storage.GetRecordingMeta(ctx, db, memberID, callID)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Status != models.RecordingOK && meta.Status != models.RecordingMismatch {
+		return nil, sql.ErrNoRows
+	}
+	return service.GenerateWaveform(ctx, db, meta)
+}
+
+// handleWaveform - GET /api/calls/{id}/waveform[?format=binary][&q=so'z]
+// JSON: bo'laklar (0..255, kanal bo'yicha), jimlik oraliqlari va belgilar (skript qoidalari, q bo'yicha kalit so'zlar).
+// format=binary: faqat qiymatlar, kanallar ketma-ket; X-Waveform-Channels va X-Waveform-Bucket-Ms sarlavhalarda.
+func handleWaveform(db *sql.DB) func(http.ResponseWriter, *http.Request, *Principal) {
+	return func(w http.ResponseWriter, r *http.Request, p *Principal) {
+		callID := r.PathValue("id")
+		wf, err := loadWaveform(r.Context(), db, p.MemberID, callID)
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "to'lqin shakli mavjud emas")
+			return
+		}
+		if err != nil {
+			apiLog.Error("Waveform xatolik", "member_id", p.MemberID, "err", err)
+			writeError(w, http.StatusInternalServerError, "to'lqin shaklini olishda xatolik")
+			return
+		}
+
+		if r.URL.Query().Get("format") == "binary" {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("X-Waveform-Channels", strconv.Itoa(len(wf.Peaks)))
+			w.Header().Set("X-Waveform-Bucket-Ms", strconv.Itoa(wf.BucketMs))
+			w.Header().Set("Cache-Control", "private, max-age=3600")
+			for _, ch := range wf.Peaks {
+				w.Write(ch)
+			}
+			return
+		}
+
+		// []uint8 JSON da base64 bo'lib qolmasligi uchun
+		peaks := make([][]int, len(wf.Peaks))
+		duration := 0
+		for c, ch := range wf.Peaks {
+			peaks[c] = make([]int, len(ch))
+			for i, v := range ch {
+				peaks[c][i] = int(v)
+			}
+			duration = len(ch) * wf.BucketMs
+		}
+
+		silences := []models.SilenceRange{}
+		if talk, err := storage.GetTalkStats(r.Context(), db, p.MemberID, callID); err == nil && talk.Silences != nil {
+			silences = talk.Silences
+		} else if err != nil && err != sql.ErrNoRows {
+			apiLog.Error("GetTalkStats xatolik", "member_id", p.MemberID, "err", err)
+		}
+
+		markers := []models.WaveformMarker{}
+		if c, err := storage.GetCallCompliance(r.Context(), db, p.MemberID, callID); err == nil {
+			for _, f := range c.Flags {
+				if f.StartMs == nil {
+					continue
+				}
+				passed := f.Passed
+				markers = append(markers, models.WaveformMarker{StartMs: *f.StartMs, Kind: "compliance", Label: f.RuleName, Passed: &passed})
+			}
+		} else if err != sql.ErrNoRows {
+			apiLog.Error("GetCallCompliance xatolik", "member_id", p.MemberID, "err", err)
+		}
+		if q := strings.TrimSpace(r.URL.Query().Get("q")); q != "" {
+			starts, err := storage.SearchCallSegments(r.Context(), db, p.MemberID, callID, q)
+			if err != nil {
+				apiLog.Error("SearchCallSegments xatolik", "member_id", p.MemberID, "err", err)
+			}
+			for _, ms := range starts {
+				markers = append(markers, models.WaveformMarker{StartMs: ms, Kind: "keyword", Label: q})
+			}
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"call_id":     callID,
+			"duration_ms": duration,
+			"bucket_ms":   wf.BucketMs,
+			"channels":    len(wf.Peaks),
+			"approximate": wf.Approximate,
+			"peaks":       peaks,
+			"silences":    silences,
+			"markers":     markers,
+		})
+	}
+}
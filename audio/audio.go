@@ -0,0 +1,73 @@
+package audio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Xatolik turlari (errors.Is bilan tekshiriladi)
+var (
+	ErrEmpty         = errors.New("fayl bo'sh")
+	ErrUnknownFormat = errors.New("noma'lum audio format")
+	ErrCorrupt       = errors.New("fayl buzilgan")
+)
+
+// Info - audio fayl sarlavhasidan olingan ma'lumotlar
+type Info struct {
+	Format     string        // wav, mp3
+	Codec      string        // pcm, alaw, ulaw, mp3, ...
+	Duration   time.Duration // haqiqiy davomiylik (MP3 da barcha freymlar bo'yicha)
+	Bitrate    int           // o'rtacha, bit/s
+	SampleRate int
+	Channels   int
+	Size       int64
+	Frames     int   // faqat MP3
+	JunkBytes  int64 // MP3 freymlari orasidagi tanib bo'lmagan baytlar
+	Truncated  bool  // oxirgi freym yoki data bo'lagi to'liq emas
+}
+
+// Probe - faylni ochib formatini aniqlaydi va sarlavhalarni tahlil qiladi (dekodlashsiz)
+func Probe(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if st.Size() == 0 {
+		return nil, ErrEmpty
+	}
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	head, err := r.Peek(12)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	var info *Info
+	switch {
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		info, err = probeWAV(r, st.Size())
+	case len(head) >= 3 && string(head[0:3]) == "ID3", len(head) >= 2 && isFrameSync(head[0], head[1]):
+		info, err = probeMP3(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	info.Size = st.Size()
+	return info, nil
+}
+
+func corrupt(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrCorrupt, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,336 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+)
+
+// SilenceDB - envelope dagi "mutlaq jimlik" qiymati (dBFS)
+const SilenceDB = -100
+
+// Envelope - har bir kanal uchun WindowMs oynalardagi o'rtacha daraja (dBFS).
+// Approximate - qiymatlar dekodlashsiz baholangan (MP3 side info), faqat nisbiy taqqoslash uchun.
+// Mixed - stereo yozuv mid/side kodlangan: kanallarni ajratib bo'lmaydi, bitta umumiy kanal qaytariladi.
+type Envelope struct {
+	WindowMs    int
+	Channels    [][]float64
+	Approximate bool
+	Mixed       bool
+}
+
+// Len - oynalar soni
+func (e *Envelope) Len() int {
+	if len(e.Channels) == 0 {
+		return 0
+	}
+	return len(e.Channels[0])
+}
+
+// ReadEnvelope - WAV (PCM, float, A-law, µ-law) namunalaridan RMS, MP3 Layer III dan esa
+// global_gain va part2_3_length bo'yicha taxminiy daraja hisoblanadi
+func ReadEnvelope(path string, windowMs int) (*Envelope, error) {
+	if windowMs <= 0 {
+		windowMs = 50
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if st.Size() == 0 {
+		return nil, ErrEmpty
+	}
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	head, _ := r.Peek(12)
+	switch {
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return wavEnvelope(r, st.Size(), windowMs)
+	case len(head) >= 3 && string(head[0:3]) == "ID3", len(head) >= 2 && isFrameSync(head[0], head[1]):
+		return mp3Envelope(r, windowMs)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// powerDB - o'rtacha quvvatdan dBFS
+func powerDB(power float64) float64 {
+	if power <= 0 {
+		return SilenceDB
+	}
+	return math.Max(10*math.Log10(power), SilenceDB)
+}
+
+func wavEnvelope(r io.Reader, fileSize int64, windowMs int) (*Envelope, error) {
+	f, dataSize, remaining, err := readWAVHeader(r, fileSize)
+	if err != nil {
+		return nil, err
+	}
+	sample := sampleDecoder(f)
+	if sample == nil {
+		return nil, corrupt("WAV kodeki qo'llab-quvvatlanmaydi (%d, %d bit)", f.Tag, f.BitsPerSample)
+	}
+	// Dekoder BitsPerSample/8 bayt o'qiydi: blok aynan kanallar soniga teng namunalardan iborat bo'lishi shart
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	if dataSize == 0 || dataSize == 0xFFFFFFFF || dataSize > remaining {
+		dataSize = remaining
+	}
+
+	bytesPerSample := f.BitsPerSample / 8
+	perWindow := f.SampleRate * windowMs / 1000
+	if perWindow <= 0 {
+		perWindow = 1
+	}
+
+	env := &Envelope{WindowMs: windowMs, Channels: make([][]float64, f.Channels)}
+	sums := make([]float64, f.Channels)
+	n := 0
+	flush := func() {
+		for c := range sums {
+			env.Channels[c] = append(env.Channels[c], powerDB(sums[c]/float64(n)))
+			sums[c] = 0
+		}
+		n = 0
+	}
+
+	br := bufio.NewReader(io.LimitReader(r, dataSize))
+	block := make([]byte, f.BlockAlign)
+	for {
+		if _, err := io.ReadFull(br, block); err != nil {
+			break
+		}
+		for c := 0; c < f.Channels; c++ {
+			v := sample(block[c*bytesPerSample : (c+1)*bytesPerSample])
+			sums[c] += v * v
+		}
+		if n++; n == perWindow {
+			flush()
+		}
+	}
+	if n > 0 {
+		flush()
+	}
+	if env.Len() == 0 {
+		return nil, ErrEmpty
+	}
+	return env, nil
+}
+
+// sampleDecoder - bitta namunani [-1, 1] oraliqqa o'giruvchi funksiya
+func sampleDecoder(f wavFormat) func([]byte) float64 {
+	switch {
+	case f.Tag == wavPCM && f.BitsPerSample == 8:
+		return func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }
+	case f.Tag == wavPCM && f.BitsPerSample == 16:
+		return func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / 32768 }
+	case f.Tag == wavPCM && f.BitsPerSample == 24:
+		return func(b []byte) float64 {
+			v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+			return float64(v) / (1 << 23)
+		}
+	case f.Tag == wavPCM && f.BitsPerSample == 32:
+		return func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31) }
+	case f.Tag == wavFloat && f.BitsPerSample == 32:
+		return func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }
+	case f.Tag == wavALaw && f.BitsPerSample == 8:
+		return func(b []byte) float64 { return float64(alawToLinear(b[0])) / 32768 }
+	case f.Tag == wavMuLaw && f.BitsPerSample == 8:
+		return func(b []byte) float64 { return float64(ulawToLinear(b[0])) / 32768 }
+	}
+	return nil
+}
+
+// G.711 A-law -> 16 bit PCM
+func alawToLinear(a byte) int16 {
+	a ^= 0x55
+	t := int16(a&0x0F) << 4
+	seg := (a & 0x70) >> 4
+	switch seg {
+	case 0:
+		t += 8
+	case 1:
+		t += 0x108
+	default:
+		t += 0x108
+		t <<= seg - 1
+	}
+	if a&0x80 != 0 {
+		return t
+	}
+	return -t
+}
+
+// G.711 µ-law -> 16 bit PCM
+func ulawToLinear(u byte) int16 {
+	u = ^u
+	t := (int16(u&0x0F)<<3 + 0x84) << ((u & 0x70) >> 4)
+	if u&0x80 != 0 {
+		return 0x84 - t
+	}
+	return t - 0x84
+}
+
+// mp3Envelope - Layer III granulalari: kvantlash qadami 2^((global_gain-210)/4), ya'ni 1.5 dB birlik;
+// part2_3_length (granulaga sarflangan bitlar) signal murakkabligini bildiradi, 0 bo'lsa - jimlik.
+func mp3Envelope(r *bufio.Reader, windowMs int) (*Envelope, error) {
+	env := &Envelope{WindowMs: windowMs, Approximate: true}
+	var sums []float64
+	var granules float64 // joriy oynadagi granulalar soni
+	var t float64        // granula boshlanish vaqti (ms)
+	cur := 0             // joriy oyna indeksi
+	var layerErr bool
+
+	// flush - joriy oynani yozadi; granula oynadan uzun bo'lsa (8 kHz), oraliq oynalar shu qiymat bilan to'ldiriladi
+	flush := func(next int) {
+		for c := range sums {
+			db := powerDB(sums[c] / granules)
+			for len(env.Channels[c]) < next {
+				env.Channels[c] = append(env.Channels[c], db)
+			}
+			sums[c] = 0
+		}
+		granules = 0
+	}
+
+	_, err := scanMP3(r, func(h mp3Header, frame []byte) {
+		if h.Layer != 3 {
+			layerErr = true
+			return
+		}
+		gains := layer3Granules(h, frame)
+		if gains == nil {
+			return
+		}
+		channels := h.Channels()
+		// Joint stereo mid/side: ikkinchi kanal "farq" signali, operator/mijoz ajratilmaydi
+		if channels == 2 && h.ChannelMode == 1 && h.ModeExt&2 != 0 {
+			env.Mixed = true
+		}
+		if env.Channels == nil {
+			env.Channels = make([][]float64, channels)
+			sums = make([]float64, channels)
+		}
+
+		granuleMs := float64(576) * 1000 / float64(h.SampleRate)
+		for _, g := range gains {
+			if idx := int(t) / windowMs; idx != cur && granules > 0 {
+				flush(idx)
+				cur = idx
+			}
+			for c := 0; c < len(sums) && c < len(g); c++ {
+				if g[c].bits > 0 {
+					db := 1.5*(float64(g[c].gain)-210) + 10*math.Log10(float64(g[c].bits))
+					sums[c] += math.Pow(10, db/10)
+				}
+			}
+			granules++
+			t += granuleMs
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if layerErr {
+		return nil, corrupt("faqat MPEG Layer III tahlil qilinadi")
+	}
+	if granules > 0 {
+		flush((int(t) + windowMs - 1) / windowMs)
+	}
+	if env.Mixed && len(env.Channels) == 2 {
+		env.Channels = env.Channels[:1]
+	}
+	if env.Len() == 0 {
+		return nil, ErrEmpty
+	}
+	return env, nil
+}
+
+type granuleGain struct {
+	gain int // global_gain
+	bits int // part2_3_length
+}
+
+// layer3Granules - side info dan har bir granula/kanal uchun global_gain va part2_3_length
+func layer3Granules(h mp3Header, frame []byte) [][]granuleGain {
+	off := sideInfoOffset(h)
+	size := sideInfoSize(h)
+	if len(frame) < off+size {
+		return nil
+	}
+	br := bitReader{data: frame[off : off+size]}
+	channels := h.Channels()
+
+	granules := 1
+	if h.Version == mpeg1 {
+		granules = 2
+		br.skip(9) // main_data_begin
+		if channels == 1 {
+			br.skip(5)
+		} else {
+			br.skip(3)
+		}
+		br.skip(4 * channels) // scfsi
+	} else {
+		br.skip(8)
+		if channels == 1 {
+			br.skip(1)
+		} else {
+			br.skip(2)
+		}
+	}
+
+	out := make([][]granuleGain, granules)
+	for gr := 0; gr < granules; gr++ {
+		out[gr] = make([]granuleGain, channels)
+		for ch := 0; ch < channels; ch++ {
+			out[gr][ch].bits = br.read(12) // part2_3_length
+			br.skip(9)                     // big_values
+			out[gr][ch].gain = br.read(8)  // global_gain
+			if h.Version == mpeg1 {
+				br.skip(4) // scalefac_compress
+			} else {
+				br.skip(9)
+			}
+			br.skip(1 + 22) // window_switching_flag va bloklar/jadvallar (har ikki holatda 22 bit)
+			if h.Version == mpeg1 {
+				br.skip(3) // preflag, scalefac_scale, count1table_select
+			} else {
+				br.skip(2)
+			}
+		}
+	}
+	return out
+}
+
+// bitReader - baytlar ustidan MSB-first bit o'qish
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (b *bitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		byteIdx := b.pos / 8
+		bit := 0
+		if byteIdx < len(b.data) {
+			bit = int(b.data[byteIdx]>>(7-uint(b.pos%8))) & 1
+		}
+		v = v<<1 | bit
+		b.pos++
+	}
+	return v
+}
+
+func (b *bitReader) skip(n int) {
+	b.pos += n
+}
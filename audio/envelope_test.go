@@ -0,0 +1,61 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTemp(t *testing.T, name string, b []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadEnvelopeWAV(t *testing.T) {
+	// 8 kHz stereo PCM16, 1 soniya: chap kanal to'liq amplituda, o'ng kanal jim
+	data := make([]byte, 8000*4)
+	for i := 0; i < 8000; i++ {
+		binary.LittleEndian.PutUint16(data[i*4:], uint16(int16(math.MaxInt16)))
+	}
+	path := writeTemp(t, "a.wav", newWAV().chunk("fmt ", 16, pcmFmt(wavPCM, 2, 8000, 16)).chunk("data", uint32(len(data)), data).Bytes())
+
+	env, err := ReadEnvelope(path, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Len() != 10 || len(env.Channels) != 2 {
+		t.Fatalf("oynalar %d, kanallar %d", env.Len(), len(env.Channels))
+	}
+	if l, r := env.Channels[0][0], env.Channels[1][0]; l < -0.1 || r != SilenceDB {
+		t.Errorf("chap %.2f dB, o'ng %.2f dB", l, r)
+	}
+}
+
+// BlockAlign kanallar va namuna kengligiga mos kelmasa panic emas, ErrCorrupt qaytishi kerak
+func TestReadEnvelopeMisalignedWAV(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		channels int
+		bits     int
+		align    int
+	}{
+		{"pcm16 stereo, blok 2", 2, 16, 2},
+		{"pcm16 stereo, blok 3", 2, 16, 3},
+		{"pcm24 mono, blok 2", 1, 24, 2},
+		{"pcm8 mono, blok 2", 1, 8, 2},
+	} {
+		f := pcmFmt(wavPCM, tc.channels, 8000, tc.bits)
+		binary.LittleEndian.PutUint16(f[12:], uint16(tc.align))
+		path := writeTemp(t, "bad.wav", newWAV().chunk("fmt ", 16, f).chunk("data", 1024, make([]byte, 1024)).Bytes())
+		if _, err := ReadEnvelope(path, 50); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: ErrCorrupt kutilgan edi: %v", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// MPEG audio versiyalari (sarlavhadagi 2 bit)
+const (
+	mpeg25 = 0
+	mpeg2  = 2
+	mpeg1  = 3
+)
+
+// kbit/s: [v1 L1, v1 L2, v1 L3, v2 L1, v2 L2/L3][index]
+var mp3Bitrates = [5][16]int{
+	{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+}
+
+var mp3SampleRates = map[int][3]int{
+	mpeg1:  {44100, 48000, 32000},
+	mpeg2:  {22050, 24000, 16000},
+	mpeg25: {11025, 12000, 8000},
+}
+
+// mp3Header - freym sarlavhasi (4 bayt)
+type mp3Header struct {
+	Version     int
+	Layer       int // 1, 2, 3
+	CRC         bool
+	Bitrate     int // bit/s
+	SampleRate  int
+	Padding     bool
+	ChannelMode int // 0 stereo, 1 joint stereo, 2 dual, 3 mono
+	ModeExt     int
+}
+
+func isFrameSync(b0, b1 byte) bool {
+	return b0 == 0xFF && b1&0xE0 == 0xE0
+}
+
+// parseMP3Header - 4 baytni tekshiradi; yaroqsiz bo'lsa ok=false
+func parseMP3Header(b []byte) (h mp3Header, ok bool) {
+	if len(b) < 4 || !isFrameSync(b[0], b[1]) {
+		return h, false
+	}
+	h.Version = int(b[1]>>3) & 3
+	layerBits := int(b[1]>>1) & 3
+	bitrateIdx := int(b[2] >> 4)
+	rateIdx := int(b[2]>>2) & 3
+	if h.Version == 1 || layerBits == 0 || bitrateIdx == 0 || bitrateIdx == 15 || rateIdx == 3 {
+		// Zaxira qiymatlar; "free format" (bitrate 0) ham qo'llab-quvvatlanmaydi
+		return h, false
+	}
+	h.Layer = 4 - layerBits
+	h.CRC = b[1]&1 == 0
+
+	table := 4
+	if h.Version == mpeg1 {
+		table = h.Layer - 1
+	} else if h.Layer == 1 {
+		table = 3
+	}
+	h.Bitrate = mp3Bitrates[table][bitrateIdx] * 1000
+	h.SampleRate = mp3SampleRates[h.Version][rateIdx]
+	h.Padding = b[2]&2 != 0
+	h.ChannelMode = int(b[3] >> 6)
+	h.ModeExt = int(b[3]>>4) & 3
+	return h, true
+}
+
+func (h mp3Header) Channels() int {
+	if h.ChannelMode == 3 {
+		return 1
+	}
+	return 2
+}
+
+// Samples - bitta freymdagi namunalar (kanal boshiga)
+func (h mp3Header) Samples() int {
+	switch {
+	case h.Layer == 1:
+		return 384
+	case h.Layer == 3 && h.Version != mpeg1:
+		return 576
+	}
+	return 1152
+}
+
+// FrameSize - sarlavha bilan birga freym hajmi (bayt)
+func (h mp3Header) FrameSize() int {
+	pad := 0
+	if h.Padding {
+		pad = 1
+	}
+	if h.Layer == 1 {
+		return (12*h.Bitrate/h.SampleRate + pad) * 4
+	}
+	return h.Samples()/8*h.Bitrate/h.SampleRate + pad
+}
+
+// skipID3v2 - fayl boshidagi ID3v2 tegini o'tkazib yuboradi
+func skipID3v2(r *bufio.Reader) error {
+	head, err := r.Peek(10)
+	if err != nil || string(head[0:3]) != "ID3" {
+		return nil
+	}
+	// Hajm "synchsafe" butun son: har baytning 7 biti
+	size := int64(head[6]&0x7F)<<21 | int64(head[7]&0x7F)<<14 | int64(head[8]&0x7F)<<7 | int64(head[9]&0x7F)
+	if head[5]&0x10 != 0 {
+		size += 10 // footer
+	}
+	_, err = io.CopyN(io.Discard, r, 10+size)
+	if err != nil {
+		return corrupt("ID3 tegi to'liq emas")
+	}
+	return nil
+}
+
+// scanMP3 - barcha freymlarni ketma-ket o'qiydi. Sinxronizatsiya yo'qolsa keyingi yaroqli
+// sarlavhagacha baytlar "junk" sifatida sanaladi. fn nil bo'lmasa har bir to'liq freym uchun chaqiriladi.
+func scanMP3(r *bufio.Reader, fn func(h mp3Header, frame []byte)) (*Info, error) {
+	if err := skipID3v2(r); err != nil {
+		return nil, err
+	}
+
+	info := &Info{Format: "mp3", Codec: "mp3"}
+	var first *mp3Header
+	var samples, frameBytes int64
+	frame := make([]byte, 0, 4096)
+
+	for {
+		b, err := r.Peek(4)
+		if len(b) < 4 {
+			info.JunkBytes += int64(len(b))
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+		// ID3v1 tegi (fayl oxirida 128 bayt)
+		if string(b[0:3]) == "TAG" {
+			if rest, _ := r.Peek(129); len(rest) == 128 {
+				break
+			}
+		}
+
+		h, ok := parseMP3Header(b)
+		// Birinchi freymdan keyin versiya/qatlam/chastota o'zgarmasligi kerak (soxta sinxronizatsiyaga qarshi)
+		if ok && first != nil && (h.Version != first.Version || h.Layer != first.Layer || h.SampleRate != first.SampleRate) {
+			ok = false
+		}
+		if !ok {
+			r.Discard(1)
+			info.JunkBytes++
+			continue
+		}
+
+		size := h.FrameSize()
+		frame = frame[:0]
+		if cap(frame) < size {
+			frame = make([]byte, 0, size)
+		}
+		frame = frame[:size]
+		n, err := io.ReadFull(r, frame)
+		if err != nil {
+			// Oxirgi freym kesilgan
+			info.Truncated = true
+			info.JunkBytes += int64(n)
+			break
+		}
+
+		if first == nil {
+			hc := h
+			first = &hc
+			// Xing/Info sarlavhali birinchi freym audio emas (VBR ma'lumoti)
+			if isXingFrame(h, frame) {
+				continue
+			}
+		}
+		info.Frames++
+		samples += int64(h.Samples())
+		frameBytes += int64(size)
+		if fn != nil {
+			fn(h, frame)
+		}
+	}
+
+	if first == nil || info.Frames == 0 {
+		return nil, corrupt("MP3 freymlari topilmadi")
+	}
+	info.SampleRate = first.SampleRate
+	info.Channels = first.Channels()
+	info.Duration = time.Duration(samples) * time.Second / time.Duration(first.SampleRate)
+	if info.Duration > 0 {
+		info.Bitrate = int(frameBytes * 8 * int64(time.Second) / int64(info.Duration))
+	}
+	return info, nil
+}
+
+func probeMP3(r *bufio.Reader) (*Info, error) {
+	return scanMP3(r, nil)
+}
+
+// sideInfoOffset - sarlavha (va CRC) dan keyingi side info boshi
+func sideInfoOffset(h mp3Header) int {
+	if h.CRC {
+		return 6
+	}
+	return 4
+}
+
+// sideInfoSize - Layer III side info hajmi (bayt)
+func sideInfoSize(h mp3Header) int {
+	mono := h.Channels() == 1
+	switch {
+	case h.Version == mpeg1 && mono:
+		return 17
+	case h.Version == mpeg1:
+		return 32
+	case mono:
+		return 9
+	}
+	return 17
+}
+
+func isXingFrame(h mp3Header, frame []byte) bool {
+	if h.Layer != 3 {
+		return false
+	}
+	off := sideInfoOffset(h) + sideInfoSize(h)
+	if len(frame) < off+4 {
+		return false
+	}
+	tag := string(frame[off : off+4])
+	if tag == "Xing" || tag == "Info" {
+		return true
+	}
+	// VBRI (Fraunhofer) har doim side info dan keyin 32 baytda
+	return len(frame) >= 36+4 && binary.BigEndian.Uint32(frame[36:40]) == 0x56425249
+}
@@ -0,0 +1,238 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// MPEG1 Layer III, CRC siz, 128 kbit/s, 44.1 kHz, stereo: 417 bayt (padding bilan 418)
+var mp3Stereo = []byte{0xFF, 0xFB, 0x90, 0x00}
+
+func mp3Frame(header []byte) []byte {
+	h, ok := parseMP3Header(header)
+	if !ok {
+		panic("yaroqsiz sarlavha")
+	}
+	frame := make([]byte, h.FrameSize())
+	copy(frame, header)
+	return frame
+}
+
+func mp3Frames(n int) []byte {
+	var b []byte
+	for i := 0; i < n; i++ {
+		b = append(b, mp3Frame(mp3Stereo)...)
+	}
+	return b
+}
+
+// id3v2 - hajmi "synchsafe" yozilgan bo'sh ID3v2 tegi
+func id3v2(size int) []byte {
+	tag := []byte{'I', 'D', '3', 4, 0, 0, byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(tag, make([]byte, size)...)
+}
+
+func scanMP3Bytes(b []byte) (*Info, error) {
+	return scanMP3(bufio.NewReader(bytes.NewReader(b)), nil)
+}
+
+func TestParseMP3Header(t *testing.T) {
+	tests := []struct {
+		name     string
+		b        []byte
+		ok       bool
+		bitrate  int
+		rate     int
+		size     int
+		samples  int
+		channels int
+	}{
+		{"mpeg1 l3", mp3Stereo, true, 128000, 44100, 417, 1152, 2},
+		{"padding, mono", []byte{0xFF, 0xFB, 0x92, 0xC0}, true, 128000, 44100, 418, 1152, 1},
+		{"crc bilan", []byte{0xFF, 0xFA, 0x90, 0x00}, true, 128000, 44100, 417, 1152, 2},
+		// MPEG2 Layer III: 576 namuna, 22.05 kHz, 64 kbit/s
+		{"mpeg2 l3", []byte{0xFF, 0xF3, 0x80, 0x00}, true, 64000, 22050, 208, 576, 2},
+		// MPEG2.5 Layer III, 8 kHz, 8 kbit/s (telefoniya)
+		{"mpeg2.5 l3", []byte{0xFF, 0xE3, 0x18, 0xC0}, true, 8000, 8000, 72, 576, 1},
+		// Layer I: (12*bitrate/rate + pad) * 4
+		{"mpeg1 l1", []byte{0xFF, 0xFF, 0x90, 0x00}, true, 288000, 44100, 312, 384, 2},
+		{"sinxronizatsiya yo'q", []byte{0xFF, 0x1B, 0x90, 0x00}, false, 0, 0, 0, 0, 0},
+		{"zaxira versiya", []byte{0xFF, 0xEB, 0x90, 0x00}, false, 0, 0, 0, 0, 0},
+		{"zaxira qatlam", []byte{0xFF, 0xF9, 0x90, 0x00}, false, 0, 0, 0, 0, 0},
+		{"free format", []byte{0xFF, 0xFB, 0x00, 0x00}, false, 0, 0, 0, 0, 0},
+		{"bitrate 15", []byte{0xFF, 0xFB, 0xF0, 0x00}, false, 0, 0, 0, 0, 0},
+		{"zaxira chastota", []byte{0xFF, 0xFB, 0x9C, 0x00}, false, 0, 0, 0, 0, 0},
+		{"qisqa", []byte{0xFF, 0xFB, 0x90}, false, 0, 0, 0, 0, 0},
+	}
+	for _, tc := range tests {
+		h, ok := parseMP3Header(tc.b)
+		if ok != tc.ok {
+			t.Errorf("%s: ok %v, kutilgan %v", tc.name, ok, tc.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if h.Bitrate != tc.bitrate || h.SampleRate != tc.rate || h.FrameSize() != tc.size ||
+			h.Samples() != tc.samples || h.Channels() != tc.channels {
+			t.Errorf("%s: bitrate %d, chastota %d, hajm %d, namuna %d, kanal %d", tc.name,
+				h.Bitrate, h.SampleRate, h.FrameSize(), h.Samples(), h.Channels())
+		}
+	}
+}
+
+func TestScanMP3(t *testing.T) {
+	const n = 100
+	wantDuration := time.Duration(n*1152) * time.Second / 44100
+
+	t.Run("ID3v2 va freymlar", func(t *testing.T) {
+		info, err := scanMP3Bytes(append(id3v2(300), mp3Frames(n)...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Frames != n || info.Duration != wantDuration || info.SampleRate != 44100 || info.Channels != 2 {
+			t.Errorf("noto'g'ri: %+v", info)
+		}
+		// 417 baytli freymlar 128 kbit/s dan biroz past
+		if info.Bitrate < 127000 || info.Bitrate > 128000 {
+			t.Errorf("bitrate %d", info.Bitrate)
+		}
+		if info.JunkBytes != 0 || info.Truncated {
+			t.Errorf("junk %d, kesilgan %v", info.JunkBytes, info.Truncated)
+		}
+	})
+
+	t.Run("Xing freymi hisoblanmaydi", func(t *testing.T) {
+		for _, tag := range []string{"Xing", "Info"} {
+			xing := mp3Frame(mp3Stereo)
+			copy(xing[4+32:], tag)
+			info, err := scanMP3Bytes(append(xing, mp3Frames(n)...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Frames != n || info.Duration != wantDuration {
+				t.Errorf("%s: freymlar %d, davomiylik %s", tag, info.Frames, info.Duration)
+			}
+		}
+	})
+
+	t.Run("VBRI freymi hisoblanmaydi", func(t *testing.T) {
+		vbri := mp3Frame(mp3Stereo)
+		copy(vbri[36:], "VBRI")
+		info, err := scanMP3Bytes(append(vbri, mp3Frames(n)...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Frames != n {
+			t.Errorf("freymlar %d", info.Frames)
+		}
+	})
+
+	t.Run("oradagi junk", func(t *testing.T) {
+		b := append(mp3Frames(2), 0x00, 0x11, 0x22, 0xFF, 0x00)
+		b = append(b, mp3Frames(3)...)
+		info, err := scanMP3Bytes(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Frames != 5 || info.JunkBytes != 5 {
+			t.Errorf("freymlar %d, junk %d", info.Frames, info.JunkBytes)
+		}
+	})
+
+	t.Run("boshqa versiyadagi soxta sarlavha", func(t *testing.T) {
+		// MPEG2 sarlavhasi MPEG1 oqimi ichida - soxta sinxronizatsiya, junk sifatida o'tkaziladi
+		b := append(mp3Frames(2), 0xFF, 0xF3, 0x80, 0x00)
+		b = append(b, mp3Frames(2)...)
+		info, err := scanMP3Bytes(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Frames != 4 || info.JunkBytes != 4 {
+			t.Errorf("freymlar %d, junk %d", info.Frames, info.JunkBytes)
+		}
+	})
+
+	t.Run("kesilgan oxirgi freym", func(t *testing.T) {
+		b := mp3Frames(3)
+		info, err := scanMP3Bytes(b[:len(b)-100])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Frames != 2 || !info.Truncated || info.JunkBytes != 317 {
+			t.Errorf("freymlar %d, kesilgan %v, junk %d", info.Frames, info.Truncated, info.JunkBytes)
+		}
+	})
+
+	t.Run("ID3v1 tegi junk emas", func(t *testing.T) {
+		tag := append([]byte("TAG"), make([]byte, 125)...)
+		info, err := scanMP3Bytes(append(mp3Frames(3), tag...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Frames != 3 || info.JunkBytes != 0 {
+			t.Errorf("freymlar %d, junk %d", info.Frames, info.JunkBytes)
+		}
+	})
+
+	t.Run("fn har freym uchun", func(t *testing.T) {
+		var calls int
+		_, err := scanMP3(bufio.NewReader(bytes.NewReader(mp3Frames(4))), func(h mp3Header, frame []byte) {
+			if len(frame) != h.FrameSize() {
+				t.Errorf("freym hajmi %d", len(frame))
+			}
+			calls++
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if calls != 4 {
+			t.Errorf("chaqiruvlar %d", calls)
+		}
+	})
+}
+
+func TestScanMP3Corrupt(t *testing.T) {
+	tests := map[string][]byte{
+		"freym yo'q":           append(id3v2(10), 0x00, 0x11, 0x22),
+		"faqat Xing":           func() []byte { f := mp3Frame(mp3Stereo); copy(f[36:], "Xing"); return f }(),
+		"ID3 to'liq emas":      id3v2(300)[:100],
+		"faqat kesilgan freym": mp3Frames(1)[:200],
+	}
+	for name, b := range tests {
+		if _, err := scanMP3Bytes(b); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: ErrCorrupt kutilgan edi: %v", name, err)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, b []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	info, err := Probe(write("a.mp3", append(id3v2(20), mp3Frames(10)...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Format != "mp3" || info.Frames != 10 || info.Size != int64(30+10*417) {
+		t.Errorf("mp3: %+v", info)
+	}
+
+	if _, err := Probe(write("empty.mp3", nil)); !errors.Is(err, ErrEmpty) {
+		t.Errorf("ErrEmpty kutilgan edi: %v", err)
+	}
+	if _, err := Probe(write("a.txt", []byte("salom, dunyo"))); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("ErrUnknownFormat kutilgan edi: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package audio
+
+import "sort"
+
+// Peaks - envelope ni ko'pi bilan maxBuckets ta bo'lakka qisqartiradi (har bo'lakda eng baland daraja)
+// va fayl bo'yicha normallashtiradi: shovqin darajasi (5-persentil) 0, eng baland nuqta 255.
+// Natija: bitta bo'lak davomiyligi (ms) va kanal bo'yicha qiymatlar.
+func Peaks(env *Envelope, maxBuckets int) (int, [][]uint8) {
+	n := env.Len()
+	if n == 0 || maxBuckets <= 0 {
+		return env.WindowMs, nil
+	}
+	per := (n + maxBuckets - 1) / maxBuckets
+	buckets := (n + per - 1) / per
+
+	var all []float64
+	for _, levels := range env.Channels {
+		all = append(all, levels...)
+	}
+	sort.Float64s(all)
+	lo, hi := all[len(all)/20], all[len(all)-1]
+
+	out := make([][]uint8, len(env.Channels))
+	for c, levels := range env.Channels {
+		out[c] = make([]uint8, buckets)
+		for b := 0; b < buckets; b++ {
+			peak := float64(SilenceDB)
+			for i := b * per; i < (b+1)*per && i < n; i++ {
+				if levels[i] > peak {
+					peak = levels[i]
+				}
+			}
+			if hi > lo && peak > lo {
+				out[c][b] = uint8((peak - lo) / (hi - lo) * 255)
+			}
+		}
+	}
+	return env.WindowMs * per, out
+}
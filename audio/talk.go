@@ -0,0 +1,123 @@
+package audio
+
+import "sort"
+
+// Nutq/jimlik aniqlash parametrlari
+const (
+	speechAboveFloorDB = 12   // shovqin darajasidan shuncha baland bo'lsa - nutq
+	minSpeechDB        = -55  // aniq (dekodlangan) darajada bundan past - har doim jimlik
+	speechHangoverMs   = 300  // so'zlar orasidagi qisqa pauzalar nutq hisoblanadi
+	minSilenceMs       = 1000 // bundan qisqa umumiy jimlik ro'yxatga kiritilmaydi
+	monologueGapMs     = 2000 // monolog shu uzunlikdagi jimlikdan keyin tugaydi
+)
+
+// Segment - vaqt oralig'i (ms)
+type Segment struct {
+	StartMs int64 `json:"start_ms"`
+	EndMs   int64 `json:"end_ms"`
+}
+
+// TalkStats - suhbat dinamikasi: har bir kanal gapirgan vaqt, umumiy jimlik, bir vaqtda gapirish va eng uzun monolog
+type TalkStats struct {
+	DurationMs         int64
+	TalkMs             []int64 // kanal bo'yicha (stereo: 0 - operator, 1 - mijoz)
+	SilenceMs          int64
+	OverlapMs          int64 // ikkala kanal bir vaqtda gapirgan
+	LongestMonologueMs int64
+	MonologueChannel   int
+	Silences           []Segment
+}
+
+// AnalyzeTalk - envelope bo'yicha nutq faolligini aniqlaydi. Chegara har bir kanal uchun
+// alohida: shovqin darajasi (10-persentil) + speechAboveFloorDB.
+func AnalyzeTalk(env *Envelope) *TalkStats {
+	n := env.Len()
+	w := int64(env.WindowMs)
+	stats := &TalkStats{DurationMs: int64(n) * w, TalkMs: make([]int64, len(env.Channels))}
+	if n == 0 {
+		return stats
+	}
+
+	active := make([][]bool, len(env.Channels))
+	for c, levels := range env.Channels {
+		active[c] = voiceActivity(levels, env.Approximate, env.WindowMs)
+	}
+
+	silenceStart := -1
+	endSilence := func(i int) {
+		if silenceStart >= 0 && int64(i-silenceStart)*w >= minSilenceMs {
+			stats.Silences = append(stats.Silences, Segment{StartMs: int64(silenceStart) * w, EndMs: int64(i) * w})
+		}
+		silenceStart = -1
+	}
+
+	// Monolog: bitta kanal gapiradi, boshqasi jim; qisqa pauzalar uzmaydi
+	speaker, runStart, lastActive := -1, 0, 0
+	endRun := func() {
+		if speaker >= 0 {
+			if d := int64(lastActive-runStart+1) * w; d > stats.LongestMonologueMs {
+				stats.LongestMonologueMs = d
+				stats.MonologueChannel = speaker
+			}
+		}
+		speaker = -1
+	}
+
+	for i := 0; i < n; i++ {
+		talking := 0
+		who := -1
+		for c := range active {
+			if active[c][i] {
+				stats.TalkMs[c] += w
+				talking++
+				who = c
+			}
+		}
+
+		switch {
+		case talking == 0:
+			stats.SilenceMs += w
+			if silenceStart < 0 {
+				silenceStart = i
+			}
+			if speaker >= 0 && int64(i-lastActive)*w >= monologueGapMs {
+				endRun()
+			}
+		case talking == 1:
+			endSilence(i)
+			if who != speaker {
+				endRun()
+				speaker, runStart = who, i
+			}
+			lastActive = i
+		default:
+			endSilence(i)
+			stats.OverlapMs += w
+			endRun()
+		}
+	}
+	endSilence(n)
+	endRun()
+	return stats
+}
+
+// voiceActivity - kanal darajalari bo'yicha nutq bor/yo'q (hangover bilan)
+func voiceActivity(levels []float64, approximate bool, windowMs int) []bool {
+	sorted := append([]float64(nil), levels...)
+	sort.Float64s(sorted)
+	threshold := sorted[len(sorted)/10] + speechAboveFloorDB
+	if !approximate && threshold < minSpeechDB {
+		threshold = minSpeechDB
+	}
+
+	hangover := speechHangoverMs / windowMs
+	active := make([]bool, len(levels))
+	last := -1 << 30
+	for i, l := range levels {
+		if l > threshold && l > SilenceDB {
+			last = i
+		}
+		active[i] = i-last <= hangover
+	}
+	return active
+}
@@ -0,0 +1,160 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// WAVE formatlari (fmt bo'lagidagi audioFormat)
+const (
+	wavPCM        = 1
+	wavFloat      = 3
+	wavALaw       = 6
+	wavMuLaw      = 7
+	wavExtensible = 0xFFFE
+)
+
+// wavFmtMax - fmt bo'lagidan o'qiladigan qism (WAVE_FORMAT_EXTENSIBLE bilan 40 bayt)
+const wavFmtMax = 40
+
+// wavFormat - "fmt " bo'lagi
+type wavFormat struct {
+	Tag           uint16
+	Channels      int
+	SampleRate    int
+	ByteRate      int
+	BlockAlign    int
+	BitsPerSample int
+}
+
+func (f wavFormat) codec() string {
+	switch f.Tag {
+	case wavPCM:
+		return "pcm"
+	case wavFloat:
+		return "float"
+	case wavALaw:
+		return "alaw"
+	case wavMuLaw:
+		return "ulaw"
+	}
+	return "unknown"
+}
+
+// validate - fmt qiymatlari o'zaro mos bo'lishi kerak: chiziqli kodeklarda (PCM, float, G.711) blok
+// har bir kanalning bitta namunasidan iborat. Mos kelmasa namunalarni kesib olish blokdan tashqariga chiqadi.
+func (f wavFormat) validate() error {
+	if f.Channels <= 0 || f.SampleRate <= 0 || f.BlockAlign <= 0 {
+		return corrupt("fmt qiymatlari noto'g'ri (kanal %d, chastota %d, blok %d)", f.Channels, f.SampleRate, f.BlockAlign)
+	}
+	if f.codec() == "unknown" {
+		// ADPCM va boshqalarda blok bir necha namunadan iborat - tekshirib bo'lmaydi
+		return nil
+	}
+	if f.BitsPerSample <= 0 || f.BitsPerSample%8 != 0 || f.BlockAlign != f.Channels*f.BitsPerSample/8 {
+		return corrupt("fmt qiymatlari mos emas (kanal %d, %d bit, blok %d)", f.Channels, f.BitsPerSample, f.BlockAlign)
+	}
+	return nil
+}
+
+// readWAVHeader - RIFF bo'laklarini "data" gacha o'qiydi; r data boshiga turadi.
+// dataSize - sarlavhadagi qiymat, remaining - fayldagi haqiqiy qolgan baytlar.
+func readWAVHeader(r io.Reader, fileSize int64) (fmtChunk wavFormat, dataSize, remaining int64, err error) {
+	var riff [12]byte
+	if _, err = io.ReadFull(r, riff[:]); err != nil {
+		return fmtChunk, 0, 0, corrupt("RIFF sarlavhasi to'liq emas")
+	}
+	offset := int64(12)
+	haveFmt := false
+
+	for {
+		var hdr [8]byte
+		if _, err = io.ReadFull(r, hdr[:]); err != nil {
+			return fmtChunk, 0, 0, corrupt("data bo'lagi topilmadi")
+		}
+		offset += 8
+		id := string(hdr[0:4])
+		size := int64(binary.LittleEndian.Uint32(hdr[4:8]))
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return fmtChunk, 0, 0, corrupt("fmt bo'lagi juda qisqa (%d)", size)
+			}
+			// Hajm fayldan olinadi: faqat kerakli 40 baytgacha o'qiladi, qolgani o'tkazib yuboriladi
+			// (buzilgan sarlavha gigabaytlab xotira ajratishga olib kelmasin)
+			buf := make([]byte, min(size, wavFmtMax))
+			if _, err = io.ReadFull(r, buf); err != nil {
+				return fmtChunk, 0, 0, corrupt("fmt bo'lagi to'liq emas")
+			}
+			if rest := size + size%2 - int64(len(buf)); rest > 0 {
+				if _, err = io.CopyN(io.Discard, r, rest); err != nil {
+					return fmtChunk, 0, 0, corrupt("fmt bo'lagi to'liq emas")
+				}
+			}
+			fmtChunk = wavFormat{
+				Tag:           binary.LittleEndian.Uint16(buf[0:2]),
+				Channels:      int(binary.LittleEndian.Uint16(buf[2:4])),
+				SampleRate:    int(binary.LittleEndian.Uint32(buf[4:8])),
+				ByteRate:      int(binary.LittleEndian.Uint32(buf[8:12])),
+				BlockAlign:    int(binary.LittleEndian.Uint16(buf[12:14])),
+				BitsPerSample: int(binary.LittleEndian.Uint16(buf[14:16])),
+			}
+			// WAVE_FORMAT_EXTENSIBLE: haqiqiy format SubFormat GUID ning birinchi 2 baytida
+			if fmtChunk.Tag == wavExtensible && size >= 26 {
+				fmtChunk.Tag = binary.LittleEndian.Uint16(buf[24:26])
+			}
+			haveFmt = true
+			offset += size + size%2
+
+		case "data":
+			if !haveFmt {
+				return fmtChunk, 0, 0, corrupt("fmt bo'lagi data dan oldin kelmagan")
+			}
+			return fmtChunk, size, fileSize - offset, nil
+
+		default:
+			skip := size + size%2
+			if _, err = io.CopyN(io.Discard, r, skip); err != nil {
+				return fmtChunk, 0, 0, corrupt("%q bo'lagi to'liq emas", id)
+			}
+			offset += skip
+		}
+	}
+}
+
+func probeWAV(r io.Reader, fileSize int64) (*Info, error) {
+	f, dataSize, remaining, err := readWAVHeader(r, fileSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	byteRate := f.ByteRate
+	if byteRate <= 0 {
+		byteRate = f.SampleRate * f.BlockAlign
+	}
+
+	info := &Info{
+		Format:     "wav",
+		Codec:      f.codec(),
+		Bitrate:    byteRate * 8,
+		SampleRate: f.SampleRate,
+		Channels:   f.Channels,
+	}
+
+	// Oqim sifatida yozilgan fayllarda data hajmi 0 yoki 0xFFFFFFFF bo'lishi mumkin
+	if dataSize == 0 || dataSize == 0xFFFFFFFF {
+		dataSize = remaining
+	} else if dataSize > remaining {
+		info.Truncated = true
+		dataSize = remaining
+	}
+	if dataSize <= 0 {
+		return nil, ErrEmpty
+	}
+	info.Duration = time.Duration(dataSize) * time.Second / time.Duration(byteRate)
+	return info, nil
+}
@@ -0,0 +1,152 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// wavFile - RIFF/WAVE fayl yig'uvchi; bo'laklar toq hajmda bo'lsa to'ldiruvchi bayt qo'shiladi
+type wavFile struct{ bytes.Buffer }
+
+func newWAV() *wavFile {
+	w := &wavFile{}
+	w.WriteString("RIFF")
+	binary.Write(w, binary.LittleEndian, uint32(0)) // yozuvchilar ko'pincha to'g'ri qo'ymaydi, tekshirilmaydi
+	w.WriteString("WAVE")
+	return w
+}
+
+func (w *wavFile) chunk(id string, size uint32, body []byte) *wavFile {
+	w.WriteString(id)
+	binary.Write(w, binary.LittleEndian, size)
+	w.Write(body)
+	if len(body)%2 == 1 {
+		w.WriteByte(0)
+	}
+	return w
+}
+
+func pcmFmt(tag uint16, channels, rate, bits int) []byte {
+	b := make([]byte, 16)
+	align := channels * bits / 8
+	binary.LittleEndian.PutUint16(b[0:], tag)
+	binary.LittleEndian.PutUint16(b[2:], uint16(channels))
+	binary.LittleEndian.PutUint32(b[4:], uint32(rate))
+	binary.LittleEndian.PutUint32(b[8:], uint32(rate*align))
+	binary.LittleEndian.PutUint16(b[12:], uint16(align))
+	binary.LittleEndian.PutUint16(b[14:], uint16(bits))
+	return b
+}
+
+func probeWAVBytes(b []byte) (*Info, error) {
+	return probeWAV(bytes.NewReader(b), int64(len(b)))
+}
+
+func TestProbeWAV(t *testing.T) {
+	// 8 kHz, mono, 16 bit: 16000 bayt/s; 2 soniya
+	data := make([]byte, 32000)
+	fmt16 := pcmFmt(wavPCM, 1, 8000, 16)
+
+	t.Run("pcm", func(t *testing.T) {
+		w := newWAV().chunk("fmt ", 16, fmt16).chunk("data", uint32(len(data)), data)
+		info, err := probeWAVBytes(w.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Codec != "pcm" || info.SampleRate != 8000 || info.Channels != 1 || info.Bitrate != 128000 {
+			t.Errorf("noto'g'ri: %+v", info)
+		}
+		if info.Duration != 2*time.Second || info.Truncated {
+			t.Errorf("davomiylik %s, kesilgan %v", info.Duration, info.Truncated)
+		}
+	})
+
+	t.Run("noma'lum bo'laklar va toq hajm", func(t *testing.T) {
+		w := newWAV().chunk("LIST", 5, []byte("INFOx")).chunk("fmt ", 16, fmt16).
+			chunk("fact", 4, []byte{1, 2, 3, 4}).chunk("data", uint32(len(data)), data)
+		info, err := probeWAVBytes(w.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Duration != 2*time.Second {
+			t.Errorf("davomiylik %s", info.Duration)
+		}
+	})
+
+	t.Run("uzun fmt va extensible", func(t *testing.T) {
+		// WAVE_FORMAT_EXTENSIBLE (40 bayt), SubFormat - µ-law; 44 baytlik bo'lakda ortiqcha 4 bayt o'tkazib yuboriladi
+		ext := make([]byte, 44)
+		copy(ext, pcmFmt(wavExtensible, 1, 8000, 8))
+		binary.LittleEndian.PutUint16(ext[16:], 22)
+		binary.LittleEndian.PutUint16(ext[24:], wavMuLaw)
+		w := newWAV().chunk("fmt ", 44, ext).chunk("data", 8000, make([]byte, 8000))
+		info, err := probeWAVBytes(w.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Codec != "ulaw" || info.Duration != time.Second {
+			t.Errorf("codec %s, davomiylik %s", info.Codec, info.Duration)
+		}
+	})
+
+	t.Run("oqim: data hajmi noma'lum", func(t *testing.T) {
+		for _, size := range []uint32{0, 0xFFFFFFFF} {
+			w := newWAV().chunk("fmt ", 16, fmt16).chunk("data", size, data)
+			info, err := probeWAVBytes(w.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Duration != 2*time.Second || info.Truncated {
+				t.Errorf("hajm %#x: davomiylik %s, kesilgan %v", size, info.Duration, info.Truncated)
+			}
+		}
+	})
+
+	t.Run("kesilgan", func(t *testing.T) {
+		w := newWAV().chunk("fmt ", 16, fmt16).chunk("data", 64000, data)
+		info, err := probeWAVBytes(w.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.Truncated || info.Duration != 2*time.Second {
+			t.Errorf("davomiylik %s, kesilgan %v", info.Duration, info.Truncated)
+		}
+	})
+
+	t.Run("bo'sh data", func(t *testing.T) {
+		w := newWAV().chunk("fmt ", 16, fmt16).chunk("data", 0, nil)
+		if _, err := probeWAVBytes(w.Bytes()); !errors.Is(err, ErrEmpty) {
+			t.Errorf("ErrEmpty kutilgan edi: %v", err)
+		}
+	})
+}
+
+func TestProbeWAVCorrupt(t *testing.T) {
+	fmt16 := pcmFmt(wavPCM, 1, 8000, 16)
+	// PCM16 stereo, lekin BlockAlign=2 (4 bo'lishi kerak)
+	misaligned := pcmFmt(wavPCM, 2, 8000, 16)
+	binary.LittleEndian.PutUint16(misaligned[12:], 2)
+	tests := map[string][]byte{
+		"RIFF qisqa":          []byte("RIFF\x00\x00"),
+		"data yo'q":           newWAV().chunk("fmt ", 16, fmt16).Bytes(),
+		"fmt data dan keyin":  newWAV().chunk("data", 4, []byte{0, 0, 0, 0}).chunk("fmt ", 16, fmt16).Bytes(),
+		"fmt qisqa":           newWAV().chunk("fmt ", 14, fmt16[:14]).chunk("data", 2, []byte{0, 0}).Bytes(),
+		"fmt to'liq emas":     newWAV().chunk("fmt ", 16, fmt16[:10]).Bytes(),
+		"bo'lak to'liq emas":  newWAV().chunk("LIST", 100, []byte("INFO")).Bytes(),
+		"kanal yo'q":          newWAV().chunk("fmt ", 16, pcmFmt(wavPCM, 0, 8000, 16)).chunk("data", 2, []byte{0, 0}).Bytes(),
+		"chastota yo'q":       newWAV().chunk("fmt ", 16, pcmFmt(wavPCM, 1, 0, 16)).chunk("data", 2, []byte{0, 0}).Bytes(),
+		"blok mos emas":       newWAV().chunk("fmt ", 16, misaligned).chunk("data", 4, []byte{0, 0, 0, 0}).Bytes(),
+		"bit 0":               newWAV().chunk("fmt ", 16, pcmFmt(wavPCM, 1, 8000, 0)).chunk("data", 2, []byte{0, 0}).Bytes(),
+		"ulkan fmt (4 GiB)":   newWAV().chunk("fmt ", 0xFFFFFFF0, fmt16).Bytes(),
+		"ulkan fmt, to'liq":   newWAV().chunk("fmt ", 0x7FFFFFFF, append(fmt16, make([]byte, 1000)...)).Bytes(),
+		"noto'g'ri bo'lak ID": newWAV().chunk("junk", 0xFFFFFFFF, nil).Bytes(),
+	}
+	for name, b := range tests {
+		if _, err := probeWAVBytes(b); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: ErrCorrupt kutilgan edi: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"bitrix/config"
+)
+
+// configPath - CONFIG_FILE yoki joriy papkadagi config.toml (bo'lmasa faqat environment)
+func configPath() string {
+	if p := os.Getenv("CONFIG_FILE"); p != "" {
+		return p
+	}
+	if _, err := os.Stat("config.toml"); err == nil {
+		return "config.toml"
+	}
+	return ""
+}
+
+// runConfigPrint - `bitrix config print --redacted`: amaldagi sozlamalar (xatolar bo'lsa ham chiqariladi)
+func runConfigPrint(path string, args []string) error {
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redacted := fs.Bool("redacted", false, "maxfiy qiymatlarni yashirish")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, loadErr := config.Load(path)
+	if cfg != nil {
+		if err := cfg.Print(os.Stdout, *redacted); err != nil {
+			return err
+		}
+	}
+	return loadErr
+}
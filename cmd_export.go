@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"bitrix/models"
+	"bitrix/service"
+)
+
+// runExport - `bitrix export --portal X --format xlsx --from 2024-01-01 --to 2024-01-31 --out calls.xlsx`
+func runExport(ctx context.Context, db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	portal := fs.String("portal", "", "portal member_id (majburiy)")
+	format := fs.String("format", "csv", "csv yoki xlsx")
+	from := fs.String("from", "", "boshlanish sanasi, YYYY-MM-DD")
+	to := fs.String("to", "", "tugash sanasi (shu kun ham kiradi), YYYY-MM-DD")
+	user := fs.String("user", "", "xodim ID (portal_user_id)")
+	phone := fs.String("phone", "", "telefon raqam (qismi)")
+	callType := fs.Int("type", 0, "qo'ng'iroq turi (call_type: 1 - chiquvchi, 2 - kiruvchi, ...)")
+	out := fs.String("out", "", "natija fayli (bo'sh bo'lsa stdout)")
+	baseURL := fs.String("base-url", "", "yozuv havolalari uchun server manzili, masalan https://calls.example.com")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *portal == "" {
+		return fmt.Errorf("--portal majburiy")
+	}
+
+	filter := models.CallFilter{UserID: *user, Phone: *phone, CallType: *callType}
+	if *from != "" {
+		t, err := time.Parse("2006-01-02", *from)
+		if err != nil {
+			return fmt.Errorf("--from noto'g'ri: %v", err)
+		}
+		filter.From = t
+	}
+	if *to != "" {
+		t, err := time.Parse("2006-01-02", *to)
+		if err != nil {
+			return fmt.Errorf("--to noto'g'ri: %v", err)
+		}
+		filter.To = t.AddDate(0, 0, 1)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	n, err := service.ExportCalls(ctx, db, *portal, filter, *format, *baseURL, w)
+	if err != nil {
+		return err
+	}
+	cliLog.Info("eksport tugadi", "member_id", *portal, "rows", n)
+	return nil
+}
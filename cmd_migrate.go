@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+
+	schema "bitrix/db"
+	"bitrix/service"
+	"bitrix/storage"
+)
+
+// runMigrate - `bitrix migrate`: qo'llanmagan migratsiyalarni tartib bilan qo'llash.
+// --status faqat ro'yxatni chiqaradi; --baseline mavjud bazada hammasini "qo'llangan" deb belgilaydi.
+// Oxirida umumiy downloads/ papkasidagi eski yozuvlar downloads/<member_id>/ ga ko'chiriladi.
+func runMigrate(ctx context.Context, db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	status := fs.Bool("status", false, "migratsiyalar holatini ko'rsatish")
+	baseline := fs.Bool("baseline", false, "migratsiyalar qo'lda qo'llangan bazani bajarmasdan belgilash")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *status {
+		list, err := storage.ListMigrations(ctx, db, schema.Files)
+		if err != nil {
+			return err
+		}
+		for _, m := range list {
+			state := "kutilmoqda"
+			if m.Applied {
+				state = "qo'llangan"
+			}
+			fmt.Printf("%-32s %s\n", m.Name, state)
+		}
+		return nil
+	}
+
+	done, err := storage.Migrate(ctx, db, schema.Files, *baseline)
+	for _, name := range done {
+		cliLog.Info("migratsiya qo'llandi", "migration", name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(done) == 0 {
+		cliLog.Info("sxema yangi, qo'llanadigan migratsiya yo'q")
+	}
+
+	// Umumiy downloads/ dagi eski fayllar portal papkalariga (SQL migratsiya fayllarni ko'chira olmaydi)
+	moved, dropped, err := service.RelocateLegacyRecordings(ctx, db)
+	if moved > 0 || dropped > 0 {
+		cliLog.Info("eski yozuvlar portal papkalariga ko'chirildi", "moved", moved, "dropped", dropped)
+	}
+	return err
+}
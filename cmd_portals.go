@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"bitrix/models"
+	"bitrix/service"
+	"bitrix/storage"
+)
+
+// runPortals - `bitrix portals list|add|disable|enable|schedule|pause|resume|oauth`
+func runPortals(ctx context.Context, db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("foydalanish: bitrix portals list|add|disable|enable|schedule|pause|resume|oauth")
+	}
+	switch args[0] {
+	case "list":
+		return listPortals(ctx, db)
+	case "add":
+		return addPortal(ctx, db, args[1:])
+	case "schedule":
+		return schedulePortal(ctx, db, args[1:])
+	case "oauth":
+		return setPortalOAuth(ctx, db, args[1:])
+	case "pause", "resume":
+		fs := flag.NewFlagSet("portals "+args[0], flag.ContinueOnError)
+		portal := fs.String("portal", "", "portal member_id (majburiy)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *portal == "" {
+			return fmt.Errorf("--portal majburiy")
+		}
+		ok, err := storage.SetSyncPaused(ctx, db, *portal, args[0] == "pause")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("portal topilmadi: %s", *portal)
+		}
+		if args[0] == "pause" {
+			cliLog.Info("jadval bo'yicha sinxronizatsiya to'xtatildi", "member_id", *portal)
+		} else {
+			cliLog.Info("jadval bo'yicha sinxronizatsiya davom ettirildi", "member_id", *portal)
+		}
+		return nil
+	case "disable", "enable":
+		fs := flag.NewFlagSet("portals "+args[0], flag.ContinueOnError)
+		portal := fs.String("portal", "", "portal member_id (majburiy)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *portal == "" {
+			return fmt.Errorf("--portal majburiy")
+		}
+		ok, err := storage.SetPortalDisabled(ctx, db, *portal, args[0] == "disable")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("portal topilmadi: %s", *portal)
+		}
+		if args[0] == "disable" {
+			cliLog.Info("portal o'chirildi (avtomatik sinxronizatsiya to'xtatildi)", "member_id", *portal)
+		} else {
+			cliLog.Info("portal yoqildi", "member_id", *portal)
+		}
+		return nil
+	}
+	return fmt.Errorf("noma'lum portals buyrug'i: %s", args[0])
+}
+
+func listPortals(ctx context.Context, db *sql.DB) error {
+	portals, err := storage.GetAllPortals(ctx, db)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "MEMBER_ID\tDOMAIN\tFOLDER\tTOKEN\tHOLAT\tOXIRGI SINXRON")
+	for _, p := range portals {
+		token := p.LastUpdate.Add(time.Duration(p.ExpiresIn) * time.Second).Format("2006-01-02 15:04")
+		state := "yoqilgan"
+		if p.Disabled {
+			state = "o'chirilgan"
+		}
+		last := "-"
+		if st, err := storage.GetSyncStatus(ctx, db, p.MemberID); err == nil && st.LastSync != nil {
+			last = st.LastSync.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", p.MemberID, p.Domain, p.FolderID, token, state, last)
+	}
+	return w.Flush()
+}
+
+// schedulePortal - `bitrix portals schedule --portal X --tz Asia/Tashkent --cron "*/5 9-18 * * 1-5" --cron "0 19-23,0-8 * * *"`.
+// --cron berilmasa jadval tozalanadi (sync.interval ishlatiladi).
+func schedulePortal(ctx context.Context, db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("portals schedule", flag.ContinueOnError)
+	portal := fs.String("portal", "", "portal member_id (majburiy)")
+	tz := fs.String("tz", "", "vaqt mintaqasi, masalan Asia/Tashkent (bo'sh bo'lsa o'zgarmaydi)")
+	var exprs []string
+	fs.Func("cron", "cron ifodasi (bir necha marta berish mumkin)", func(v string) error {
+		exprs = append(exprs, v)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *portal == "" {
+		return fmt.Errorf("--portal majburiy")
+	}
+
+	current, err := storage.GetPortalSchedule(ctx, db, *portal)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("portal topilmadi: %s", *portal)
+	}
+	if err != nil {
+		return err
+	}
+	settings := current.SyncSettings
+	settings.Schedule = exprs
+	if *tz != "" {
+		settings.TimeZone = *tz
+	}
+	if err := service.ValidateSyncSettings(&settings); err != nil {
+		return err
+	}
+	if _, err := storage.UpdateSyncSettings(ctx, db, *portal, &settings); err != nil {
+		return err
+	}
+
+	current.SyncSettings = settings
+	next, err := service.NextSyncRun(current, cfg.Sync.Interval, time.Now())
+	if err != nil {
+		return err
+	}
+	cliLog.Info("portal jadvali saqlandi", "member_id", *portal, "timezone", settings.TimeZone,
+		"next_run", next.Format("2006-01-02 15:04 MST"))
+	return nil
+}
+
+// addPortal - ilovani qayta o'rnatmasdan portal qo'shish: refresh token orqali access token olinadi
+func addPortal(ctx context.Context, db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("portals add", flag.ContinueOnError)
+	memberID := fs.String("member-id", "", "portal member_id (majburiy)")
+	domain := fs.String("domain", "", "portal domeni, masalan example.bitrix24.ru (majburiy)")
+	refreshToken := fs.String("refresh-token", "", "OAuth refresh token (majburiy)")
+	endpoint := fs.String("client-endpoint", "", "REST manzili (bo'sh bo'lsa https://<domain>/rest/)")
+	folder := fs.String("folder", "", "yozuvlar papkasi (disk.folder ID)")
+	oauthServer := fs.String("oauth-server", "", "OAuth server (box portal uchun, masalan https://crm.example.uz); bo'sh - bitrix.oauth_server")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *memberID == "" || *domain == "" || *refreshToken == "" {
+		return fmt.Errorf("--member-id, --domain va --refresh-token majburiy")
+	}
+	server, err := service.NormalizeOAuthServer(*oauthServer)
+	if err != nil {
+		return err
+	}
+	if *endpoint == "" {
+		*endpoint = "https://" + *domain + "/rest/"
+	}
+
+	t := &models.TokenInfo{
+		PortalDomain:   *domain,
+		MemberID:       *memberID,
+		RefreshToken:   *refreshToken,
+		ClientEndpoint: *endpoint,
+		OAuthServer:    server,
+	}
+	// Token yangilanishi ham tekshiruv: noto'g'ri refresh token bilan portal saqlanmaydi
+	if err := service.RefreshToken(ctx, db, t, cfg.Bitrix.ClientID, cfg.Bitrix.ClientSecret); err != nil {
+		return err
+	}
+	if *folder != "" {
+		if err := storage.UpdatePortalFolderID(ctx, db, *memberID, *folder); err != nil {
+			return fmt.Errorf("FolderID saqlashda xatolik: %v", err)
+		}
+	}
+	cliLog.Info("portal qo'shildi", "member_id", *memberID, "domain", *domain)
+	return nil
+}
+
+// setPortalOAuth - `bitrix portals oauth --portal X --server https://crm.example.uz`: token yangilash uchun OAuth server
+// (box portal); --server "" - qo'lda berilgan serverni olib tashlash (server_endpoint yoki bitrix.oauth_server)
+func setPortalOAuth(ctx context.Context, db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("portals oauth", flag.ContinueOnError)
+	portal := fs.String("portal", "", "portal member_id (majburiy)")
+	server := fs.String("server", "", "OAuth server manzili (bo'sh - standart)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *portal == "" {
+		return fmt.Errorf("--portal majburiy")
+	}
+	normalized, err := service.NormalizeOAuthServer(*server)
+	if err != nil {
+		return err
+	}
+	ok, err := storage.SetPortalOAuthServer(ctx, db, *portal, normalized)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("portal topilmadi: %s", *portal)
+	}
+	t, err := storage.GetTokenByMemberID(ctx, db, *portal)
+	if err != nil {
+		return err
+	}
+	cliLog.Info("OAuth server belgilandi", "member_id", *portal, "oauth_server", service.PortalOAuthServer(t))
+	return nil
+}
+
+// runTokens - `bitrix tokens refresh --portal X` yoki `--all`
+func runTokens(ctx context.Context, db *sql.DB, args []string) error {
+	if len(args) == 0 || args[0] != "refresh" {
+		return fmt.Errorf("foydalanish: bitrix tokens refresh --portal X | --all")
+	}
+	fs := flag.NewFlagSet("tokens refresh", flag.ContinueOnError)
+	portal := fs.String("portal", "", "portal member_id")
+	all := fs.Bool("all", false, "barcha yoqilgan portallar")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if (*portal == "") == !*all {
+		return fmt.Errorf("--portal yoki --all dan bittasi kerak")
+	}
+
+	portals, err := selectPortals(ctx, db, *portal)
+	if err != nil {
+		return err
+	}
+	var failed int
+	for _, p := range portals {
+		t, err := storage.GetTokenByMemberID(ctx, db, p.MemberID)
+		if err != nil {
+			cliLog.Error("token topilmadi", "member_id", p.MemberID, "err", err)
+			failed++
+			continue
+		}
+		if err := service.RefreshToken(ctx, db, t, cfg.Bitrix.ClientID, cfg.Bitrix.ClientSecret); err != nil {
+			cliLog.Error("tokenni yangilab bo'lmadi", "member_id", p.MemberID, "err", err)
+			failed++
+			continue
+		}
+		cliLog.Info("token yangilandi", "member_id", p.MemberID, "expires_in", t.ExpiresIn)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d ta portal tokeni yangilanmadi", failed)
+	}
+	return nil
+}
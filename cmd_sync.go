@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"bitrix/models"
+	"bitrix/service"
+	"bitrix/storage"
+)
+
+// runSync - `bitrix sync --portal X --since 2024-01-01 --until 2024-01-31`: bir martalik sinxronizatsiya.
+// --portal berilmasa barcha yoqilgan portallar; oldin yuklab olingan yozuvlar o'tkazib yuboriladi.
+func runSync(ctx context.Context, db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	portal := fs.String("portal", "", "portal member_id (bo'sh bo'lsa barcha yoqilgan portallar)")
+	since := fs.String("since", "", "shu vaqtdan yaratilgan fayllar, YYYY-MM-DD yoki RFC3339")
+	until := fs.String("until", "", "shu vaqtgacha (sana bo'lsa shu kun ham kiradi)")
+	force := fs.Bool("force", false, "oldin yuklab olingan yozuvlarni ham qayta ishlash")
+	directory := fs.Bool("directory", true, "bo'limlar va xodimlar katalogini ham yangilash (eskirgan bo'lsa)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts, err := rangeOptions(*since, *until)
+	if err != nil {
+		return err
+	}
+	opts.Force = *force
+
+	portals, err := selectPortals(ctx, db, *portal)
+	if err != nil {
+		return err
+	}
+	return syncPortals(ctx, db, portals, opts, *directory)
+}
+
+// runBackfill - `bitrix backfill --portal X --since 2024-01-01 [--until 2024-06-30]`: voximplant.statistic.get ni
+// kunlik oynalar bilan yurib chiqadi. Portalda tugallanmagan (yoki oxirgisi xato bilan tugagan) vazifa bo'lsa,
+// o'sha checkpoint dan davom etadi; --cancel bilan uni bekor qilib, yangisini boshlash mumkin.
+// Ctrl+C - joriy qo'ng'iroqdan keyin to'xtaydi, vazifa navbatga qaytadi (keyingi ishga tushirishda davom etadi).
+func runBackfill(ctx context.Context, db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	portal := fs.String("portal", "", "portal member_id (majburiy)")
+	since := fs.String("since", "", "boshlanish sanasi, YYYY-MM-DD (yangi vazifa uchun majburiy)")
+	until := fs.String("until", "", "tugash sanasi, shu kun ham kiradi (bo'sh bo'lsa hozirgacha)")
+	status := fs.Bool("status", false, "vazifalar holatini ko'rsatish")
+	cancel := fs.Bool("cancel", false, "tugallanmagan yoki xato bilan tugagan vazifani bekor qilish")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *portal == "" {
+		return fmt.Errorf("--portal majburiy")
+	}
+
+	if *status {
+		return printBackfillJobs(ctx, db, *portal)
+	}
+
+	job, err := storage.GetResumableBackfillJob(ctx, db, *portal)
+	if *cancel {
+		if err != nil {
+			return fmt.Errorf("bekor qilinadigan vazifa yo'q")
+		}
+		if _, err := storage.SetBackfillStatus(ctx, db, *portal, job.ID, models.BackfillCancelled,
+			models.BackfillQueued, models.BackfillRunning, models.BackfillPaused, models.BackfillFailed); err != nil {
+			return err
+		}
+		cliLog.Info("backfill bekor qilindi", "job_id", job.ID, "member_id", *portal)
+		return nil
+	}
+
+	switch {
+	case err == nil:
+		cliLog.Info("backfill davom ettiriladi", "job_id", job.ID, "member_id", *portal, "status", job.Status,
+			"cursor", job.Cursor.Format("2006-01-02"), "until", job.Until.Format("2006-01-02"))
+		if *since != "" || *until != "" {
+			cliLog.Warn("--since/--until e'tiborga olinmadi: avval vazifani --cancel bilan bekor qiling", "job_id", job.ID)
+		}
+	case err == sql.ErrNoRows:
+		if *since == "" {
+			return fmt.Errorf("--since majburiy")
+		}
+		opts, err := rangeOptions(*since, *until)
+		if err != nil {
+			return err
+		}
+		if job, err = service.NewBackfillJob(ctx, db, *portal, opts.Since, opts.Until); err != nil {
+			return err
+		}
+		cliLog.Info("backfill yaratildi", "job_id", job.ID, "member_id", *portal, "windows_total", job.WindowsTotal)
+	default:
+		return err
+	}
+
+	ok, err := storage.ClaimBackfillJob(ctx, db, job.ID, service.BackfillStaleAfter)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("backfill #%d boshqa jarayonda bajarilmoqda", job.ID)
+	}
+
+	return service.RunBackfill(ctx, db, job, syncOptions())
+}
+
+func printBackfillJobs(ctx context.Context, db *sql.DB, memberID string) error {
+	jobs, err := storage.ListBackfillJobs(ctx, db, memberID, 20)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tHOLAT\tORALIQ\tOYNA\tQO'NG'IROQ\tYOZUV\tETA\tXATOLIK")
+	for _, j := range jobs {
+		eta := "-"
+		if j.ETASeconds != nil && j.Status != models.BackfillCancelled {
+			eta = (time.Duration(*j.ETASeconds) * time.Second).String()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s..%s\t%d/%d\t%d\t%d\t%s\t%s\n", j.ID, j.Status,
+			j.Since.Format("2006-01-02"), j.Until.Format("2006-01-02"), j.WindowsDone, j.WindowsTotal,
+			j.CallsIngested, j.Recordings, eta, j.LastError)
+	}
+	return w.Flush()
+}
+
+func syncPortals(ctx context.Context, db *sql.DB, portals []models.PortalInfo, opts service.SyncOptions, directory bool) error {
+	opts.SkipDirectory = !directory
+	var failed int
+	for _, p := range portals {
+		if service.Stopping(ctx) {
+			return fmt.Errorf("to'xtatildi")
+		}
+		if p.FolderID == "" {
+			cliLog.Warn("folder_id belgilanmagan, o'tkazib yuborildi", "member_id", p.MemberID)
+			continue
+		}
+		n, err := service.SyncPortal(ctx, db, p.MemberID, p.FolderID, opts)
+		if err != nil {
+			cliLog.Error("sinxronizatsiya xatolik", "member_id", p.MemberID, "err", err)
+			failed++
+			continue
+		}
+		cliLog.Info("sinxronizatsiya tugadi", "member_id", p.MemberID, "downloaded", n)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d ta portal sinxronlanmadi", failed)
+	}
+	return nil
+}
+
+// rangeOptions - --since/--until qiymatlaridan SyncOptions
+func rangeOptions(since, until string) (service.SyncOptions, error) {
+	opts := syncOptions()
+	var err error
+	if since != "" {
+		if opts.Since, _, err = parseTimeArg(since); err != nil {
+			return opts, fmt.Errorf("--since noto'g'ri: %v", err)
+		}
+	}
+	if until != "" {
+		var dateOnly bool
+		if opts.Until, dateOnly, err = parseTimeArg(until); err != nil {
+			return opts, fmt.Errorf("--until noto'g'ri: %v", err)
+		}
+		if dateOnly {
+			opts.Until = opts.Until.AddDate(0, 0, 1)
+		}
+	}
+	if !opts.Since.IsZero() && !opts.Until.IsZero() && !opts.Since.Before(opts.Until) {
+		return opts, fmt.Errorf("--since --until dan oldin bo'lishi kerak")
+	}
+	return opts, nil
+}
+
+// parseTimeArg - YYYY-MM-DD (server vaqt mintaqasida) yoki RFC3339
+func parseTimeArg(s string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	return t, false, err
+}
+
+// selectPortals - bitta portal (o'chirilgan bo'lsa ham) yoki barcha yoqilgan portallar
+func selectPortals(ctx context.Context, db *sql.DB, memberID string) ([]models.PortalInfo, error) {
+	portals, err := storage.GetAllPortals(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var selected []models.PortalInfo
+	for _, p := range portals {
+		if memberID != "" && p.MemberID == memberID {
+			return []models.PortalInfo{p}, nil
+		}
+		if memberID == "" && !p.Disabled {
+			selected = append(selected, p)
+		}
+	}
+	if memberID != "" {
+		return nil, fmt.Errorf("portal topilmadi: %s", memberID)
+	}
+	return selected, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"sort"
+
+	"bitrix/models"
+	"bitrix/service"
+)
+
+// runVerifyRecordings - `bitrix verify-recordings --portal X`: yuklab olingan fayllarni qayta tekshirish
+// (diskdagi fayl o'chgan, kesilgan yoki buzilgan bo'lsa holati yangilanadi)
+func runVerifyRecordings(ctx context.Context, db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("verify-recordings", flag.ContinueOnError)
+	portal := fs.String("portal", "", "portal member_id (bo'sh bo'lsa barcha yoqilgan portallar)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	portals, err := selectPortals(ctx, db, *portal)
+	if err != nil {
+		return err
+	}
+	for _, p := range portals {
+		counts, err := service.VerifyRecordings(ctx, db, p.MemberID, func(m *models.RecordingMeta) {
+			cliLog.Warn("yozuvda muammo", "member_id", p.MemberID, "call_id", m.CallID, "status", m.Status,
+				"problem", m.Problem, "path", m.AudioPath)
+		})
+		if err != nil {
+			return fmt.Errorf("portal %s: %v", p.MemberID, err)
+		}
+
+		statuses := make([]string, 0, len(counts))
+		for s := range counts {
+			statuses = append(statuses, s)
+		}
+		sort.Strings(statuses)
+		fmt.Printf("Portal %s:\n", p.MemberID)
+		for _, s := range statuses {
+			fmt.Printf("  %-10s %d\n", s, counts[s])
+		}
+	}
+	return nil
+}
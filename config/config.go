@@ -0,0 +1,362 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config - ilova sozlamalari. Manba tartibi: standart qiymatlar -> TOML fayl -> environment.
+// Maydon teglari: toml - fayldagi kalit, env - environment o'zgaruvchisi,
+// secret - qiymat faylda/env da "_file" (env da "_FILE") orqali fayldan ham o'qilishi mumkin va chop etilganda yashiriladi.
+type Config struct {
+	Bitrix     Bitrix     `toml:"bitrix"`
+	Database   Database   `toml:"database"`
+	Server     Server     `toml:"server"`
+	Sync       Sync       `toml:"sync"`
+	Transcribe Transcribe `toml:"transcribe"`
+	Telegram   Telegram   `toml:"telegram"`
+	HTTP       HTTP       `toml:"http"`
+	Log        Log        `toml:"log"`
+}
+
+type Bitrix struct {
+	ClientID     string `toml:"client_id" env:"BITRIX_CLIENT_ID"`
+	ClientSecret string `toml:"client_secret" env:"BITRIX_CLIENT_SECRET" secret:"true"`
+	RedirectURI  string `toml:"redirect_uri" env:"BITRIX_REDIRECT_URI"`
+	// FolderID - yangi o'rnatilgan portal uchun yozuvlar papkasi (disk.folder ID)
+	FolderID string `toml:"folder_id" env:"BITRIX_FOLDER_ID"`
+	// OAuthServer - bulut OAuth serveri (server_endpoint va qo'lda berilgan server bo'lmagan portallar uchun)
+	OAuthServer string `toml:"oauth_server" env:"BITRIX_OAUTH_SERVER"`
+	// BoxDomains - ishonchli box (o'z serveridagi) portallar, vergul bilan: "crm.example.uz" yoki "http://crm.local"
+	BoxDomains string `toml:"box_domains" env:"BITRIX_BOX_DOMAINS"`
+}
+
+// BoxDomainList - box_domains ro'yxati (bo'sh elementlarsiz)
+func (b Bitrix) BoxDomainList() []string {
+	var list []string
+	for _, d := range strings.Split(b.BoxDomains, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			list = append(list, d)
+		}
+	}
+	return list
+}
+
+type Database struct {
+	// URL - lib/pq ulanish satri: "postgres://..." yoki "user=... dbname=..."
+	URL string `toml:"url" env:"DATABASE_URL" secret:"true"`
+}
+
+type Server struct {
+	Addr string `toml:"addr" env:"HTTP_ADDR"`
+	// PublicURL - ilovaning tashqi manzili (CRM izohlari, Telegram xabarlari va eksportdagi havolalar)
+	PublicURL string `toml:"public_url" env:"PUBLIC_URL"`
+	// ShutdownTimeout - SIGTERM dan keyin joriy so'rovlar va fon vazifalarini kutish muddati
+	ShutdownTimeout time.Duration `toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// MetricsToken - /metrics uchun Bearer token. Standart holatda bo'sh, ya'ni /metrics ochiq: unda portallarning
+	// member_id lari va sinxronizatsiya vaqtlari ko'rinadi. public_url berilgan (server tashqaridan ochiq) bo'lsa,
+	// token yoki alohida metrics_addr majburiy.
+	MetricsToken string `toml:"metrics_token" env:"METRICS_TOKEN" secret:"true"`
+	// MetricsAddr - /metrics uchun alohida (ichki) manzil, masalan "127.0.0.1:9090"; berilsa /metrics asosiy
+	// addr da berilmaydi
+	MetricsAddr string `toml:"metrics_addr" env:"METRICS_ADDR"`
+}
+
+type Sync struct {
+	// Interval - o'z cron jadvali bo'lmagan portallar uchun
+	Interval time.Duration `toml:"interval" env:"SYNC_INTERVAL"`
+}
+
+type Transcribe struct {
+	URL      string        `toml:"url" env:"TRANSCRIBE_URL"`
+	APIKey   string        `toml:"api_key" env:"TRANSCRIBE_API_KEY" secret:"true"`
+	Model    string        `toml:"model" env:"TRANSCRIBE_MODEL"`
+	Language string        `toml:"language" env:"TRANSCRIBE_LANGUAGE"`
+	Interval time.Duration `toml:"interval" env:"TRANSCRIBE_INTERVAL"`
+}
+
+type Telegram struct {
+	BotToken string `toml:"bot_token" env:"TELEGRAM_BOT_TOKEN" secret:"true"`
+}
+
+// HTTP - tashqi so'rovlar klienti (Bitrix24 REST, OAuth, yozuvlarni yuklab olish)
+type HTTP struct {
+	ConnectTimeout time.Duration `toml:"connect_timeout" env:"HTTP_CONNECT_TIMEOUT"`
+	// ReadTimeout - javob kutish va javob tanasini o'qishdagi jimlik
+	ReadTimeout time.Duration `toml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	// Retries - tarmoq xatoligi va 5xx dan keyin qayta urinishlar (0 - o'chirilgan)
+	Retries   int           `toml:"retries" env:"HTTP_RETRIES"`
+	RetryWait time.Duration `toml:"retry_wait" env:"HTTP_RETRY_WAIT"`
+	// Proxy - bo'sh bo'lsa HTTPS_PROXY/HTTP_PROXY environment dan
+	Proxy string `toml:"proxy" env:"HTTP_CLIENT_PROXY" secret:"true"`
+	// CAFile - qo'shimcha ishonchli sertifikatlar (PEM)
+	CAFile string `toml:"ca_file" env:"HTTP_CA_FILE"`
+}
+
+// Log - tuzilgan loglar (log/slog)
+type Log struct {
+	// Level - debug, info, warn, error
+	Level string `toml:"level" env:"LOG_LEVEL"`
+	// Format - json yoki text
+	Format string `toml:"format" env:"LOG_FORMAT"`
+	// Levels - quyi tizimlar darajasi, masalan "sync=debug,storage=warn"
+	Levels string `toml:"levels" env:"LOG_LEVELS"`
+}
+
+// Default - standart qiymatlar
+func Default() *Config {
+	return &Config{
+		Bitrix:     Bitrix{OAuthServer: "https://oauth.bitrix.info"},
+		Server:     Server{Addr: ":8090", ShutdownTimeout: 30 * time.Second},
+		Sync:       Sync{Interval: time.Hour},
+		Transcribe: Transcribe{Interval: 30 * time.Second},
+		Log:        Log{Level: "info", Format: "json"},
+		HTTP:       HTTP{ConnectTimeout: 10 * time.Second, ReadTimeout: time.Minute, Retries: 3, RetryWait: time.Second},
+	}
+}
+
+// ValidationError - barcha topilgan muammolar ro'yxati
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return "konfiguratsiya xatolari:\n  - " + strings.Join(e, "\n  - ")
+}
+
+// Load - standart qiymatlar, path dagi fayl (bo'sh bo'lsa o'tkazib yuboriladi) va environment.
+// Qiymatlarni o'qish va tekshirishdagi barcha xatolar bitta ValidationError da qaytariladi.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	var problems ValidationError
+
+	values := map[string]string{}
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("konfiguratsiya fayli: %v", err)
+		}
+		values, err = parseTOML(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+
+	used := map[string]bool{}
+	eachField(cfg, func(f field) {
+		key := f.section + "." + f.key
+		raw, ok := values[key]
+		source := path + ": " + key
+		used[key] = true
+		if f.secret {
+			fileKey := key + "_file"
+			used[fileKey] = true
+			if p, has := values[fileKey]; has {
+				if ok {
+					problems = append(problems, fmt.Sprintf("%s va %s birga berilgan", key, fileKey))
+				}
+				raw, ok, source = readSecretFile(p, &problems, path+": "+fileKey)
+			}
+		}
+		if env, has := os.LookupEnv(f.env); has {
+			raw, ok, source = env, true, f.env
+		}
+		if f.secret {
+			if p, has := os.LookupEnv(f.env + "_FILE"); has {
+				raw, ok, source = readSecretFile(p, &problems, f.env+"_FILE")
+			}
+		}
+		if ok {
+			if err := f.set(raw); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", source, err))
+			}
+		}
+	})
+	for key := range values {
+		if !used[key] {
+			problems = append(problems, fmt.Sprintf("%s: noma'lum kalit %s", path, key))
+		}
+	}
+
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return cfg, problems
+	}
+	return cfg, nil
+}
+
+func readSecretFile(path string, problems *ValidationError, source string) (string, bool, string) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		*problems = append(*problems, fmt.Sprintf("%s: %v", source, err))
+		return "", false, source
+	}
+	return strings.TrimSpace(string(b)), true, source
+}
+
+// validate - majburiy qiymatlar va formatlar
+func (c *Config) validate() []string {
+	var p []string
+	if c.Bitrix.ClientID == "" {
+		p = append(p, "bitrix.client_id (BITRIX_CLIENT_ID) berilmagan")
+	}
+	if c.Bitrix.ClientSecret == "" {
+		p = append(p, "bitrix.client_secret (BITRIX_CLIENT_SECRET) berilmagan")
+	}
+	if c.Bitrix.RedirectURI == "" {
+		p = append(p, "bitrix.redirect_uri (BITRIX_REDIRECT_URI) berilmagan")
+	} else if !isAbsURL(c.Bitrix.RedirectURI) {
+		p = append(p, "bitrix.redirect_uri to'liq URL bo'lishi kerak")
+	}
+	if c.Bitrix.FolderID != "" {
+		if _, err := strconv.ParseUint(c.Bitrix.FolderID, 10, 64); err != nil {
+			p = append(p, "bitrix.folder_id raqam bo'lishi kerak")
+		}
+	}
+	if !isAbsURL(c.Bitrix.OAuthServer) {
+		p = append(p, "bitrix.oauth_server to'liq URL bo'lishi kerak (https://...)")
+	}
+	for _, d := range c.Bitrix.BoxDomainList() {
+		if strings.Contains(d, "://") && !isAbsURL(d) {
+			p = append(p, "bitrix.box_domains: noto'g'ri manzil "+d)
+		}
+	}
+	if c.Database.URL == "" {
+		p = append(p, "database.url (DATABASE_URL) berilmagan")
+	}
+	if c.Server.Addr == "" {
+		p = append(p, "server.addr bo'sh")
+	}
+	if c.Server.PublicURL != "" && !isAbsURL(c.Server.PublicURL) {
+		p = append(p, "server.public_url to'liq URL bo'lishi kerak (https://...)")
+	}
+	if c.Server.PublicURL != "" && c.Server.MetricsToken == "" && c.Server.MetricsAddr == "" {
+		p = append(p, "server.metrics_token (METRICS_TOKEN) yoki server.metrics_addr berilmagan: public_url bilan /metrics tashqaridan ochiq bo'ladi")
+	}
+	if c.Server.MetricsAddr != "" && c.Server.MetricsAddr == c.Server.Addr {
+		p = append(p, "server.metrics_addr server.addr dan farq qilishi kerak")
+	}
+	if c.Server.ShutdownTimeout < 0 {
+		p = append(p, "server.shutdown_timeout manfiy bo'lmasligi kerak")
+	}
+	if c.Sync.Interval < time.Minute {
+		p = append(p, "sync.interval kamida 1m bo'lishi kerak")
+	}
+	if c.Transcribe.Interval < time.Second {
+		p = append(p, "transcribe.interval kamida 1s bo'lishi kerak")
+	}
+	if c.Transcribe.URL != "" && !isAbsURL(c.Transcribe.URL) {
+		p = append(p, "transcribe.url to'liq URL bo'lishi kerak")
+	}
+	if c.HTTP.ConnectTimeout <= 0 || c.HTTP.ReadTimeout <= 0 {
+		p = append(p, "http.connect_timeout va http.read_timeout musbat bo'lishi kerak")
+	}
+	if c.HTTP.Retries < 0 || c.HTTP.Retries > 10 {
+		p = append(p, "http.retries 0..10 oralig'ida bo'lishi kerak")
+	}
+	if c.HTTP.Proxy != "" {
+		if u, err := url.Parse(c.HTTP.Proxy); err != nil || u.Host == "" {
+			p = append(p, "http.proxy to'liq URL bo'lishi kerak (http://host:port)")
+		}
+	}
+	return p
+}
+
+func isAbsURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Print - konfiguratsiyani TOML ko'rinishida chiqarish; redacted bo'lsa maxfiy qiymatlar yashiriladi
+func (c *Config) Print(w io.Writer, redacted bool) error {
+	section := ""
+	var err error
+	eachField(c, func(f field) {
+		if err != nil {
+			return
+		}
+		if f.section != section {
+			if section != "" {
+				fmt.Fprintln(w)
+			}
+			section = f.section
+			_, err = fmt.Fprintf(w, "[%s]\n", section)
+		}
+		value := f.String()
+		if redacted && f.secret && value != "" {
+			value = "***"
+		}
+		if f.value.Kind() == reflect.String || f.value.Type() == reflect.TypeOf(time.Duration(0)) {
+			value = strconv.Quote(value)
+		}
+		_, err = fmt.Fprintf(w, "%s = %s\n", f.key, value)
+	})
+	return err
+}
+
+// field - bitta sozlama (reflect orqali)
+type field struct {
+	section, key, env string
+	secret            bool
+	value             reflect.Value
+}
+
+func eachField(c *Config, fn func(field)) {
+	root := reflect.ValueOf(c).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		sec := root.Field(i)
+		secName := root.Type().Field(i).Tag.Get("toml")
+		for j := 0; j < sec.NumField(); j++ {
+			t := sec.Type().Field(j)
+			fn(field{
+				section: secName,
+				key:     t.Tag.Get("toml"),
+				env:     t.Tag.Get("env"),
+				secret:  t.Tag.Get("secret") == "true",
+				value:   sec.Field(j),
+			})
+		}
+	}
+}
+
+func (f field) set(raw string) error {
+	if f.value.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("davomiylik noto'g'ri (masalan 30s, 1h): %s", raw)
+		}
+		f.value.SetInt(int64(d))
+		return nil
+	}
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("butun son kutilgan: %s", raw)
+		}
+		f.value.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("true/false kutilgan: %s", raw)
+		}
+		f.value.SetBool(b)
+	default:
+		return fmt.Errorf("qo'llab-quvvatlanmaydigan tur %s", f.value.Type())
+	}
+	return nil
+}
+
+func (f field) String() string {
+	if d, ok := f.value.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return fmt.Sprint(f.value.Interface())
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// parseTOML - konfiguratsiya uchun yetarli TOML qismi: [bo'lim], kalit = qiymat
+// (satr "..." yoki '...', butun son, haqiqiy son, true/false) va # izohlar.
+// Natija: "bo'lim.kalit" -> qiymat (satr ko'rinishida).
+func parseTOML(r io.Reader) (map[string]string, error) {
+	values := map[string]string{}
+	section := ""
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		if strings.HasPrefix(s, "[") {
+			end := strings.Index(s, "]")
+			if end < 0 || strings.TrimSpace(s[end+1:]) != "" && !strings.HasPrefix(strings.TrimSpace(s[end+1:]), "#") {
+				return nil, fmt.Errorf("%d-qator: bo'lim sarlavhasi noto'g'ri", line)
+			}
+			section = strings.TrimSpace(s[1:end])
+			continue
+		}
+
+		eq := strings.Index(s, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("%d-qator: '=' kutilgan edi", line)
+		}
+		key := strings.TrimSpace(s[:eq])
+		if key == "" {
+			return nil, fmt.Errorf("%d-qator: kalit bo'sh", line)
+		}
+		value, err := parseTOMLValue(strings.TrimSpace(s[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("%d-qator (%s): %v", line, key, err)
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("%d-qator: %s takrorlangan", line, key)
+		}
+		values[key] = value
+	}
+	return values, sc.Err()
+}
+
+func parseTOMLValue(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		// Yopuvchi qo'shtirnoqni topish (escape lar hisobga olinadi), keyin strconv bilan ochish
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+				continue
+			}
+			if s[i] == '"' {
+				if rest := strings.TrimSpace(s[i+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+					return "", fmt.Errorf("satrdan keyin ortiqcha belgilar")
+				}
+				return strconv.Unquote(s[:i+1])
+			}
+		}
+		return "", fmt.Errorf("satr yopilmagan")
+	case strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("satr yopilmagan")
+		}
+		if rest := strings.TrimSpace(s[end+2:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("satrdan keyin ortiqcha belgilar")
+		}
+		return s[1 : end+1], nil
+	}
+
+	if i := strings.Index(s, "#"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	if s == "true" || s == "false" {
+		return s, nil
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64); err == nil {
+		return strings.ReplaceAll(s, "_", ""), nil
+	}
+	return "", fmt.Errorf("qiymat noto'g'ri: %s (satrlar qo'shtirnoqda bo'lishi kerak)", s)
+}
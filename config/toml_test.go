@@ -0,0 +1,83 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	src := `
+# izoh
+top = "yuqori"
+
+[server]
+addr = ":8080"            # qator oxiridagi izoh
+public_url = "https://example.com/#anchor"
+escaped = "a\"b\\c\tq"
+literal = 'C:\path\#1'   # izoh
+workers = 4
+ratio = 0.5
+big = 1_000_000
+enabled = true
+off = false
+
+[ log ]
+level = "debug"
+`
+	got, err := parseTOML(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"top":               "yuqori",
+		"server.addr":       ":8080",
+		"server.public_url": "https://example.com/#anchor",
+		"server.escaped":    "a\"b\\c\tq",
+		"server.literal":    `C:\path\#1`,
+		"server.workers":    "4",
+		"server.ratio":      "0.5",
+		"server.big":        "1000000",
+		"server.enabled":    "true",
+		"server.off":        "false",
+		"log.level":         "debug",
+	}
+	if len(got) != len(want) {
+		t.Errorf("%d ta kalit, kutilgan %d: %v", len(got), len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, kutilgan %q", k, got[k], v)
+		}
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := map[string]string{
+		"yopilmagan bo'lim":   "[server\naddr = 1",
+		"sarlavhadan keyin":   "[server] x\n",
+		"tenglik yo'q":        "addr\n",
+		"kalit bo'sh":         " = 1\n",
+		"qo'shtirnoqsiz satr": "addr = localhost\n",
+		"yopilmagan satr":     `addr = "abc` + "\n",
+		"yopilmagan literal":  "addr = 'abc\n",
+		"satrdan keyin":       `addr = "a" b` + "\n",
+		"literaldan keyin":    "addr = 'a' b\n",
+		"noto'g'ri escape":    `addr = "\q"` + "\n",
+		"takrorlangan kalit":  "[a]\nx = 1\n[a]\nx = 2\n",
+		"noto'g'ri mantiqiy":  "x = True\n",
+		"bo'sh qiymat":        "x =\n",
+		"faqat izoh qiymatda": "x = # izoh\n",
+	}
+	for name, src := range tests {
+		if _, err := parseTOML(strings.NewReader(src)); err == nil {
+			t.Errorf("%s: xatolik kutilgan edi", name)
+		}
+	}
+}
+
+func TestParseTOMLLineNumbers(t *testing.T) {
+	_, err := parseTOML(strings.NewReader("# 1\n\na = 1\nb = oops\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "4-qator") {
+		t.Errorf("4-qator xatoligi kutilgan edi: %v", err)
+	}
+}
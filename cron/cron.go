@@ -0,0 +1,215 @@
+// Package cron - standart 5 maydonli cron ifodalari: "daqiqa soat kun oy hafta_kuni".
+//
+// Qo'llab-quvvatlanadi: *, son, oraliq (1-5), qadam (*/5, 9-18/2), ro'yxat (0,30), oy va hafta kuni
+// nomlari (jan, mon), hamda @hourly, @daily (@midnight), @weekly, @monthly, @yearly (@annually).
+// Kun va hafta kuni ikkalasi ham cheklangan bo'lsa, ulardan biri mos kelishi yetarli (Vixie cron kabi).
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule - tahlil qilingan ifoda; har bir maydon ruxsat etilgan qiymatlar bit maskasi
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar/dowStar - maydon "*" bilan berilgan (kun/hafta kuni qoidasi uchun)
+	domStar, dowStar bool
+}
+
+type bounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minutes = bounds{0, 59, nil}
+	hours   = bounds{0, 23, nil}
+	doms    = bounds{1, 31, nil}
+	months  = bounds{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 ham yakshanba
+	dows = bounds{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var aliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse - ifodani tahlil qiladi; xatolik matni qaysi maydon noto'g'ri ekanini ko'rsatadi
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if a, ok := aliases[strings.ToLower(expr)]; ok {
+		expr = a
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron: 5 ta maydon kutilgan, %d ta berilgan: %q", len(fields), expr)
+	}
+
+	s := &Schedule{}
+	var err error
+	if s.minute, err = parseField(fields[0], minutes); err != nil {
+		return nil, fmt.Errorf("cron: daqiqa: %v", err)
+	}
+	if s.hour, err = parseField(fields[1], hours); err != nil {
+		return nil, fmt.Errorf("cron: soat: %v", err)
+	}
+	if s.dom, err = parseField(fields[2], doms); err != nil {
+		return nil, fmt.Errorf("cron: kun: %v", err)
+	}
+	if s.month, err = parseField(fields[3], months); err != nil {
+		return nil, fmt.Errorf("cron: oy: %v", err)
+	}
+	if s.dow, err = parseField(fields[4], dows); err != nil {
+		return nil, fmt.Errorf("cron: hafta kuni: %v", err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+// parseField - vergul bilan ajratilgan qismlar: *, n, a-b, har biri /qadam bilan
+func parseField(field string, b bounds) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("qadam noto'g'ri: %q", part)
+			}
+			rng, step = part[:i], n
+		}
+
+		lo, hi := b.min, b.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			i := strings.IndexByte(rng, '-')
+			var err error
+			if lo, err = value(rng[:i], b); err != nil {
+				return 0, err
+			}
+			if hi, err = value(rng[i+1:], b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("oraliq noto'g'ri: %q", rng)
+			}
+		default:
+			v, err := value(rng, b)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// "5/15" - 5 dan boshlab oxirigacha, "5" - faqat 5
+			if step == 1 {
+				hi = v
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+func value(s string, b bounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("qiymat noto'g'ri: %q", s)
+	}
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("%d chegaradan tashqarida (%d-%d)", v, b.min, b.max)
+	}
+	return v, nil
+}
+
+// Next - t dan keyingi (qat'iy katta) mos daqiqa, t ning vaqt mintaqasida.
+// Mos vaqt 5 yil ichida topilmasa (masalan 30-fevral) nol vaqt qaytaradi.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			// Yozgi vaqtga o'tishda soat "orqaga" normallashishi mumkin
+			if !next.After(t) {
+				next = t.Add(time.Hour).Truncate(time.Hour)
+			}
+			t = next
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Multi - bir nechta ifoda (masalan ish vaqtida har 5 daqiqa, tunda har soat); eng yaqini tanlanadi
+type Multi []*Schedule
+
+// ParseAll - har bir ifodani tahlil qiladi; birinchi xatolikda to'xtaydi
+func ParseAll(exprs []string) (Multi, error) {
+	m := make(Multi, 0, len(exprs))
+	for _, e := range exprs {
+		s, err := Parse(e)
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, s)
+	}
+	return m, nil
+}
+
+// Next - ifodalar ichidagi eng yaqin keyingi vaqt; bo'sh ro'yxat uchun nol vaqt
+func (m Multi) Next(t time.Time) time.Time {
+	var next time.Time
+	for _, s := range m {
+		if n := s.Next(t); !n.IsZero() && (next.IsZero() || n.Before(next)) {
+			next = n
+		}
+	}
+	return next
+}
@@ -0,0 +1,172 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("vaqt mintaqasi yo'q: %v", err)
+	}
+	return loc
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"10-5 * * * *",
+		"1-x * * * *",
+		"* * * foo *",
+		"@every",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q): xatolik kutilgan edi", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	utc := time.UTC
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, utc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		expr, from, want string
+	}{
+		// Qat'iy keyingi daqiqa: aynan mos vaqtning o'zi qaytarilmaydi
+		{"* * * * *", "2024-05-10 12:00", "2024-05-10 12:01"},
+		{"0 * * * *", "2024-05-10 12:00", "2024-05-10 13:00"},
+		// Soniyalar tashlab yuboriladi
+		{"*/15 * * * *", "2024-05-10 12:07", "2024-05-10 12:15"},
+		{"*/15 * * * *", "2024-05-10 12:45", "2024-05-10 13:00"},
+		// Ro'yxat, oraliq va oraliq ichida qadam
+		{"0,30 9-18/2 * * *", "2024-05-10 10:00", "2024-05-10 11:00"},
+		{"0,30 9-18/2 * * *", "2024-05-10 17:30", "2024-05-11 09:00"},
+		// "5/20" - 5 dan boshlab har 20 daqiqa
+		{"5/20 * * * *", "2024-05-10 12:06", "2024-05-10 12:25"},
+		// Nomlar va 7 = yakshanba
+		{"0 8 * jan-mar mon", "2024-05-10 00:00", "2025-01-06 08:00"},
+		{"0 0 * * 7", "2024-05-10 00:00", "2024-05-12 00:00"},
+		{"0 0 * * SUN", "2024-05-10 00:00", "2024-05-12 00:00"},
+		// Taxalluslar
+		{"@hourly", "2024-05-10 12:30", "2024-05-10 13:00"},
+		{"@daily", "2024-05-10 12:30", "2024-05-11 00:00"},
+		{"@weekly", "2024-05-10 12:30", "2024-05-12 00:00"},
+		{"@monthly", "2024-05-10 12:30", "2024-06-01 00:00"},
+		{"@YEARLY", "2024-05-10 12:30", "2025-01-01 00:00"},
+		// Oy oxiri va kabisa yili
+		{"0 0 31 * *", "2024-04-01 00:00", "2024-05-31 00:00"},
+		{"0 0 29 2 *", "2025-01-01 00:00", "2028-02-29 00:00"},
+		// Vixie qoidasi: kun va hafta kuni ikkalasi cheklangan - biri mos kelsa yetarli
+		// (2024-05-10 juma; 13-may dushanba)
+		{"0 0 13 * 5", "2024-05-10 00:00", "2024-05-13 00:00"},
+		{"0 0 13 * 1", "2024-05-10 00:00", "2024-05-13 00:00"},
+		{"0 0 20 * 6", "2024-05-10 00:00", "2024-05-11 00:00"},
+		// Biri "*" bo'lsa - ikkalasi mos kelishi kerak (faqat cheklangani hisobga olinadi)
+		{"0 0 * * 5", "2024-05-10 00:00", "2024-05-17 00:00"},
+		{"0 0 13 * *", "2024-05-10 00:00", "2024-05-13 00:00"},
+		// "*/2" ham "*" hisoblanadi: 13-may dushanba, lekin toq kun emas - keyingi juft hafta kunigacha
+		{"0 0 13 * */2", "2024-05-10 00:00", "2024-06-13 00:00"},
+	}
+	for _, tc := range tests {
+		s, err := Parse(tc.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.expr, err)
+			continue
+		}
+		if got := s.Next(at(tc.from)); !got.Equal(at(tc.want)) {
+			t.Errorf("%q dan keyin %q: %s, kutilgan %s", tc.from, tc.expr, got.Format("2006-01-02 15:04"), tc.want)
+		}
+	}
+}
+
+func TestNextImpossible(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("30-fevral uchun nol vaqt kutilgan edi, %s", got)
+	}
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	tashkent := mustLoad(t, "Asia/Tashkent")
+	s, _ := Parse("0 9 * * *")
+	got := s.Next(time.Date(2024, 5, 10, 10, 0, 0, 0, tashkent))
+	want := time.Date(2024, 5, 11, 9, 0, 0, 0, tashkent)
+	if !got.Equal(want) || got.Location() != tashkent {
+		t.Errorf("%s, kutilgan %s", got, want)
+	}
+}
+
+func TestNextDST(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	date := func(y int, m time.Month, d, h, min int) time.Time { return time.Date(y, m, d, h, min, 0, 0, ny) }
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		// 2024-03-10: 02:00 -> 03:00, 02:30 mavjud emas - o'sha kun o'tkazib yuboriladi
+		{"bahor, yo'q soat", "30 2 * * *", date(2024, 3, 10, 0, 0), date(2024, 3, 11, 2, 30)},
+		// Soat qadami 03:00 dan orqaga qaytmaydi
+		{"bahor, keyingi soat", "0 3 * * *", date(2024, 3, 10, 1, 30), date(2024, 3, 10, 3, 0)},
+		{"bahor, har soat", "0 * * * *", date(2024, 3, 10, 1, 30), date(2024, 3, 10, 3, 0)},
+		// 2024-11-03: 02:00 EDT -> 01:00 EST; har soatlik ifoda 01:00 EST ni o'tkazib yubormaydi
+		{"kuz, har soat", "0 * * * *", date(2024, 11, 3, 1, 0), date(2024, 11, 3, 1, 0).Add(time.Hour)},
+		{"kuz, keyingi kun", "0 3 * * *", date(2024, 11, 3, 0, 30), date(2024, 11, 3, 3, 0)},
+	}
+	for _, tc := range tests {
+		s, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Next(tc.from); !got.Equal(tc.want) {
+			t.Errorf("%s: %s, kutilgan %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMulti(t *testing.T) {
+	m, err := ParseAll([]string{"*/5 9-17 * * mon-fri", "0 * * * *"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2024-05-11 shanba: faqat har soatlik ifoda
+	from := time.Date(2024, 5, 11, 10, 2, 0, 0, time.UTC)
+	if got, want := m.Next(from), time.Date(2024, 5, 11, 11, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("shanba: %s, kutilgan %s", got, want)
+	}
+	from = time.Date(2024, 5, 10, 10, 2, 0, 0, time.UTC)
+	if got, want := m.Next(from), time.Date(2024, 5, 10, 10, 5, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("juma: %s, kutilgan %s", got, want)
+	}
+
+	if _, err := ParseAll([]string{"0 * * * *", "bad"}); err == nil {
+		t.Error("noto'g'ri ifoda uchun xatolik kutilgan edi")
+	}
+	if got := (Multi{}).Next(from); !got.IsZero() {
+		t.Errorf("bo'sh ro'yxat: %s", got)
+	}
+}
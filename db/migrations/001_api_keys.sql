@@ -0,0 +1,27 @@
+-- API kalitlari: har bir kalit bitta portalga (member_id) bog'langan
+CREATE TABLE IF NOT EXISTS api_keys (
+        id SERIAL PRIMARY KEY,
+        member_id VARCHAR(255) NOT NULL,
+        name VARCHAR(255),
+        key_hash VARCHAR(64) UNIQUE NOT NULL,  -- sha256(hex), kalitning o'zi saqlanmaydi
+        scopes TEXT[] NOT NULL DEFAULT '{}',
+        created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+        last_used_at TIMESTAMP,
+        revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_member_id_idx ON api_keys (member_id);
+
+-- Bitrix24 ichida (placement/iframe) ochilgan sessiyalar
+CREATE TABLE IF NOT EXISTS frame_sessions (
+        token_hash VARCHAR(64) PRIMARY KEY,
+        member_id VARCHAR(255) NOT NULL,
+        user_id VARCHAR(50),
+        scopes TEXT[] NOT NULL DEFAULT '{}',
+        created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+        expires_at TIMESTAMP NOT NULL
+);
+
+-- Qo'ng'iroqlar qaysi portalga tegishli ekanini bilish uchun
+ALTER TABLE CallInfo ADD COLUMN IF NOT EXISTS member_id VARCHAR(255);
+CREATE INDEX IF NOT EXISTS callinfo_member_id_idx ON CallInfo (member_id);
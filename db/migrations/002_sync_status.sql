@@ -0,0 +1,6 @@
+-- Dashboard uchun portal sinxronizatsiya holati
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS last_sync_at TIMESTAMP;
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS last_sync_error TEXT;
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS last_sync_files INT;
+
+CREATE INDEX IF NOT EXISTS total_call_id_idx ON total (call_id);
@@ -0,0 +1,32 @@
+-- VARCHAR ustunlarni haqiqiy turlarga o'tkazish. Son bo'lmagan eski qiymatlar NULL ga aylanadi.
+
+ALTER TABLE CallInfo
+        ALTER COLUMN call_duration TYPE INTEGER
+                USING CASE WHEN call_duration ~ '^\s*-?\d+\s*$' THEN trim(call_duration)::INTEGER END,
+        ALTER COLUMN call_vote TYPE SMALLINT
+                USING CASE WHEN call_vote ~ '^\s*-?\d+\s*$' THEN trim(call_vote)::SMALLINT END,
+        ALTER COLUMN cost TYPE NUMERIC(14, 4)
+                USING CASE WHEN cost ~ '^\s*-?\d+(\.\d+)?\s*$' THEN trim(cost)::NUMERIC END,
+        ALTER COLUMN transcript_pending TYPE BOOLEAN
+                USING upper(trim(transcript_pending)) IN ('Y', 'TRUE', '1', 'T'),
+        ALTER COLUMN redial_attempt TYPE INTEGER
+                USING CASE WHEN redial_attempt ~ '^\s*-?\d+\s*$' THEN trim(redial_attempt)::INTEGER END,
+        ALTER COLUMN record_duration TYPE INTEGER
+                USING CASE WHEN record_duration ~ '^\s*-?\d+\s*$' THEN trim(record_duration)::INTEGER END,
+        ALTER COLUMN call_type TYPE SMALLINT
+                USING CASE WHEN call_type ~ '^\s*-?\d+\s*$' THEN trim(call_type)::SMALLINT END,
+        -- Eski TIMESTAMP qiymatlar server vaqt mintaqasida deb hisoblanadi
+        ALTER COLUMN call_start_date TYPE TIMESTAMPTZ
+                USING call_start_date::TIMESTAMPTZ;
+
+ALTER TABLE users
+        ALTER COLUMN active TYPE BOOLEAN
+                USING upper(trim(active)) IN ('Y', 'TRUE', '1', 'T'),
+        ALTER COLUMN last_login TYPE TIMESTAMPTZ
+                USING CASE WHEN last_login ~ '^\d{4}-\d{2}-\d{2}' THEN last_login::TIMESTAMPTZ END,
+        ALTER COLUMN department_ids TYPE JSONB
+                USING CASE WHEN department_ids ~ '^\s*\[' THEN department_ids::JSONB ELSE '[]'::JSONB END;
+
+CREATE INDEX IF NOT EXISTS callinfo_call_start_date_idx ON CallInfo (call_start_date);
+CREATE INDEX IF NOT EXISTS callinfo_phone_number_idx ON CallInfo (phone_number);
+CREATE INDEX IF NOT EXISTS callinfo_portal_user_id_idx ON CallInfo (portal_user_id);
@@ -0,0 +1,78 @@
+-- Barcha jadvallarni portal (member_id) bo'yicha ajratish.
+-- portals - yagona portal reyestri: tokenlar ham shu yerda saqlanadi (avval alohida tokens jadvali ishlatilgan).
+
+DO $$
+BEGIN
+        IF to_regclass('tokens') IS NOT NULL THEN
+                INSERT INTO portals (member_id, domain, access_token, refresh_token, expires_in, scope, last_update, client_endpoint)
+                SELECT member_id, portal_domain, access_token, refresh_token, expires_in, scope, last_update, client_endpoint
+                FROM tokens
+                ON CONFLICT (member_id) DO UPDATE SET
+                        domain = EXCLUDED.domain,
+                        access_token = EXCLUDED.access_token,
+                        refresh_token = EXCLUDED.refresh_token,
+                        expires_in = EXCLUDED.expires_in,
+                        scope = EXCLUDED.scope,
+                        last_update = EXCLUDED.last_update,
+                        client_endpoint = EXCLUDED.client_endpoint;
+        END IF;
+END $$;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS member_id VARCHAR(255);
+ALTER TABLE total ADD COLUMN IF NOT EXISTS member_id VARCHAR(255);
+ALTER TABLE months ADD COLUMN IF NOT EXISTS member_id VARCHAR(255);
+
+-- Mavjud qatorlar: total CallInfo dan oladi; qolganlari faqat bitta portal bo'lsa shu portalga beriladi
+UPDATE total t SET member_id = c.member_id
+FROM CallInfo c
+WHERE t.member_id IS NULL AND c.id = t.call_id AND c.member_id IS NOT NULL;
+
+DO $$
+DECLARE
+        portal_count INT;
+        only_member VARCHAR(255);
+BEGIN
+        SELECT COUNT(*), MIN(member_id) INTO portal_count, only_member FROM portals;
+
+        IF EXISTS (SELECT 1 FROM CallInfo WHERE member_id IS NULL)
+                OR EXISTS (SELECT 1 FROM users WHERE member_id IS NULL)
+                OR EXISTS (SELECT 1 FROM total WHERE member_id IS NULL)
+                OR EXISTS (SELECT 1 FROM months WHERE member_id IS NULL) THEN
+                IF portal_count <> 1 THEN
+                        RAISE EXCEPTION 'member_id siz qatorlar bor va portallar soni %: ularni qo''lda biriktiring', portal_count;
+                END IF;
+                UPDATE CallInfo SET member_id = only_member WHERE member_id IS NULL;
+                UPDATE users SET member_id = only_member WHERE member_id IS NULL;
+                UPDATE total SET member_id = only_member WHERE member_id IS NULL;
+                UPDATE months SET member_id = only_member WHERE member_id IS NULL;
+        END IF;
+END $$;
+
+-- Eski bir ustunli kalitlar o'rniga (member_id, id)
+ALTER TABLE total DROP CONSTRAINT IF EXISTS total_call_id_fkey;
+ALTER TABLE total DROP CONSTRAINT IF EXISTS total_user_id_fkey;
+ALTER TABLE CallInfo DROP CONSTRAINT IF EXISTS callinfo_pkey;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_pkey;
+ALTER TABLE months DROP CONSTRAINT IF EXISTS months_pkey;
+
+ALTER TABLE CallInfo ALTER COLUMN member_id SET NOT NULL;
+ALTER TABLE users ALTER COLUMN member_id SET NOT NULL;
+ALTER TABLE total ALTER COLUMN member_id SET NOT NULL;
+ALTER TABLE months ALTER COLUMN member_id SET NOT NULL;
+
+ALTER TABLE CallInfo ADD PRIMARY KEY (member_id, id);
+ALTER TABLE users ADD PRIMARY KEY (member_id, id);
+ALTER TABLE months ADD PRIMARY KEY (member_id, id);
+
+ALTER TABLE CallInfo ADD FOREIGN KEY (member_id) REFERENCES portals (member_id) ON DELETE CASCADE;
+ALTER TABLE users ADD FOREIGN KEY (member_id) REFERENCES portals (member_id) ON DELETE CASCADE;
+ALTER TABLE months ADD FOREIGN KEY (member_id) REFERENCES portals (member_id) ON DELETE CASCADE;
+ALTER TABLE total ADD FOREIGN KEY (member_id, call_id) REFERENCES CallInfo (member_id, id) ON DELETE CASCADE;
+ALTER TABLE total ADD FOREIGN KEY (member_id, user_id) REFERENCES users (member_id, id);
+ALTER TABLE api_keys ADD FOREIGN KEY (member_id) REFERENCES portals (member_id) ON DELETE CASCADE;
+ALTER TABLE frame_sessions ADD FOREIGN KEY (member_id) REFERENCES portals (member_id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS callinfo_member_id_idx;
+DROP INDEX IF EXISTS total_call_id_idx;
+CREATE INDEX IF NOT EXISTS callinfo_member_start_idx ON CallInfo (member_id, call_start_date);
+CREATE INDEX IF NOT EXISTS total_member_call_idx ON total (member_id, call_id);
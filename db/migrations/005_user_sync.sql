@@ -0,0 +1,23 @@
+-- Xodimlar katalogini davriy sinxronizatsiya qilish va holat tarixi
+ALTER TABLE users ADD COLUMN IF NOT EXISTS synced_at TIMESTAMPTZ;
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS users_synced_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS user_history (
+        member_id VARCHAR(255) NOT NULL,
+        user_id VARCHAR(50) NOT NULL,
+        active BOOLEAN NOT NULL,
+        department_ids JSONB NOT NULL DEFAULT '[]',
+        work_position VARCHAR(255),
+        valid_from TIMESTAMPTZ NOT NULL,
+        valid_to TIMESTAMPTZ,  -- NULL - hozirgi holat
+        PRIMARY KEY (member_id, user_id, valid_from),
+        FOREIGN KEY (member_id, user_id) REFERENCES users (member_id, id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_history_current_idx ON user_history (member_id, user_id) WHERE valid_to IS NULL;
+
+-- Mavjud xodimlar uchun boshlang'ich holat
+INSERT INTO user_history (member_id, user_id, active, department_ids, work_position, valid_from)
+SELECT member_id, id, COALESCE(active, FALSE), COALESCE(department_ids, '[]'::JSONB), work_position, NOW()
+FROM users
+ON CONFLICT DO NOTHING;
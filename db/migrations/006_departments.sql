@@ -0,0 +1,13 @@
+-- Bitrix24 tashkiliy tuzilmasi (department.get)
+CREATE TABLE IF NOT EXISTS departments (
+        member_id VARCHAR(255) NOT NULL REFERENCES portals (member_id) ON DELETE CASCADE,
+        id VARCHAR(50) NOT NULL,
+        name VARCHAR(255),
+        sort INT,
+        parent_id VARCHAR(50),   -- NULL - eng yuqori bo'lim
+        head_user_id VARCHAR(50),
+        synced_at TIMESTAMPTZ,
+        PRIMARY KEY (member_id, id)
+);
+
+CREATE INDEX IF NOT EXISTS departments_parent_idx ON departments (member_id, parent_id);
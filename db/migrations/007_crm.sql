@@ -0,0 +1,29 @@
+-- Qo'ng'iroqqa bog'langan CRM obyektlari (lid, kontakt, kompaniya, bitim) va faoliyatlar snapshoti
+CREATE TABLE IF NOT EXISTS crm_entities (
+        member_id VARCHAR(255) NOT NULL REFERENCES portals (member_id) ON DELETE CASCADE,
+        entity_type VARCHAR(20) NOT NULL,   -- LEAD, CONTACT, COMPANY, DEAL
+        entity_id VARCHAR(50) NOT NULL,
+        title TEXT,
+        customer_name TEXT,
+        responsible_id VARCHAR(50),
+        stage_id VARCHAR(100),              -- bitim uchun STAGE_ID, lid uchun STATUS_ID
+        phone VARCHAR(100),
+        email VARCHAR(255),
+        raw JSONB,
+        fetched_at TIMESTAMPTZ NOT NULL,
+        PRIMARY KEY (member_id, entity_type, entity_id)
+);
+
+CREATE TABLE IF NOT EXISTS crm_activities (
+        member_id VARCHAR(255) NOT NULL REFERENCES portals (member_id) ON DELETE CASCADE,
+        id VARCHAR(50) NOT NULL,
+        subject TEXT,
+        responsible_id VARCHAR(50),
+        direction SMALLINT,                 -- 1 - kiruvchi, 2 - chiquvchi
+        owner_type_id SMALLINT,
+        owner_id VARCHAR(50),
+        start_time TIMESTAMPTZ,
+        raw JSONB,
+        fetched_at TIMESTAMPTZ NOT NULL,
+        PRIMARY KEY (member_id, id)
+);
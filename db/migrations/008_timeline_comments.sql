@@ -0,0 +1,14 @@
+-- CRM timeline ga izoh yuborish (portal bo'yicha ixtiyoriy)
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS timeline_comments_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Har bir qo'ng'iroq uchun bitta izoh: qayta sinxronizatsiyada takrorlanmasligi uchun
+CREATE TABLE IF NOT EXISTS crm_timeline_comments (
+        member_id VARCHAR(255) NOT NULL,
+        call_id VARCHAR(100) NOT NULL,
+        entity_type VARCHAR(20) NOT NULL,
+        entity_id VARCHAR(50) NOT NULL,
+        comment_id VARCHAR(50),   -- NULL - yuborilmoqda
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (member_id, call_id),
+        FOREIGN KEY (member_id, call_id) REFERENCES CallInfo (member_id, id) ON DELETE CASCADE
+);
@@ -0,0 +1,43 @@
+-- Nutqni matnga o'girish: har bir yozuv uchun bitta vazifa (job) va natija
+CREATE TABLE IF NOT EXISTS transcription_jobs (
+        id SERIAL PRIMARY KEY,
+        member_id VARCHAR(255) NOT NULL,
+        call_id VARCHAR(100) NOT NULL,
+        audio_path TEXT NOT NULL,
+        status VARCHAR(20) NOT NULL DEFAULT 'queued',   -- queued, running, done, failed, skipped
+        provider VARCHAR(50),
+        attempts INT NOT NULL DEFAULT 0,
+        last_error TEXT,
+        next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        UNIQUE (member_id, call_id),
+        FOREIGN KEY (member_id, call_id) REFERENCES CallInfo (member_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS transcription_jobs_queue_idx ON transcription_jobs (next_attempt_at) WHERE status = 'queued';
+
+CREATE TABLE IF NOT EXISTS transcripts (
+        member_id VARCHAR(255) NOT NULL,
+        call_id VARCHAR(100) NOT NULL,
+        provider VARCHAR(50) NOT NULL,
+        language VARCHAR(20),
+        text TEXT NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (member_id, call_id),
+        FOREIGN KEY (member_id, call_id) REFERENCES CallInfo (member_id, id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS transcript_segments (
+        member_id VARCHAR(255) NOT NULL,
+        call_id VARCHAR(100) NOT NULL,
+        idx INT NOT NULL,
+        start_ms INT NOT NULL,
+        end_ms INT NOT NULL,
+        channel SMALLINT,          -- stereo yozuvda: 0 - operator, 1 - mijoz
+        speaker VARCHAR(50),
+        text TEXT NOT NULL,
+        confidence REAL,
+        PRIMARY KEY (member_id, call_id, idx),
+        FOREIGN KEY (member_id, call_id) REFERENCES transcripts (member_id, call_id) ON DELETE CASCADE
+);
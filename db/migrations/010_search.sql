@@ -0,0 +1,12 @@
+-- Transkriptlar va qo'ng'iroq izohlari bo'yicha to'liq matnli qidiruv.
+-- 'russian' konfiguratsiyasi kirill so'zlarni rus stemmeri, lotin so'zlarni ingliz stemmeri bilan qayta ishlaydi;
+-- o'zbek tili uchun alohida lug'at yo'q, shuning uchun 'simple' (aynan so'z) ham qo'shiladi.
+
+ALTER TABLE transcript_segments ADD COLUMN IF NOT EXISTS search TSVECTOR
+        GENERATED ALWAYS AS (to_tsvector('russian', text) || to_tsvector('simple', text)) STORED;
+
+ALTER TABLE CallInfo ADD COLUMN IF NOT EXISTS comment_search TSVECTOR
+        GENERATED ALWAYS AS (to_tsvector('russian', COALESCE(comment, '')) || to_tsvector('simple', COALESCE(comment, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS transcript_segments_search_idx ON transcript_segments USING GIN (search);
+CREATE INDEX IF NOT EXISTS callinfo_comment_search_idx ON CallInfo USING GIN (comment_search);
@@ -0,0 +1,41 @@
+-- Suhbat skriptiga rioya qilish: portal bo'yicha kalit so'z qoidalari va har bir qo'ng'iroq natijasi
+CREATE TABLE IF NOT EXISTS compliance_rules (
+        id SERIAL PRIMARY KEY,
+        member_id VARCHAR(255) NOT NULL REFERENCES portals (member_id) ON DELETE CASCADE,
+        name VARCHAR(255) NOT NULL,
+        kind VARCHAR(20) NOT NULL,      -- required, forbidden, competitor
+        phrases TEXT[] NOT NULL,
+        channel SMALLINT,               -- 0 - operator, 1 - mijoz, NULL - ikkalasi
+        within_seconds INT,             -- required: suhbatning birinchi N soniyasida
+        weight INT NOT NULL DEFAULT 10,
+        alert BOOLEAN NOT NULL DEFAULT TRUE,
+        enabled BOOLEAN NOT NULL DEFAULT TRUE,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS compliance_rules_member_idx ON compliance_rules (member_id);
+
+CREATE TABLE IF NOT EXISTS call_compliance (
+        member_id VARCHAR(255) NOT NULL,
+        call_id VARCHAR(100) NOT NULL,
+        score REAL NOT NULL,            -- 0..100, qoidalar og'irligi bo'yicha
+        violations INT NOT NULL,
+        evaluated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        alerted_at TIMESTAMPTZ,         -- Telegram ogohlantirishi bir marta yuboriladi
+        PRIMARY KEY (member_id, call_id),
+        FOREIGN KEY (member_id, call_id) REFERENCES CallInfo (member_id, id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS call_compliance_flags (
+        member_id VARCHAR(255) NOT NULL,
+        call_id VARCHAR(100) NOT NULL,
+        rule_id INT NOT NULL REFERENCES compliance_rules (id) ON DELETE CASCADE,
+        passed BOOLEAN NOT NULL,
+        matched TEXT,
+        start_ms INT,
+        PRIMARY KEY (member_id, call_id, rule_id),
+        FOREIGN KEY (member_id, call_id) REFERENCES call_compliance (member_id, call_id) ON DELETE CASCADE
+);
+
+-- Ogohlantirishlar uchun Telegram chat (bo'sh bo'lsa yuborilmaydi)
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS telegram_chat_id VARCHAR(100);
@@ -0,0 +1,20 @@
+-- Yuklab olingan yozuv fayli ma'lumotlari (sarlavhadan) va tekshiruv natijasi
+CREATE TABLE IF NOT EXISTS recordings (
+        member_id VARCHAR(255) NOT NULL,
+        call_id VARCHAR(100) NOT NULL,
+        audio_path TEXT NOT NULL,
+        format VARCHAR(10),
+        codec VARCHAR(20),
+        duration_ms BIGINT,
+        bitrate INT,
+        sample_rate INT,
+        channels SMALLINT,
+        size_bytes BIGINT NOT NULL DEFAULT 0,
+        status VARCHAR(20) NOT NULL,    -- ok, mismatch, empty, corrupt, missing
+        problem TEXT,
+        checked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (member_id, call_id),
+        FOREIGN KEY (member_id, call_id) REFERENCES CallInfo (member_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS recordings_problem_idx ON recordings (member_id, checked_at) WHERE status <> 'ok';
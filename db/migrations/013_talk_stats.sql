@@ -0,0 +1,17 @@
+-- Yozuv bo'yicha suhbat dinamikasi: jimlik, kanal bo'yicha gapirish vaqti, eng uzun monolog
+CREATE TABLE IF NOT EXISTS call_talk_stats (
+        member_id VARCHAR(255) NOT NULL,
+        call_id VARCHAR(100) NOT NULL,
+        duration_ms BIGINT NOT NULL,
+        operator_talk_ms BIGINT NOT NULL,       -- mono yozuvda umumiy nutq
+        customer_talk_ms BIGINT,                -- faqat kanallar ajratilgan stereo yozuvda
+        silence_ms BIGINT NOT NULL,
+        overlap_ms BIGINT NOT NULL,             -- ikkala tomon bir vaqtda gapirgan
+        longest_monologue_ms BIGINT NOT NULL,
+        monologue_channel SMALLINT,             -- 0 - operator, 1 - mijoz
+        silences JSONB NOT NULL DEFAULT '[]',
+        approximate BOOLEAN NOT NULL DEFAULT FALSE,  -- MP3: dekodlashsiz baho
+        analyzed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (member_id, call_id),
+        FOREIGN KEY (member_id, call_id) REFERENCES CallInfo (member_id, id) ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+-- Pleyer uchun qisqartirilgan to'lqin shakli: har bir bo'lakda eng baland daraja (0..255)
+CREATE TABLE IF NOT EXISTS recording_waveforms (
+        member_id VARCHAR(255) NOT NULL,
+        call_id VARCHAR(100) NOT NULL,
+        bucket_ms INT NOT NULL,
+        channels SMALLINT NOT NULL,
+        peaks BYTEA NOT NULL,       -- kanallar ketma-ket: avval 0-kanal, keyin 1-kanal
+        approximate BOOLEAN NOT NULL DEFAULT FALSE,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (member_id, call_id),
+        FOREIGN KEY (member_id, call_id) REFERENCES CallInfo (member_id, id) ON DELETE CASCADE
+);
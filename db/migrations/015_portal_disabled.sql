@@ -0,0 +1,5 @@
+-- O'chirilgan portal avtomatik sinxronizatsiyadan chiqariladi (tokenlar va ma'lumotlar saqlanadi)
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Qayta sinxronizatsiyada yozuv takror yuklanmasligi uchun
+CREATE INDEX IF NOT EXISTS total_member_call_idx ON total (member_id, call_id);
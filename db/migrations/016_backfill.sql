@@ -0,0 +1,22 @@
+-- Tarixiy yozuvlarni kunlik oynalar bilan yuklash; cursor - keyingi oyna boshi (checkpoint)
+CREATE TABLE IF NOT EXISTS backfill_jobs (
+        id SERIAL PRIMARY KEY,
+        member_id VARCHAR(255) NOT NULL REFERENCES portals (member_id) ON DELETE CASCADE,
+        since TIMESTAMPTZ NOT NULL,
+        until TIMESTAMPTZ NOT NULL,
+        cursor TIMESTAMPTZ NOT NULL,
+        status VARCHAR(20) NOT NULL DEFAULT 'queued',  -- queued, running, paused, done, failed, cancelled
+        windows_total INT NOT NULL,
+        windows_done INT NOT NULL DEFAULT 0,
+        calls_ingested INT NOT NULL DEFAULT 0,
+        recordings INT NOT NULL DEFAULT 0,
+        elapsed_ms BIGINT NOT NULL DEFAULT 0,      -- faqat ishlagan vaqt (pauzalarsiz), ETA uchun
+        last_error TEXT,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        finished_at TIMESTAMPTZ
+);
+
+-- Portalda bir vaqtda bitta tugallanmagan backfill
+CREATE UNIQUE INDEX IF NOT EXISTS backfill_jobs_active_idx ON backfill_jobs (member_id)
+        WHERE status IN ('queued', 'running', 'paused');
@@ -0,0 +1,8 @@
+-- Portal bo'yicha sinxronizatsiya jadvali: bir nechta cron ifodasi (eng yaqini tanlanadi) va vaqt mintaqasi.
+-- Jadval bo'sh bo'lsa sozlamalardagi sync.interval ishlatiladi.
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS sync_schedule TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS sync_timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+-- Pauza: jadval bo'yicha ishga tushmaydi, qo'lda ("hozir sinxronlash") ishlaydi
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS sync_paused BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS sync_requested_at TIMESTAMPTZ;
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS sync_started_at TIMESTAMPTZ;
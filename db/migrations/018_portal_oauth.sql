@@ -0,0 +1,5 @@
+-- OAuth server portal bo'yicha: bulutda oauth.bitrix.info, box (o'z serveridagi) versiyada portalning o'zi.
+-- server_endpoint - token javobidagi qiymat (masalan https://oauth.bitrix.info/rest/);
+-- oauth_server - qo'lda berilgan manzil (bo'sh bo'lsa server_endpoint dan, u ham bo'lmasa standart).
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS server_endpoint TEXT;
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS oauth_server TEXT;
@@ -0,0 +1,4 @@
+-- Oxirgi muvaffaqiyatli sinxronizatsiya (last_sync_at xatolikda ham yangilanadi): /metrics dagi ogohlantirish uchun
+ALTER TABLE portals ADD COLUMN IF NOT EXISTS last_sync_success_at TIMESTAMPTZ;
+UPDATE portals SET last_sync_success_at = last_sync_at
+ WHERE last_sync_success_at IS NULL AND last_sync_at IS NOT NULL AND last_sync_error IS NULL;
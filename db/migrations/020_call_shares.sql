@@ -0,0 +1,9 @@
+-- Ulashiladigan havolalar (CRM timeline izohi, ogohlantirishlar): Bitrix24 frame sessiyasisiz ochiladi.
+-- Faqat token hashi saqlanadi; qo'ng'iroq o'chirilsa havola ham o'chadi.
+CREATE TABLE IF NOT EXISTS call_shares (
+        token_hash VARCHAR(64) PRIMARY KEY,
+        member_id VARCHAR(255) NOT NULL,
+        call_id VARCHAR(100) NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        FOREIGN KEY (member_id, call_id) REFERENCES CallInfo (member_id, id) ON DELETE CASCADE
+);
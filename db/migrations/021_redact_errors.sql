@@ -0,0 +1,15 @@
+-- Oldin saqlangan xatolik matnlaridagi tokenlarni yashirish (so'rov URL lari ?auth=... bilan yozilgan edi)
+UPDATE portals
+   SET last_sync_error = regexp_replace(last_sync_error,
+       '(auth|access_token|refresh_token|client_secret|auth_id|refresh_id|code)=[^&[:space:]"'']+', '\1=***', 'gi')
+ WHERE last_sync_error ~* '(auth|token|secret|refresh_id|code)=';
+
+UPDATE backfill_jobs
+   SET last_error = regexp_replace(last_error,
+       '(auth|access_token|refresh_token|client_secret|auth_id|refresh_id|code)=[^&[:space:]"'']+', '\1=***', 'gi')
+ WHERE last_error ~* '(auth|token|secret|refresh_id|code)=';
+
+UPDATE transcription_jobs
+   SET last_error = regexp_replace(last_error,
+       '(auth|access_token|refresh_token|client_secret|auth_id|refresh_id|code)=[^&[:space:]"'']+', '\1=***', 'gi')
+ WHERE last_error ~* '(auth|token|secret|refresh_id|code)=';
@@ -0,0 +1,11 @@
+-- Ulashilgan havolalar muddati va bekor qilinishi. Bitta qo'ng'iroq uchun amaldagi havola qayta ishlatiladi:
+-- token o'zi ham saqlanadi (havola baribir CRM va Telegram da ochiq matn), qidiruv hash bo'yicha.
+-- Mavjud havolalar yaratilganidan 30 kun o'tib eskiradi; ularning tokeni yo'q - qayta ishlatilmaydi.
+ALTER TABLE call_shares ADD COLUMN IF NOT EXISTS token TEXT;
+ALTER TABLE call_shares ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE call_shares ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMPTZ;
+
+UPDATE call_shares SET expires_at = created_at + INTERVAL '30 days' WHERE expires_at IS NULL;
+ALTER TABLE call_shares ALTER COLUMN expires_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS call_shares_call_idx ON call_shares (member_id, call_id);
@@ -0,0 +1,3 @@
+-- Baholanmagan qo'ng'iroqlar call_vote = 0 bilan yozilgan edi (bo'sh qiymat 0 ga aylanardi); baho 1..5,
+-- shuning uchun 0 - "baho yo'q". Bo'sh cost ham 0 bo'lib qolgan, lekin uni bepul qo'ng'iroqdan ajratib bo'lmaydi.
+UPDATE CallInfo SET call_vote = NULL WHERE call_vote = 0;
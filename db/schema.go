@@ -0,0 +1,9 @@
+// Package db - SQL sxema: db.sql (boshlang'ich jadvallar) va migrations/ (tartib raqami bo'yicha)
+package db
+
+import "embed"
+
+// Files - binarga joylangan sxema fayllari (`bitrix migrate` uchun)
+//
+//go:embed db.sql migrations/*.sql
+var Files embed.FS
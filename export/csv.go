@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+const csvFlushEvery = 500
+
+type csvWriter struct {
+	w       io.Writer
+	cw      *csv.Writer
+	numeric []bool
+	rows    int
+}
+
+// NewCSV - Excel to'g'ri ochishi uchun UTF-8 BOM bilan CSV
+func NewCSV(w io.Writer) RowWriter {
+	return &csvWriter{w: w, cw: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteHeader(cols []Column) error {
+	if _, err := c.w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+	names := make([]string, len(cols))
+	c.numeric = make([]bool, len(cols))
+	for i, col := range cols {
+		names[i] = col.Name
+		c.numeric[i] = col.Numeric
+	}
+	return c.cw.Write(names)
+}
+
+func (c *csvWriter) WriteRow(values []string) error {
+	cells := make([]string, len(values))
+	for i, v := range values {
+		cells[i] = cellText(v, i < len(c.numeric) && c.numeric[i])
+	}
+	if err := c.cw.Write(cells); err != nil {
+		return err
+	}
+	c.rows++
+	if c.rows%csvFlushEvery == 0 {
+		c.cw.Flush()
+		return c.cw.Error()
+	}
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	c.cw.Flush()
+	return c.cw.Error()
+}
@@ -0,0 +1,57 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Column - eksport ustuni; Numeric bo'lsa XLSX da son sifatida yoziladi
+type Column struct {
+	Name    string
+	Numeric bool
+}
+
+// cellText - tashqaridan kelgan matn (CRM mijoz nomi, izohlar) =, +, -, @, tab yoki CR bilan boshlansa, Excel/LibreOffice
+// uni formula deb bajaradi (CSV/formula injection): oldiga ' qo'yiladi. Son ustunidagi haqiqiy son (masalan -5) o'zgarmaydi.
+func cellText(v string, numeric bool) string {
+	if v == "" {
+		return v
+	}
+	switch v[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		if numeric {
+			if _, err := strconv.ParseFloat(v, 64); err == nil {
+				return v
+			}
+		}
+		return "'" + v
+	}
+	return v
+}
+
+// RowWriter - qatorma-qator (stream) yozuvchi: butun hisobot xotiraga yuklanmaydi
+type RowWriter interface {
+	WriteHeader(cols []Column) error
+	WriteRow(values []string) error
+	Close() error
+}
+
+// New - format bo'yicha yozuvchi: "csv" yoki "xlsx"
+func New(format string, w io.Writer) (RowWriter, error) {
+	switch format {
+	case "csv":
+		return NewCSV(w), nil
+	case "xlsx":
+		return NewXLSX(w), nil
+	}
+	return nil, fmt.Errorf("noma'lum eksport formati: %s", format)
+}
+
+// ContentType - HTTP javob uchun MIME turi
+func ContentType(format string) string {
+	if format == "xlsx" {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
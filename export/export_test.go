@@ -0,0 +1,97 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+)
+
+var testColumns = []Column{{Name: "Mijoz"}, {Name: "Narxi", Numeric: true}}
+
+func TestCellText(t *testing.T) {
+	tests := []struct {
+		in      string
+		numeric bool
+		want    string
+	}{
+		{"Ali Valiyev", false, "Ali Valiyev"},
+		{"", false, ""},
+		{"=HYPERLINK(\"http://x\")", false, "'=HYPERLINK(\"http://x\")"},
+		{"+998901234567", false, "'+998901234567"},
+		{"-1+1", false, "'-1+1"},
+		{"@SUM(A1)", false, "'@SUM(A1)"},
+		{"\t=1", false, "'\t=1"},
+		{"\r=1", false, "'\r=1"},
+		{"a=1", false, "a=1"},
+		// Son ustunidagi haqiqiy son o'zgarmaydi, son bo'lmagan qiymat esa himoyalanadi
+		{"-5.5", true, "-5.5"},
+		{"+3", true, "+3"},
+		{"-1+1", true, "'-1+1"},
+	}
+	for _, tc := range tests {
+		if got := cellText(tc.in, tc.numeric); got != tc.want {
+			t.Errorf("cellText(%q, %v) = %q, kutilgan %q", tc.in, tc.numeric, got, tc.want)
+		}
+	}
+}
+
+func TestCSVFormulaInjection(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSV(&buf)
+	if err := w.WriteHeader(testColumns); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow([]string{"=cmd|' /C calc'!A0", "-12.5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\xEF\xBB\xBF"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][0] != "'=cmd|' /C calc'!A0" || records[1][1] != "-12.5" {
+		t.Errorf("noto'g'ri qatorlar: %q", records)
+	}
+}
+
+func TestXLSXFormulaInjection(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewXLSX(&buf)
+	if err := w.WriteHeader(testColumns); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow([]string{"@SUM(1+1)", "-12.5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := io.ReadAll(rc)
+			rc.Close()
+			sheet = string(b)
+		}
+	}
+	for _, want := range []string{`<t xml:space="preserve">&#39;@SUM(1+1)</t>`, `<c r="B2"><v>-12.5</v></c>`, `<t xml:space="preserve">Mijoz</t>`} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("varaqda %s yo'q:\n%s", want, sheet)
+		}
+	}
+}
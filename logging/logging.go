@@ -0,0 +1,150 @@
+// Package logging - log/slog asosidagi tuzilgan loglar: JSON (yoki text) chiqish, quyi tizim (subsystem)
+// bo'yicha darajalar va tokenlarni yashirish. Umumiy maydon nomlari: member_id, call_id, file_id, job_id, method.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// Options - Setup parametrlari (sozlamalardagi [log] bo'limi)
+type Options struct {
+	// Level - standart daraja: debug, info, warn, error
+	Level string
+	// Format - json yoki text
+	Format string
+	// Levels - quyi tizimlar darajasi: "sync=debug,storage=warn"
+	Levels string
+	Output io.Writer
+}
+
+type state struct {
+	handler slog.Handler
+	level   slog.Level
+	levels  map[string]slog.Level
+}
+
+var current atomic.Pointer[state]
+
+func init() {
+	current.Store(&state{handler: newHandler(os.Stderr, "json"), level: slog.LevelInfo})
+	slog.SetDefault(For("app"))
+}
+
+// Setup - chiqish formati va darajalarni o'rnatadi; For bilan oldin yaratilgan loggerlar ham yangi sozlamani oladi.
+// Standart log paketi ham shu handler orqali yoziladi.
+func Setup(opts Options) error {
+	st := &state{level: slog.LevelInfo, levels: map[string]slog.Level{}}
+	if opts.Level != "" {
+		if err := st.level.UnmarshalText([]byte(opts.Level)); err != nil {
+			return fmt.Errorf("log darajasi noto'g'ri: %s", opts.Level)
+		}
+	}
+	for _, item := range strings.Split(opts.Levels, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		var lvl slog.Level
+		if !ok || strings.TrimSpace(name) == "" || lvl.UnmarshalText([]byte(strings.TrimSpace(value))) != nil {
+			return fmt.Errorf("log darajasi noto'g'ri: %s (kutilgan: subsystem=debug)", item)
+		}
+		st.levels[strings.TrimSpace(name)] = lvl
+	}
+	switch opts.Format {
+	case "", "json", "text":
+	default:
+		return fmt.Errorf("log formati noto'g'ri: %s (json yoki text)", opts.Format)
+	}
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	st.handler = newHandler(out, opts.Format)
+	current.Store(st)
+	return nil
+}
+
+func newHandler(w io.Writer, format string) slog.Handler {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: redactAttr}
+	if format == "text" {
+		return slog.NewTextHandler(w, opts)
+	}
+	return slog.NewJSONHandler(w, opts)
+}
+
+// For - quyi tizim logger i (paket darajasidagi o'zgaruvchi sifatida yaratiladi); har bir yozuvda "subsystem" maydoni
+func For(subsystem string) *slog.Logger {
+	return slog.New(&handler{subsystem: subsystem})
+}
+
+// handler - daraja va chiqishni har yozuvda joriy sozlamadan oladi
+type handler struct {
+	subsystem string
+	ops       []func(slog.Handler) slog.Handler
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	st := current.Load()
+	min, ok := st.levels[h.subsystem]
+	if !ok {
+		min = st.level
+	}
+	return level >= min
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	out := current.Load().handler.WithAttrs([]slog.Attr{slog.String("subsystem", h.subsystem)})
+	for _, op := range h.ops {
+		out = op(out)
+	}
+	return out.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithGroup(name) })
+}
+
+func (h *handler) with(op func(slog.Handler) slog.Handler) slog.Handler {
+	ops := append(append([]func(slog.Handler) slog.Handler(nil), h.ops...), op)
+	return &handler{subsystem: h.subsystem, ops: ops}
+}
+
+var (
+	// URL va forma parametrlaridagi tokenlar: ?auth=..., &refresh_token=..., AUTH_ID=...
+	secretParam = regexp.MustCompile(`(?i)(\b(?:auth|access_token|refresh_token|client_secret|auth_id|refresh_id|code)=)[^&\s"']+`)
+	// Telegram bot API manzili: /bot<id>:<token>/
+	telegramToken = regexp.MustCompile(`bot[0-9]+:[A-Za-z0-9_-]+`)
+)
+
+// Redact - matndagi tokenlarni "***" bilan almashtiradi (xatolik matnlari ham URL ni o'z ichiga oladi)
+func Redact(s string) string {
+	if !strings.Contains(s, "=") && !strings.Contains(s, "bot") {
+		return s
+	}
+	s = secretParam.ReplaceAllString(s, "${1}***")
+	return telegramToken.ReplaceAllString(s, "bot***")
+}
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(Redact(err.Error()))
+		}
+	}
+	return a
+}
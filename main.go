@@ -1,65 +1,227 @@
 package main
 
 import (
-	"bitrix/models"
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
+	"bitrix/api"
+	"bitrix/config"
+	"bitrix/logging"
+	"bitrix/models"
 	"bitrix/service"
 	"bitrix/storage"
+	"bitrix/web"
 
 	_ "github.com/lib/pq"
 )
 
-var (
-	clientID     = "CLIENT_ID"
-	clientSecret = "CLIENT_SECRET"
-	redirectURI  = "REDIRECT_URI"
-)
+// cfg - fayl va environment dan o'qilgan sozlamalar (main da yuklanadi)
+var cfg *config.Config
+
+const usage = `Foydalanish: bitrix <buyruq> [parametrlar]
+
+Buyruqlar:
+  serve                  HTTP server va fon vazifalari (standart)
+  sync                   portal yozuvlarini bir marta sinxronlash (--portal, --since, --until)
+  backfill               tarixiy qo'ng'iroqlarni kunlik oynalar bilan yuklash (--portal, --since, --until, --status, --cancel)
+  migrate                DB sxemasini yangilash (--status, --baseline)
+  portals list|add|disable|enable|schedule|pause|resume|oauth
+  tokens refresh         portal tokenlarini yangilash (--portal yoki --all)
+  export                 qo'ng'iroqlarni CSV/XLSX ga eksport qilish
+  verify-recordings      yuklab olingan fayllarni qayta tekshirish (--portal)
+  config print           amaldagi sozlamalar (--redacted)
+
+Har bir buyruq parametrlari: bitrix <buyruq> -h
+`
 
 func main() {
-	// 1) Muhitni tekshirish
-	if clientID == "" || clientSecret == "" || redirectURI == "" {
-		log.Fatal("Iltimos, BITRIX_CLIENT_ID, BITRIX_CLIENT_SECRET, BITRIX_REDIRECT_URI ni environmentda sozlang.")
+	cmd, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+
+	// 1) Sozlamalar: config.toml (yoki CONFIG_FILE) + environment
+	path := configPath()
+	switch cmd {
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	case "config":
+		if len(args) == 0 || args[0] != "print" {
+			fmt.Fprintln(os.Stderr, "Foydalanish: bitrix config print [--redacted]")
+			os.Exit(2)
+		}
+		if err := runConfigPrint(path, args[1:]); err != nil {
+			fatal("config print xatolik", "err", err)
+		}
+		return
+	}
+	commands := map[string]func(context.Context, *sql.DB, []string) error{
+		"serve":             runServe,
+		"sync":              runSync,
+		"backfill":          runBackfill,
+		"migrate":           runMigrate,
+		"portals":           runPortals,
+		"tokens":            runTokens,
+		"export":            runExport,
+		"verify-recordings": runVerifyRecordings,
+	}
+	run, ok := commands[cmd]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Noma'lum buyruq: %s\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	if cfg, err = config.Load(path); err != nil {
+		fatal("sozlamalarni yuklab bo'lmadi", "err", err)
+	}
+	if err := logging.Setup(logging.Options{Level: cfg.Log.Level, Format: cfg.Log.Format, Levels: cfg.Log.Levels}); err != nil {
+		fatal("log sozlamalari noto'g'ri", "err", err)
+	}
+
+	// Tashqi so'rovlar klienti: timeout, qayta urinishlar, proxy, CA
+	client, err := service.NewHTTPClient(service.HTTPOptions{
+		ConnectTimeout: cfg.HTTP.ConnectTimeout,
+		ReadTimeout:    cfg.HTTP.ReadTimeout,
+		Retries:        cfg.HTTP.Retries,
+		RetryWait:      cfg.HTTP.RetryWait,
+		Proxy:          cfg.HTTP.Proxy,
+		CAFile:         cfg.HTTP.CAFile,
+	})
+	if err != nil {
+		fatal("HTTP klient sozlamalari noto'g'ri", "err", err)
+	}
+	service.SetHTTPClient(client)
+	if err := service.SetDefaultOAuthServer(cfg.Bitrix.OAuthServer); err != nil {
+		fatal("bitrix.oauth_server noto'g'ri", "err", err)
 	}
 
 	// 2) DB ga ulanish
-	connStr := "user=godb password=0208 dbname=bitrix sslmode=disable" // misol: "user=godb password=0208 dbname=bitrix sslmode=disable"
-	db, err := storage.OpenDatabase(connStr)
+	db, err := storage.OpenDatabase(cfg.Database.URL)
 	if err != nil {
-		log.Fatal("DB connection xatolik:", err)
+		fatal("DB ga ulanib bo'lmadi", "err", err)
 	}
 	defer db.Close()
 
-	// 3) "/" – oddiy sahifa (test uchun)
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintf(w, "Welcome to My Bitrix24 Install + Call Records App!\n")
-		fmt.Fprintf(w, "client_id: %s\nredirect_uri: %s\n", clientID, redirectURI)
-	})
+	// 3) SIGINT/SIGTERM: yangi ish qabul qilinmaydi, joriysi server.shutdown_timeout ichida tugatiladi
+	ctx, cancel := shutdownContext(cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := run(ctx, db, args); err != nil {
+		db.Close()
+		fatal("buyruq xatolik bilan tugadi", "command", cmd, "err", err)
+	}
+}
+
+var (
+	cliLog    = logging.For("cli")
+	serverLog = logging.For("server")
+)
+
+// fatal - xatolikni yozib, 1 kodi bilan chiqish (log.Fatal o'rniga)
+func fatal(msg string, args ...any) {
+	cliLog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// shutdownContext - birinchi signal yumshoq to'xtatishni boshlaydi (service.Stopping), timeout dan keyin
+// yoki ikkinchi signalda ctx bekor qilinadi va joriy so'rovlar/yuklab olishlar uziladi
+func shutdownContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := make(chan struct{})
+
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sig:
+		case <-ctx.Done():
+			return
+		}
+		cliLog.Info("to'xtatilmoqda: joriy ishlar tugatiladi (darhol chiqish uchun yana Ctrl+C)", "timeout", timeout.String())
+		close(stop)
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-sig:
+		case <-timer.C:
+			cliLog.Warn("to'xtatish muddati tugadi, joriy ishlar uziladi")
+		case <-ctx.Done():
+		}
+		cancel()
+		signal.Stop(sig)
+	}()
+	return service.WithStop(ctx, stop), cancel
+}
+
+// runServe - `bitrix serve`: dashboard, API, OAuth install va fon vazifalari.
+// To'xtatishda server yangi ulanishlarni qabul qilmaydi, joriy so'rovlar va fon vazifalari kutiladi.
+func runServe(ctx context.Context, db *sql.DB, args []string) error {
+	// 3) Dashboard ("/" – qo'ng'iroqlar ro'yxati, Bitrix24 placement ichida ham ishlaydi)
+	web.RegisterRoutes(http.DefaultServeMux, db)
 
-	// 4) "/bitrix/oauth" – Bitrix24 ilovasini o‘rnatish (install) paytida code keladi
+	// 4) "/bitrix/oauth" – Bitrix24 ilovasini o‘rnatish (install): OAuth code (bulut va box) yoki
+	// box dagi lokal ilova o'rnatish formasi (AUTH_ID, REFRESH_ID)
 	http.HandleFunc("/bitrix/oauth", func(w http.ResponseWriter, r *http.Request) {
+		boxDomains := cfg.Bitrix.BoxDomainList()
 		code := r.URL.Query().Get("code")
-		if code == "" {
+		var tokenInfo *models.TokenInfo
+		var err error
+		switch {
+		case code != "":
+			// Kodni bergan server: bulutda oauth.bitrix.info, box da portalning o'zi (server_domain)
+			server, serr := service.TrustedOAuthServer(r.URL.Query().Get("server_domain"), boxDomains)
+			if serr != nil {
+				http.Error(w, serr.Error(), http.StatusBadRequest)
+				return
+			}
+			// code -> token (access_token, refresh_token, ...)
+			tokenInfo, err = service.ExchangeCodeForToken(r.Context(), db, server, code, cfg.Bitrix.ClientID, cfg.Bitrix.ClientSecret, cfg.Bitrix.RedirectURI)
+		case r.Method == http.MethodPost && r.FormValue("AUTH_ID") != "":
+			expires, _ := strconv.Atoi(r.FormValue("AUTH_EXPIRES"))
+			tokenInfo, err = service.InstallLocalApp(r.Context(), db, service.LocalInstall{
+				Domain:         r.FormValue("DOMAIN"),
+				HTTPS:          r.FormValue("PROTOCOL") != "0",
+				MemberID:       r.FormValue("member_id"),
+				AuthID:         r.FormValue("AUTH_ID"),
+				RefreshID:      r.FormValue("REFRESH_ID"),
+				ExpiresIn:      expires,
+				ServerEndpoint: r.FormValue("SERVER_ENDPOINT"),
+			}, boxDomains)
+		default:
 			http.Error(w, "Missing 'code'", http.StatusBadRequest)
 			return
 		}
-
-		// code -> token (access_token, refresh_token, ...)
-		tokenInfo, err := service.ExchangeCodeForToken(db, code, clientID, clientSecret, redirectURI)
 		if err != nil {
 			http.Error(w, "Token exchange error: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		// Demo uchun folderID ni statik qilamiz (disk.folder ID)
-		folderID := "521316"
-		// DB da folderID saqlash (portals jadvalida)
-		if err := storage.UpdatePortalFolderID(db, tokenInfo.MemberID, folderID); err != nil {
-			log.Println("FolderID saqlashda xatolik:", err)
+		// Yozuvlar papkasi (disk.folder ID) sozlamalardan; berilmagan bo'lsa keyinroq qo'lda belgilanadi
+		folderID := cfg.Bitrix.FolderID
+		if folderID != "" {
+			// DB da folderID saqlash (portals jadvalida)
+			if err := storage.UpdatePortalFolderID(r.Context(), db, tokenInfo.MemberID, folderID); err != nil {
+				serverLog.Error("FolderID saqlashda xatolik", "member_id", tokenInfo.MemberID, "err", err)
+			}
+		}
+
+		// Lokal ilova: o'rnatish Bitrix24 oynasida BX24.installFinish() bilan yakunlanadi
+		if code == "" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<script src="//api.bitrix24.com/api/v1/"></script><script>BX24.init(function(){BX24.installFinish();});</script>`)
+			return
 		}
 
 		// Install muvaffaqiyatli bo'ldi
@@ -68,94 +230,164 @@ func main() {
 		fmt.Fprintf(w, "FolderID: %s\n", folderID)
 	})
 
-	// 5) Avtomatik call records yuklab olish (har 1 soatda)
-	go startAutoDownload(db)
+	// 5) API (kalit yoki Bitrix24 frame sessiyasi orqali, har doim bitta portal doirasida).
+	// Sinxronizatsiya: har portal o'z jadvali bo'yicha (bo'sh bo'lsa har sync.interval da)
+	sched := service.NewScheduler(db, syncOptions(), cfg.Sync.Interval)
+	api.RegisterRoutes(http.DefaultServeMux, db, sched, cfg.Server.PublicURL)
+	// Prometheus: Bitrix24 so'rovlari, yuklab olish, navbat, portal sinxronizatsiyasi, DB.
+	// metrics_addr berilsa faqat o'sha (ichki) manzilda, aks holda asosiy serverda
+	metricsMux := http.DefaultServeMux
+	if cfg.Server.MetricsAddr != "" {
+		metricsMux = http.NewServeMux()
+	}
+	metricsMux.Handle("GET /metrics", api.MetricsHandler(db, cfg.Server.MetricsToken))
+
+	// 6) Fon vazifalari
+	var wg sync.WaitGroup
+	for _, worker := range []func(context.Context){
+		sched.Run,
+		func(ctx context.Context) { startHousekeeping(ctx, db) },
+		func(ctx context.Context) { startTranscriptionWorker(ctx, db) },
+		func(ctx context.Context) { startBackfillWorker(ctx, db) },
+	} {
+		wg.Add(1)
+		go func(run func(context.Context)) {
+			defer wg.Done()
+			run(ctx)
+		}(worker)
+	}
+
+	// 7) Serverni ishga tushirish; so'rovlar ctx dan: muddat tugasa ular ham uziladi
+	srv := &http.Server{
+		Addr:        cfg.Server.Addr,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	servers := []*http.Server{srv}
+	if cfg.Server.MetricsAddr != "" {
+		servers = append(servers, &http.Server{
+			Addr:        cfg.Server.MetricsAddr,
+			Handler:     metricsMux,
+			BaseContext: func(net.Listener) context.Context { return ctx },
+		})
+	}
+	errc := make(chan error, len(servers))
+	for _, s := range servers {
+		go func(s *http.Server) { errc <- s.ListenAndServe() }(s)
+		serverLog.Info("server ishga tushdi", "addr", s.Addr)
+	}
+
+	select {
+	case err := <-errc:
+		return err
+	case <-service.Stopped(ctx):
+	}
+
+	// 8) To'xtatish: avval HTTP, keyin fon vazifalari (ctx bekor qilinguncha)
+	for _, s := range servers {
+		if err := s.Shutdown(ctx); err != nil {
+			serverLog.Error("HTTP serverni to'xtatishda xatolik", "addr", s.Addr, "err", err)
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		serverLog.Info("fon vazifalari tugadi")
+	case <-ctx.Done():
+		serverLog.Warn("fon vazifalari kutilmadi: muddat tugadi")
+	}
+	return nil
+}
 
-	// 6) Serverni ishga tushirish
-	port := ":8090"
-	log.Printf("Server running on %s...", port)
-	log.Fatal(http.ListenAndServe(port, nil))
+// syncOptions - sozlamalardagi Bitrix24 ilova ma'lumotlari bilan
+func syncOptions() service.SyncOptions {
+	return service.SyncOptions{
+		ClientID:     cfg.Bitrix.ClientID,
+		ClientSecret: cfg.Bitrix.ClientSecret,
+		PublicURL:    cfg.Server.PublicURL,
+	}
 }
 
-// startAutoDownload – har 1 soatda call recordlarni yuklab olish
-func startAutoDownload(db *sql.DB) {
-	ticker := time.NewTicker(1 * time.Hour)
+// startHousekeeping – eskirgan frame sessiyalar va ulashilgan havolalarni tozalash (har soatda)
+func startHousekeeping(ctx context.Context, db *sql.DB) {
+	ticker := time.NewTicker(time.Hour)
 	defer ticker.Stop()
 
 	for {
-		portals, err := storage.GetAllPortals(db)
-		if err != nil {
-			log.Println("GetAllPortals xatolik:", err)
-			<-ticker.C
-			continue
+		if err := storage.DeleteExpiredFrameSessions(ctx, db); err != nil {
+			serverLog.Error("DeleteExpiredFrameSessions xatolik", "err", err)
 		}
-		log.Printf("Portal soni: %d\n", len(portals))
-
-		// Har bir portal uchun
-		for _, p := range portals {
-			checkAndDownloadRecords(db, p.MemberID, p.FolderID)
+		if err := storage.DeleteExpiredCallShares(ctx, db, time.Now()); err != nil {
+			serverLog.Error("DeleteExpiredCallShares xatolik", "err", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-service.Stopped(ctx):
+			return
 		}
-
-		<-ticker.C
 	}
 }
 
-// checkAndDownloadRecords – call recordlarni yuklab, DB ga yozish
-func checkAndDownloadRecords(db *sql.DB, memberID, folderID string) {
-	log.Printf("🔍 Portal %s, folder %s – call recordlarni tekshirish...", memberID, folderID)
-
-	// 1) Disk papkadan audio fayllar
-	audioFiles, err := service.GetAllAudioFiles(db, memberID, folderID, clientID, clientSecret)
-	if err != nil {
-		log.Println("GetAllAudioFiles xatolik:", err)
-		return
+// startTranscriptionWorker – navbatdagi yozuvlarni matnga o'girish (har transcribe.interval da)
+func startTranscriptionWorker(ctx context.Context, db *sql.DB) {
+	// transcribe.url bo'sh bo'lsa vazifalar "skipped" bo'ladi (CRM timeline izohi transkriptsiz yuboriladi)
+	var transcribers []service.Transcriber
+	if h := service.NewHTTPTranscriber(cfg.Transcribe.URL, cfg.Transcribe.APIKey, cfg.Transcribe.Model, cfg.Transcribe.Language); h != nil {
+		transcribers = append(transcribers, h)
 	}
-	if len(audioFiles) == 0 {
-		log.Println("📭 Yangi audio fayl topilmadi.")
-		return
+
+	if n, err := storage.RequeueStaleTranscriptionJobs(ctx, db, time.Hour); err != nil {
+		serverLog.Error("RequeueStaleTranscriptionJobs xatolik", "err", err)
+	} else if n > 0 {
+		serverLog.Info("uzilgan transkripsiya vazifalari navbatga qaytarildi", "jobs", n)
 	}
-	log.Printf("✅ Topilgan audio fayllar soni: %d\n", len(audioFiles))
 
-	// 2) Har bir audio fayl uchun call info, user info, yuklab olish
-	for _, audio := range audioFiles {
-		callInfo, err := service.GetCallInfo(db, memberID, audio.ID, clientID, clientSecret)
-		if err != nil {
-			log.Println("GetCallInfo xatolik:", err)
-			continue
+	telegram := service.NewTelegramNotifier(cfg.Telegram.BotToken)
+
+	ticker := time.NewTicker(cfg.Transcribe.Interval)
+	defer ticker.Stop()
+
+	for {
+		// Navbat bo'shaguncha (yoki to'xtatish boshlanguncha) ketma-ket ishlash
+		for !service.Stopping(ctx) {
+			n, err := service.ProcessTranscriptionJobs(ctx, db, transcribers, syncOptions(), telegram)
+			if err != nil {
+				serverLog.Error("ProcessTranscriptionJobs xatolik", "err", err)
+			}
+			if n == 0 {
+				break
+			}
 		}
-		// DB ga call_info yozish
-		if err := storage.InsertCallInfo(callInfo, db); err != nil {
-			log.Println("InsertCallInfo xatolik:", err)
+		select {
+		case <-ticker.C:
+		case <-service.Stopped(ctx):
+			return
 		}
+	}
+}
 
-		// Audio faylni yuklab olish
-		audioPath, err := service.DownloadAudio(audio.DownloadURL, audio.Name)
-		if err != nil {
-			log.Println("DownloadAudio xatolik:", err)
-			continue
-		}
+// startBackfillWorker – API orqali navbatga qo'yilgan (yoki uzilib qolgan) backfill vazifalarini bajarish
+func startBackfillWorker(ctx context.Context, db *sql.DB) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
 
-		// Foydalanuvchini olish
-		userInfo, err := service.GetUserInfo(db, memberID, callInfo.PortalUserID, clientID, clientSecret)
+	for !service.Stopping(ctx) {
+		job, err := storage.ClaimNextBackfillJob(ctx, db, service.BackfillStaleAfter)
 		if err != nil {
-			log.Println("UserInfo xatolik:", err)
-			userInfo = &models.User{ID: callInfo.PortalUserID, Name: "Noma'lum"}
-		}
-		if err := storage.InsertUser(userInfo, db); err != nil {
-			log.Println("InsertUser xatolik:", err)
+			serverLog.Error("ClaimNextBackfillJob xatolik", "err", err)
 		}
-
-		// Total jadvaliga yozish
-		total := models.Total{
-			AudioPath: audioPath,
-			UserID:    userInfo.ID,
-			CallID:    callInfo.ID,
+		if job != nil {
+			if err := service.RunBackfill(ctx, db, job, syncOptions()); err != nil {
+				serverLog.Error("RunBackfill xatolik", "job_id", job.ID, "member_id", job.MemberID, "err", err)
+			}
+			continue
 		}
-		if err := storage.InsertTotal(total, db); err != nil {
-			log.Println("InsertTotal xatolik:", err)
+		select {
+		case <-ticker.C:
+		case <-service.Stopped(ctx):
 		}
-
-		log.Printf("⬇️ Yuklab olingan fayl: %s, CallID: %s, UserID: %s\n",
-			audioPath, callInfo.ID, userInfo.ID)
 	}
 }
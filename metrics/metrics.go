@@ -0,0 +1,258 @@
+// Package metrics - Prometheus text formatidagi hisoblagichlar, o'lchagichlar va gistogrammalar.
+// Qiymatlar jarayon xotirasida; /metrics so'rovida Write bilan chiqariladi.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets - so'rov davomiyligi uchun standart chegaralar (soniya)
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+type metric interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+var registry struct {
+	mu   sync.Mutex
+	list []metric
+}
+
+func register(m metric) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for _, r := range registry.list {
+		if r.name() == m.name() {
+			panic("metrics: takroriy nom " + m.name())
+		}
+	}
+	registry.list = append(registry.list, m)
+	sort.Slice(registry.list, func(i, j int) bool { return registry.list[i].name() < registry.list[j].name() })
+}
+
+// Write - barcha metrikalar, nom bo'yicha tartiblangan (text exposition format 0.0.4)
+func Write(w io.Writer) error {
+	registry.mu.Lock()
+	list := append([]metric(nil), registry.list...)
+	registry.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range list {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+// ContentType - Write chiqaradigan format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// vec - label qiymatlari bo'yicha seriyalar
+type vec struct {
+	metricName, help, kind string
+	labels                 []string
+	mu                     sync.Mutex
+	series                 map[string][]string // kalit -> label qiymatlari
+}
+
+func newVec(name, help, kind string, labels []string) vec {
+	return vec{metricName: name, help: help, kind: kind, labels: labels, series: map[string][]string{}}
+}
+
+func (v *vec) name() string { return v.metricName }
+
+// key - mu ostida chaqiriladi; label soni mos kelmasa dastur xatosi
+func (v *vec) key(values []string) string {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s uchun %d ta label kutilgan, %d berildi", v.metricName, len(v.labels), len(values)))
+	}
+	k := strings.Join(values, "\xff")
+	if _, ok := v.series[k]; !ok {
+		v.series[k] = append([]string(nil), values...)
+	}
+	return k
+}
+
+// sortedKeys - mu ostida chaqiriladi
+func (v *vec) sortedKeys() []string {
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *vec) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.metricName, escapeHelp(v.help), v.metricName, v.kind)
+}
+
+// labelString - {a="x",b="y"}; extra - qo'shimcha label (gistogramma "le")
+func (v *vec) labelString(values []string, extra ...string) string {
+	if len(values) == 0 && len(extra) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(values)+1)
+	for i, val := range values {
+		parts = append(parts, v.labels[i]+`="`+escapeLabel(val)+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		parts = append(parts, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// CounterVec - faqat o'sadigan qiymat
+type CounterVec struct {
+	vec
+	values map[string]float64
+}
+
+// NewCounter - hisoblagichni ro'yxatdan o'tkazadi (paket darajasidagi o'zgaruvchi sifatida)
+func NewCounter(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec: newVec(name, help, "counter", labels), values: map[string]float64{}}
+	register(c)
+	return c
+}
+
+func (c *CounterVec) Inc(values ...string) { c.Add(1, values...) }
+
+func (c *CounterVec) Add(n float64, values ...string) {
+	if n < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.values[c.key(values)] += n
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w)
+	for _, k := range c.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelString(c.series[k]), formatFloat(c.values[k]))
+	}
+}
+
+// GaugeVec - ixtiyoriy qiymat (navbat uzunligi, vaqt belgisi)
+type GaugeVec struct {
+	vec
+	values map[string]float64
+}
+
+func NewGauge(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{vec: newVec(name, help, "gauge", labels), values: map[string]float64{}}
+	register(g)
+	return g
+}
+
+func (g *GaugeVec) Set(n float64, values ...string) {
+	g.mu.Lock()
+	g.values[g.key(values)] = n
+	g.mu.Unlock()
+}
+
+// Reset - barcha seriyalarni o'chirish (masalan, o'chirilgan portal qolib ketmasligi uchun qayta to'ldirishdan oldin)
+func (g *GaugeVec) Reset() {
+	g.mu.Lock()
+	g.series = map[string][]string{}
+	g.values = map[string]float64{}
+	g.mu.Unlock()
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w)
+	for _, k := range g.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", g.metricName, g.labelString(g.series[k]), formatFloat(g.values[k]))
+	}
+}
+
+// HistogramVec - kuzatuvlar taqsimoti (chegaralar bo'yicha yig'ma)
+type HistogramVec struct {
+	vec
+	buckets []float64
+	values  map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64 // har chegara uchun (yig'ma emas)
+	count  uint64
+	sum    float64
+}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	h := &HistogramVec{vec: newVec(name, help, "histogram", labels), buckets: b, values: map[string]*histogram{}}
+	register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(n float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	k := h.key(values)
+	s := h.values[k]
+	if s == nil {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[k] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, n); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += n
+}
+
+// Since - start dan beri o'tgan vaqt (soniya)
+func (h *HistogramVec) Since(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w)
+	for _, k := range h.sortedKeys() {
+		labels, s := h.series[k], h.values[k]
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(labels, "le", formatFloat(le)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labelString(labels), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labelString(labels), s.count)
+	}
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 // --- Bitrixdan olinadigan token ma'lumotlari ---
 type TokenInfo struct {
@@ -13,34 +16,41 @@ type TokenInfo struct {
 	Scope          string
 	LastUpdate     time.Time
 	ClientEndpoint string
+	// ServerEndpoint - OAuth server REST manzili (token javobidan)
+	ServerEndpoint string
+	// OAuthServer - portal uchun qo'lda berilgan OAuth server (bo'sh bo'lsa ServerEndpoint dan aniqlanadi)
+	OAuthServer string
 }
 type User struct {
-	ID               string   `json:"id"`
-	XML_ID           string   `json:"xml_id"`
-	Active           bool     `json:"active"`
-	Name             string   `json:"name"`
-	LastName         string   `json:"last_name"`
-	SecondName       string   `json:"second_name"`
-	Email            string   `json:"email"`
-	LastLogin        string   `json:"last_login"`
-	TimeZone         string   `json:"time_zone"`
-	TimeZoneOffset   string   `json:"time_zone_offset"`
-	PersonalPhoto    string   `json:"personal_photo"`
-	PersonalGender   string   `json:"personal_gender"`
-	PersonalWWW      string   `json:"personal_www"`
-	PersonalBirthday string   `json:"personal_birthday"`
-	PersonalMobile   string   `json:"personal_mobile"`
-	PersonalCity     string   `json:"personal_city"`
-	WorkPhone        string   `json:"work_phone"`
-	WorkPosition     string   `json:"work_position"`
-	EmploymentDate   string   `json:"employment_date"`
-	UserType         string   `json:"user_type"`
-	Department       []string `json:"department"`
+	ID               string    `json:"id"`
+	MemberID         string    `json:"member_id"`
+	XML_ID           string    `json:"xml_id"`
+	Active           FlexBool  `json:"active"`
+	Name             string    `json:"name"`
+	LastName         string    `json:"last_name"`
+	SecondName       string    `json:"second_name"`
+	Email            string    `json:"email"`
+	LastLogin        FlexTime  `json:"last_login"`
+	TimeZone         string    `json:"time_zone"`
+	TimeZoneOffset   FlexInt   `json:"time_zone_offset"`
+	PersonalPhoto    string    `json:"personal_photo"`
+	PersonalGender   string    `json:"personal_gender"`
+	PersonalWWW      string    `json:"personal_www"`
+	PersonalBirthday string    `json:"personal_birthday"`
+	PersonalMobile   string    `json:"personal_mobile"`
+	PersonalCity     string    `json:"personal_city"`
+	WorkPhone        string    `json:"work_phone"`
+	WorkPosition     string    `json:"work_position"`
+	EmploymentDate   string    `json:"uf_employment_date"`
+	UserType         string    `json:"user_type"`
+	Department       FlexIDs   `json:"uf_department"`
+	SyncedAt         time.Time `json:"-"`
 }
 
 // months jadvali
 type Month struct {
 	ID                   string `json:"id"`
+	MemberID             string `json:"member_id"`
 	Name                 string `json:"name"`
 	Code                 string `json:"code"`
 	StorageID            string `json:"storage_id"`
@@ -61,36 +71,57 @@ type Month struct {
 }
 
 type CallInfo struct {
-	ID                string `json:"id"`
-	PortalUserID      string `json:"portal_user_id"`
-	PortalNumber      string `json:"portal_number"`
-	PhoneNumber       string `json:"phone_number"`
-	CallID            string `json:"call_id"`
-	ExternalCallID    string `json:"external_call_id"`
-	CallCategory      string `json:"call_category"`
-	CallDuration      string `json:"call_duration"`
-	CallStartDate     string `json:"call_start_date"`
-	CallRecordURL     string `json:"call_record_url"`
-	CallVote          string `json:"call_vote"`
-	Cost              string `json:"cost"`
-	CostCurrency      string `json:"cost_currency"`
-	CallFailedCode    string `json:"call_failed_code"`
-	CallFailedReason  string `json:"call_failed_reason"`
-	CRMEntityType     string `json:"crm_entity_type"`
-	CRMEntityID       string `json:"crm_entity_id"`
-	CRMActivityID     string `json:"crm_activity_id"`
-	RestAppID         string `json:"rest_app_id"`
-	RestAppName       string `json:"rest_app_name"`
-	TranscriptID      string `json:"transcript_id"`
-	TranscriptPending string `json:"transcript_pending"`
-	SessionID         string `json:"session_id"`
-	RedialAttempt     string `json:"redial_attempt"`
-	Comment           string `json:"comment"`
-	RecordDuration    string `json:"record_duration"`
-	RecordFileID      string `json:"record_file_id"`
-	CallType          string `json:"call_type"`
+	ID                string    `json:"id"`
+	MemberID          string    `json:"member_id"`
+	PortalUserID      string    `json:"portal_user_id"`
+	PortalNumber      string    `json:"portal_number"`
+	PhoneNumber       string    `json:"phone_number"`
+	CallID            string    `json:"call_id"`
+	ExternalCallID    string    `json:"external_call_id"`
+	CallCategory      string    `json:"call_category"`
+	CallDuration      FlexInt   `json:"call_duration"`
+	CallStartDate     FlexTime  `json:"call_start_date"`
+	CallRecordURL     string    `json:"call_record_url"`
+	CallVote          FlexInt   `json:"call_vote"`
+	Cost              FlexFloat `json:"cost"`
+	CostCurrency      string    `json:"cost_currency"`
+	CallFailedCode    string    `json:"call_failed_code"`
+	CallFailedReason  string    `json:"call_failed_reason"`
+	CRMEntityType     string    `json:"crm_entity_type"`
+	CRMEntityID       string    `json:"crm_entity_id"`
+	CRMActivityID     string    `json:"crm_activity_id"`
+	RestAppID         string    `json:"rest_app_id"`
+	RestAppName       string    `json:"rest_app_name"`
+	TranscriptID      string    `json:"transcript_id"`
+	TranscriptPending FlexBool  `json:"transcript_pending"`
+	SessionID         string    `json:"session_id"`
+	RedialAttempt     FlexInt   `json:"redial_attempt"`
+	Comment           string    `json:"comment"`
+	RecordDuration    FlexInt   `json:"record_duration"`
+	RecordFileID      string    `json:"record_file_id"`
+	CallType          FlexInt   `json:"call_type"`
 }
+
+// CallTypeName - voximplant CALL_TYPE qiymatining nomi
+func CallTypeName(t FlexInt) string {
+	if !t.Valid {
+		return ""
+	}
+	switch t.Int64 {
+	case 1:
+		return "Chiquvchi"
+	case 2:
+		return "Kiruvchi"
+	case 3:
+		return "Kiruvchi (yo'naltirilgan)"
+	case 4:
+		return "Callback"
+	}
+	return strconv.FormatInt(t.Int64, 10)
+}
+
 type Total struct {
+	MemberID  string `json:"member_id"`
 	AudioPath string `json:"audio_path"`
 	CallID    string `json:"call_id"`
 	UserID    string `json:"user_id"`
@@ -106,4 +137,402 @@ type PortalInfo struct {
 	LastUpdate     time.Time
 	ClientEndpoint string
 	FolderID       string
+	Disabled       bool // avtomatik sinxronizatsiya o'chirilgan
+}
+
+// --- API kalitlari va ruxsatlar (scopes) ---
+const (
+	ScopeCallsRead      = "calls:read"
+	ScopeRecordingsRead = "recordings:read"
+	ScopeAdmin          = "admin"
+)
+
+// APIKey - bitta portalga bog'langan API kaliti (kalitning o'zi emas, faqat hash saqlanadi)
+type APIKey struct {
+	ID         int        `json:"id"`
+	MemberID   string     `json:"member_id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// FrameSession - Bitrix24 ichida (placement) ochilgan ilova sessiyasi
+type FrameSession struct {
+	MemberID  string
+	UserID    string
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// CallFilter - qo'ng'iroqlar ro'yxati uchun filtrlar (bo'sh maydonlar hisobga olinmaydi)
+type CallFilter struct {
+	From     time.Time
+	To       time.Time
+	UserID   string
+	Phone    string
+	CallType int
+	// MinOverlapSec - operator va mijoz bir vaqtda gapirgan vaqt kamida shuncha soniya
+	MinOverlapSec int
+	Limit         int
+	Offset        int
+}
+
+// CallListItem - qo'ng'iroq + xodim ismi + yuklab olingan audio yo'li
+type CallListItem struct {
+	CallInfo
+	UserName        string   `json:"user_name"`
+	UserLastName    string   `json:"user_last_name"`
+	UserDepartments FlexIDs  `json:"user_departments,omitempty"`
+	DepartmentNames []string `json:"department_names,omitempty"`
+	AudioPath       string   `json:"audio_path"`
+
+	// CRM snapshoti (crm_entities), bo'lmasa bo'sh
+	CRMTitle           string `json:"crm_title,omitempty"`
+	CRMCustomerName    string `json:"crm_customer_name,omitempty"`
+	CRMResponsibleID   string `json:"crm_responsible_id,omitempty"`
+	CRMResponsibleName string `json:"crm_responsible_name,omitempty"`
+	CRMStageID         string `json:"crm_stage_id,omitempty"`
+}
+
+// SyncStatus - portalning oxirgi sinxronizatsiya holati
+type SyncStatus struct {
+	MemberID  string     `json:"member_id"`
+	Domain    string     `json:"domain"`
+	FolderID  string     `json:"folder_id"`
+	LastSync  *time.Time `json:"last_sync_at,omitempty"`
+	LastError string     `json:"last_sync_error,omitempty"`
+	LastFiles int        `json:"last_sync_files"`
+}
+
+// AnalyticsQuery - statistikaning davri, guruhlash (user/department) va bo'linishi (day/week/month)
+type AnalyticsQuery struct {
+	From    time.Time
+	To      time.Time
+	Bucket  string
+	GroupBy string
+	Rollup  bool // bo'lim kesimida: qo'ng'iroq barcha yuqori bo'limlarga ham qo'shiladi
+}
+
+// CallStats - bitta xodim (yoki bo'lim) va davr bo'lagi uchun yig'ma ko'rsatkichlar
+type CallStats struct {
+	Bucket              *time.Time `json:"bucket,omitempty"`
+	Key                 string     `json:"key"`
+	Name                string     `json:"name"`
+	Total               int        `json:"total"`
+	Inbound             int        `json:"inbound"`
+	Outbound            int        `json:"outbound"`
+	Missed              int        `json:"missed"`
+	Answered            int        `json:"answered"`
+	TalkSeconds         int64      `json:"talk_seconds"`
+	AvgTalkSeconds      float64    `json:"avg_talk_seconds"`
+	AnswerRate          float64    `json:"answer_rate"`
+	AvgFirstResponseSec *float64   `json:"avg_first_response_seconds,omitempty"`
+	MissedNotReturned   int        `json:"missed_not_returned"`
+	Cost                float64    `json:"cost"`
+	// Yozuv tahlili: operator nutqi ulushi (stereo), eng uzun monolog va bir vaqtda gapirish
+	AvgTalkRatio        *float64 `json:"avg_talk_ratio,omitempty"`
+	AvgLongestMonologue *float64 `json:"avg_longest_monologue_seconds,omitempty"`
+	OverlapSeconds      int64    `json:"overlap_seconds"`
+	// Skript qoidalari: baholangan qo'ng'iroqlar o'rtacha bahosi va buzilishli qo'ng'iroqlar soni
+	AvgComplianceScore   *float64 `json:"avg_compliance_score,omitempty"`
+	ComplianceViolations int      `json:"compliance_violations"`
+}
+
+// UserHistory - xodim holatining (faollik, bo'lim, lavozim) amal qilgan davri
+type UserHistory struct {
+	UserID       string     `json:"user_id"`
+	Active       bool       `json:"active"`
+	Department   FlexIDs    `json:"department_ids"`
+	WorkPosition string     `json:"work_position"`
+	ValidFrom    time.Time  `json:"valid_from"`
+	ValidTo      *time.Time `json:"valid_to,omitempty"`
+}
+
+// Department - department.get natijasi (PARENT - yuqori bo'lim, UF_HEAD - bo'lim rahbari)
+type Department struct {
+	ID         string  `json:"id"`
+	MemberID   string  `json:"member_id"`
+	Name       string  `json:"name"`
+	Sort       FlexInt `json:"sort"`
+	ParentID   string  `json:"parent"`
+	HeadUserID string  `json:"uf_head"`
+}
+
+// CRMEntity - qo'ng'iroqqa bog'langan CRM obyektining saqlangan snapshoti
+type CRMEntity struct {
+	MemberID      string    `json:"member_id"`
+	EntityType    string    `json:"entity_type"`
+	EntityID      string    `json:"entity_id"`
+	Title         string    `json:"title"`
+	CustomerName  string    `json:"customer_name"`
+	ResponsibleID string    `json:"responsible_id"`
+	StageID       string    `json:"stage_id"`
+	Phone         string    `json:"phone"`
+	Email         string    `json:"email"`
+	Raw           []byte    `json:"-"`
+	FetchedAt     time.Time `json:"fetched_at"`
+}
+
+// CRMActivity - crm.activity.get natijasi (qo'ng'iroq faoliyati)
+type CRMActivity struct {
+	MemberID      string    `json:"member_id"`
+	ID            string    `json:"id"`
+	Subject       string    `json:"subject"`
+	ResponsibleID string    `json:"responsible_id"`
+	Direction     FlexInt   `json:"direction"`
+	OwnerTypeID   FlexInt   `json:"owner_type_id"`
+	OwnerID       string    `json:"owner_id"`
+	StartTime     FlexTime  `json:"start_time"`
+	Raw           []byte    `json:"-"`
+	FetchedAt     time.Time `json:"fetched_at"`
+}
+
+// TranscriptionJob - bitta yozuvni matnga o'girish vazifasi
+type TranscriptionJob struct {
+	ID        int    `json:"id"`
+	MemberID  string `json:"member_id"`
+	CallID    string `json:"call_id"`
+	AudioPath string `json:"audio_path"`
+	Status    string `json:"status"`
+	Provider  string `json:"provider,omitempty"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Transcript - qo'ng'iroq matni va vaqt belgilari bilan bo'laklari
+type Transcript struct {
+	MemberID  string              `json:"member_id"`
+	CallID    string              `json:"call_id"`
+	Provider  string              `json:"provider"`
+	Language  string              `json:"language,omitempty"`
+	Text      string              `json:"text"`
+	Segments  []TranscriptSegment `json:"segments"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// TranscriptSegment - matn bo'lagi: vaqt (ms), kanal/so'zlovchi va ishonchlilik (0..1)
+type TranscriptSegment struct {
+	StartMs    int64   `json:"start_ms"`
+	EndMs      int64   `json:"end_ms"`
+	Channel    *int    `json:"channel,omitempty"`
+	Speaker    string  `json:"speaker,omitempty"`
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence"`
+}
+
+// SearchResult - qidiruv natijasi: qo'ng'iroq, reyting, ajratilgan parcha va yozuvdagi vaqtlar (ms)
+type SearchResult struct {
+	CallID        string   `json:"call_id"`
+	Rank          float64  `json:"rank"`
+	Matches       int      `json:"matches"`
+	Source        string   `json:"source"` // transcript yoki comment
+	Snippet       string   `json:"snippet"`
+	StartMs       *int64   `json:"start_ms,omitempty"`
+	Timestamps    []int64  `json:"timestamps_ms,omitempty"`
+	CallStartDate FlexTime `json:"call_start_date"`
+	PhoneNumber   string   `json:"phone_number"`
+	PortalUserID  string   `json:"portal_user_id"`
+	UserName      string   `json:"user_name"`
+}
+
+// Compliance qoidalari turlari
+const (
+	RuleRequired   = "required"   // ibora aytilishi shart (masalan salomlashish)
+	RuleForbidden  = "forbidden"  // taqiqlangan so'zlar
+	RuleCompetitor = "competitor" // raqobatchilar nomi tilga olinishi
+)
+
+// ComplianceRule - portal rahbarlari belgilaydigan kalit so'z qoidasi
+type ComplianceRule struct {
+	ID            int       `json:"id"`
+	MemberID      string    `json:"-"`
+	Name          string    `json:"name"`
+	Kind          string    `json:"kind"`
+	Phrases       []string  `json:"phrases"`
+	Channel       *int      `json:"channel,omitempty"`        // 0 - operator, 1 - mijoz
+	WithinSeconds *int      `json:"within_seconds,omitempty"` // faqat required uchun
+	Weight        int       `json:"weight"`
+	Alert         bool      `json:"alert"`
+	Enabled       bool      `json:"enabled"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ComplianceFlag - bitta qoidaning qo'ng'iroqdagi natijasi
+type ComplianceFlag struct {
+	RuleID   int    `json:"rule_id"`
+	RuleName string `json:"rule_name"`
+	Kind     string `json:"kind"`
+	Passed   bool   `json:"passed"`
+	Matched  string `json:"matched,omitempty"`
+	StartMs  *int64 `json:"start_ms,omitempty"`
+}
+
+// CallCompliance - qo'ng'iroqning umumiy bahosi (0..100) va qoidalar bo'yicha natijalar
+type CallCompliance struct {
+	MemberID    string           `json:"-"`
+	CallID      string           `json:"call_id"`
+	Score       float64          `json:"score"`
+	Violations  int              `json:"violations"`
+	EvaluatedAt time.Time        `json:"evaluated_at"`
+	Flags       []ComplianceFlag `json:"flags"`
+}
+
+// Yozuv fayli holatlari
+const (
+	RecordingOK       = "ok"
+	RecordingMismatch = "mismatch" // davomiylik CallInfo.RecordDuration dan farq qiladi
+	RecordingEmpty    = "empty"
+	RecordingCorrupt  = "corrupt"
+	RecordingMissing  = "missing"
+)
+
+// RecordingMeta - yozuv fayli sarlavhasidan olingan ma'lumotlar va tekshiruv natijasi
+type RecordingMeta struct {
+	MemberID   string    `json:"-"`
+	CallID     string    `json:"call_id"`
+	AudioPath  string    `json:"-"`
+	Format     string    `json:"format,omitempty"`
+	Codec      string    `json:"codec,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	Bitrate    int       `json:"bitrate"`
+	SampleRate int       `json:"sample_rate"`
+	Channels   int       `json:"channels"`
+	SizeBytes  int64     `json:"size_bytes"`
+	Status     string    `json:"status"`
+	Problem    string    `json:"problem,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// DownloadedRecording - total dagi yuklab olingan fayl (qayta tekshirish uchun)
+type DownloadedRecording struct {
+	CallID         string
+	AudioPath      string
+	RecordDuration int64 // Bitrix24 dagi davomiylik, soniya
+}
+
+// CallTalkStats - yozuv bo'yicha suhbat dinamikasi (ms). Kanallar ajratilmagan yozuvda
+// CustomerTalkMs va MonologueChannel bo'sh, OperatorTalkMs - umumiy nutq.
+type CallTalkStats struct {
+	MemberID           string         `json:"-"`
+	CallID             string         `json:"call_id"`
+	DurationMs         int64          `json:"duration_ms"`
+	OperatorTalkMs     int64          `json:"operator_talk_ms"`
+	CustomerTalkMs     *int64         `json:"customer_talk_ms,omitempty"`
+	SilenceMs          int64          `json:"silence_ms"`
+	OverlapMs          int64          `json:"overlap_ms"`
+	LongestMonologueMs int64          `json:"longest_monologue_ms"`
+	MonologueChannel   *int           `json:"monologue_channel,omitempty"`
+	Silences           []SilenceRange `json:"silences"`
+	Approximate        bool           `json:"approximate"`
+	AnalyzedAt         time.Time      `json:"analyzed_at"`
+}
+
+// SilenceRange - yozuvdagi jimlik oralig'i (ms)
+type SilenceRange struct {
+	StartMs int64 `json:"start_ms"`
+	EndMs   int64 `json:"end_ms"`
+}
+
+// MonologueSide - eng uzun monolog kimniki (kanallar ajratilmagan bo'lsa bo'sh)
+func (s *CallTalkStats) MonologueSide() string {
+	switch {
+	case s.MonologueChannel == nil:
+		return ""
+	case *s.MonologueChannel == 0:
+		return "operator"
+	}
+	return "mijoz"
+}
+
+// TalkRatio - operator nutqining umumiy nutqdagi ulushi (faqat stereo yozuvda)
+func (s *CallTalkStats) TalkRatio() *float64 {
+	if s.CustomerTalkMs == nil || s.OperatorTalkMs+*s.CustomerTalkMs == 0 {
+		return nil
+	}
+	r := float64(s.OperatorTalkMs) / float64(s.OperatorTalkMs+*s.CustomerTalkMs)
+	return &r
+}
+
+// Waveform - yozuvning qisqartirilgan to'lqin shakli: har bir BucketMs bo'lakdagi eng baland daraja (0..255)
+type Waveform struct {
+	MemberID    string
+	CallID      string
+	BucketMs    int
+	Peaks       [][]uint8 // kanal bo'yicha
+	Approximate bool
+	CreatedAt   time.Time
+}
+
+// WaveformMarker - to'lqin shaklidagi belgi (kalit so'z, skript qoidasi)
+type WaveformMarker struct {
+	StartMs int64  `json:"start_ms"`
+	Kind    string `json:"kind"` // compliance, keyword
+	Label   string `json:"label"`
+	Passed  *bool  `json:"passed,omitempty"`
+}
+
+// --- Tarixiy yuklash (backfill) ---
+const (
+	BackfillQueued    = "queued"
+	BackfillRunning   = "running"
+	BackfillPaused    = "paused"
+	BackfillDone      = "done"
+	BackfillFailed    = "failed"
+	BackfillCancelled = "cancelled"
+)
+
+// BackfillJob - voximplant.statistic.get ni kunlik oynalar bilan yurib chiqish vazifasi.
+// Cursor - keyingi oyna boshi; har oynadan keyin saqlanadi, shuning uchun to'xtatib davom ettirish mumkin.
+type BackfillJob struct {
+	ID            int        `json:"id"`
+	MemberID      string     `json:"-"`
+	Since         time.Time  `json:"since"`
+	Until         time.Time  `json:"until"`
+	Cursor        time.Time  `json:"cursor"`
+	Status        string     `json:"status"`
+	WindowsTotal  int        `json:"windows_total"`
+	WindowsDone   int        `json:"windows_done"`
+	CallsIngested int        `json:"calls_ingested"`
+	Recordings    int        `json:"recordings"`
+	ElapsedMs     int64      `json:"elapsed_ms"`
+	ETASeconds    *int64     `json:"eta_seconds,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+// ETA - qolgan oynalar uchun taxminiy vaqt (bajarilgan oynalarning o'rtacha davomiyligi bo'yicha);
+// hali birorta oyna tugamagan bo'lsa false
+func (j *BackfillJob) ETA() (time.Duration, bool) {
+	if j.WindowsDone == 0 || j.Status == BackfillDone {
+		return 0, false
+	}
+	perWindow := time.Duration(j.ElapsedMs) * time.Millisecond / time.Duration(j.WindowsDone)
+	remaining := j.WindowsTotal - j.WindowsDone
+	if remaining < 0 {
+		remaining = 0
+	}
+	return perWindow * time.Duration(remaining), true
+}
+
+// SyncSettings - portal sinxronizatsiya jadvali (cron ifodalari portal vaqt mintaqasida)
+type SyncSettings struct {
+	Schedule    []string   `json:"schedule"`
+	TimeZone    string     `json:"timezone"`
+	Paused      bool       `json:"paused"`
+	Disabled    bool       `json:"disabled"`
+	Running     bool       `json:"running"`
+	RequestedAt *time.Time `json:"requested_at,omitempty"`
+	StartedAt   *time.Time `json:"last_started_at,omitempty"`
+	NextRunAt   *time.Time `json:"next_run_at,omitempty"`
+}
+
+// PortalSchedule - rejalashtiruvchi uchun portal va uning jadvali
+type PortalSchedule struct {
+	MemberID string
+	FolderID string
+	SyncSettings
 }
@@ -0,0 +1,280 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bitrix REST bir xil maydonni goh son, goh satr ("12"), goh null qilib qaytaradi.
+// Quyidagi turlar shularning barchasini qabul qiladi va DB ga to'g'ri tur bilan yoziladi.
+
+// FlexInt - 12, "12" -> int64; "", null yoki maydon yo'q bo'lsa Valid=false (DB ga NULL yoziladi,
+// masalan baholanmagan qo'ng'iroqning call_vote si 0 emas)
+type FlexInt struct {
+	Int64 int64
+	Valid bool
+}
+
+// FlexFloat - 1.5, "1.5000" -> float64; "", null yoki maydon yo'q bo'lsa Valid=false (DB ga NULL)
+type FlexFloat struct {
+	Float64 float64
+	Valid   bool
+}
+
+// FlexBool - true, "true", "Y", "1" -> true; qolganlari false
+type FlexBool bool
+
+// FlexTime - RFC3339 satr; "" yoki null bo'lsa nol vaqt (DB ga NULL yoziladi)
+type FlexTime struct {
+	time.Time
+}
+
+// FlexIDs - [1, "5"] -> []string{"1", "5"} (masalan UF_DEPARTMENT); false, "" va null - bo'sh ro'yxat
+type FlexIDs []string
+
+// unquote - JSON qiymatdan qo'shtirnoqni olib tashlaydi; null -> ""
+func unquote(b []byte) (string, error) {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return "", nil
+	}
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(s), nil
+	}
+	return string(b), nil
+}
+
+// NewFlexInt - mavjud qiymat (masalan DB dan o'qilgan oddiy son)
+func NewFlexInt(v int64) FlexInt {
+	return FlexInt{Int64: v, Valid: true}
+}
+
+func (n *FlexInt) UnmarshalJSON(b []byte) error {
+	s, err := unquote(b)
+	if err != nil || s == "" {
+		*n = FlexInt{}
+		return err
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		// "12.0" kabi qiymatlar ham uchraydi
+		f, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil {
+			return fmt.Errorf("butun son kutilgan, keldi: %s", s)
+		}
+		v = int64(f)
+	}
+	*n = NewFlexInt(v)
+	return nil
+}
+
+// String - qiymat yo'q bo'lsa bo'sh satr (eksport katagi bo'sh qoladi)
+func (n FlexInt) String() string {
+	if !n.Valid {
+		return ""
+	}
+	return strconv.FormatInt(n.Int64, 10)
+}
+
+func (n FlexInt) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return strconv.AppendInt(nil, n.Int64, 10), nil
+}
+
+func (n FlexInt) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Int64, nil
+}
+
+func (n *FlexInt) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*n = FlexInt{}
+	case int64:
+		*n = NewFlexInt(v)
+	case float64:
+		*n = NewFlexInt(int64(v))
+	case []byte:
+		return n.UnmarshalJSON(strconv.AppendQuote(nil, string(v)))
+	case string:
+		return n.UnmarshalJSON(strconv.AppendQuote(nil, v))
+	default:
+		return fmt.Errorf("FlexInt: %T turini o'qib bo'lmaydi", src)
+	}
+	return nil
+}
+
+// NewFlexFloat - mavjud qiymat
+func NewFlexFloat(v float64) FlexFloat {
+	return FlexFloat{Float64: v, Valid: true}
+}
+
+func (f *FlexFloat) UnmarshalJSON(b []byte) error {
+	s, err := unquote(b)
+	if err != nil || s == "" {
+		*f = FlexFloat{}
+		return err
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("son kutilgan, keldi: %s", s)
+	}
+	*f = NewFlexFloat(v)
+	return nil
+}
+
+// String - qiymat yo'q bo'lsa bo'sh satr
+func (f FlexFloat) String() string {
+	if !f.Valid {
+		return ""
+	}
+	return strconv.FormatFloat(f.Float64, 'f', -1, 64)
+}
+
+func (f FlexFloat) MarshalJSON() ([]byte, error) {
+	if !f.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(f.Float64)
+}
+
+func (f FlexFloat) Value() (driver.Value, error) {
+	if !f.Valid {
+		return nil, nil
+	}
+	return f.Float64, nil
+}
+
+func (f *FlexFloat) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*f = FlexFloat{}
+	case float64:
+		*f = NewFlexFloat(v)
+	case int64:
+		*f = NewFlexFloat(float64(v))
+	case []byte:
+		// NUMERIC ustuni pq orqali []byte bo'lib keladi
+		return f.UnmarshalJSON(strconv.AppendQuote(nil, string(v)))
+	case string:
+		return f.UnmarshalJSON(strconv.AppendQuote(nil, v))
+	default:
+		return fmt.Errorf("FlexFloat: %T turini o'qib bo'lmaydi", src)
+	}
+	return nil
+}
+
+func (v *FlexBool) UnmarshalJSON(b []byte) error {
+	s, err := unquote(b)
+	if err != nil {
+		return err
+	}
+	*v = FlexBool(parseBool(s))
+	return nil
+}
+
+func parseBool(s string) bool {
+	switch strings.ToUpper(s) {
+	case "Y", "TRUE", "1", "T":
+		return true
+	}
+	return false
+}
+
+func (v FlexBool) Value() (driver.Value, error) {
+	return bool(v), nil
+}
+
+func (v *FlexBool) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case nil:
+		*v = false
+	case bool:
+		*v = FlexBool(s)
+	case []byte:
+		*v = FlexBool(parseBool(string(s)))
+	case string:
+		*v = FlexBool(parseBool(s))
+	default:
+		return fmt.Errorf("FlexBool: %T turini o'qib bo'lmaydi", src)
+	}
+	return nil
+}
+
+func (t *FlexTime) UnmarshalJSON(b []byte) error {
+	s, err := unquote(b)
+	if err != nil || s == "" {
+		t.Time = time.Time{}
+		return err
+	}
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("sana formati noto'g'ri: %s", s)
+	}
+	t.Time = parsed
+	return nil
+}
+
+func (t FlexTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.Time)
+}
+
+func (t FlexTime) Value() (driver.Value, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	return t.Time, nil
+}
+
+func (t *FlexTime) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		t.Time = time.Time{}
+	case time.Time:
+		t.Time = v
+	default:
+		return fmt.Errorf("FlexTime: %T turini o'qib bo'lmaydi", src)
+	}
+	return nil
+}
+
+func (ids *FlexIDs) UnmarshalJSON(b []byte) error {
+	// bo'sh maydonni Bitrix massiv o'rniga false yoki "" qilib qaytaradi
+	switch string(bytes.TrimSpace(b)) {
+	case "false", `""`, "null":
+		*ids = FlexIDs{}
+		return nil
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	out := make(FlexIDs, 0, len(raw))
+	for _, r := range raw {
+		s, err := unquote(r)
+		if err != nil {
+			return err
+		}
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	*ids = out
+	return nil
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFlexInt(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  FlexInt
+		value driver.Value
+	}{
+		{`12`, NewFlexInt(12), int64(12)},
+		{`"12"`, NewFlexInt(12), int64(12)},
+		{`" -3 "`, NewFlexInt(-3), int64(-3)},
+		{`"12.0"`, NewFlexInt(12), int64(12)},
+		{`0`, NewFlexInt(0), int64(0)},
+		{`""`, FlexInt{}, nil},
+		{`null`, FlexInt{}, nil},
+	}
+	for _, tc := range tests {
+		var n FlexInt
+		if err := json.Unmarshal([]byte(tc.in), &n); err != nil {
+			t.Errorf("%s: %v", tc.in, err)
+			continue
+		}
+		if n != tc.want {
+			t.Errorf("%s: %+v, kutilgan %+v", tc.in, n, tc.want)
+		}
+		if v, _ := n.Value(); v != tc.value {
+			t.Errorf("%s: Value %v, kutilgan %v", tc.in, v, tc.value)
+		}
+	}
+
+	var n FlexInt
+	if err := json.Unmarshal([]byte(`"abc"`), &n); err == nil {
+		t.Error(`"abc": xatolik kutilgan edi`)
+	}
+}
+
+func TestFlexFloat(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  FlexFloat
+		value driver.Value
+	}{
+		{`1.5`, NewFlexFloat(1.5), 1.5},
+		{`"1.5000"`, NewFlexFloat(1.5), 1.5},
+		{`0`, NewFlexFloat(0), 0.0},
+		{`""`, FlexFloat{}, nil},
+		{`null`, FlexFloat{}, nil},
+	}
+	for _, tc := range tests {
+		var f FlexFloat
+		if err := json.Unmarshal([]byte(tc.in), &f); err != nil {
+			t.Errorf("%s: %v", tc.in, err)
+			continue
+		}
+		if f != tc.want {
+			t.Errorf("%s: %+v, kutilgan %+v", tc.in, f, tc.want)
+		}
+		if v, _ := f.Value(); v != tc.value {
+			t.Errorf("%s: Value %v, kutilgan %v", tc.in, v, tc.value)
+		}
+	}
+}
+
+// Bitrix javobida maydonning o'zi bo'lmasa ham DB ga NULL yoziladi
+func TestFlexMissingField(t *testing.T) {
+	var call CallInfo
+	if err := json.Unmarshal([]byte(`{"id": "1", "call_duration": "30"}`), &call); err != nil {
+		t.Fatal(err)
+	}
+	for name, v := range map[string]driver.Valuer{"call_vote": call.CallVote, "cost": call.Cost} {
+		if got, _ := v.Value(); got != nil {
+			t.Errorf("%s: NULL kutilgan edi, keldi %v", name, got)
+		}
+	}
+	if got, _ := call.CallDuration.Value(); got != int64(30) {
+		t.Errorf("call_duration: %v", got)
+	}
+
+	b, err := json.Marshal(struct {
+		Vote FlexInt   `json:"vote"`
+		Cost FlexFloat `json:"cost"`
+	}{Cost: NewFlexFloat(2.5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"vote":null,"cost":2.5}` {
+		t.Errorf("JSON: %s", b)
+	}
+}
+
+func TestFlexScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want FlexInt
+	}{
+		{nil, FlexInt{}},
+		{int64(7), NewFlexInt(7)},
+		{[]byte("7"), NewFlexInt(7)},
+	}
+	for _, tc := range tests {
+		var n FlexInt
+		if err := n.Scan(tc.src); err != nil || n != tc.want {
+			t.Errorf("%v: %+v, %v", tc.src, n, err)
+		}
+	}
+
+	var f FlexFloat
+	if err := f.Scan([]byte("12.3400")); err != nil || f != NewFlexFloat(12.34) {
+		t.Errorf("NUMERIC: %+v, %v", f, err)
+	}
+	if err := f.Scan(nil); err != nil || f.Valid {
+		t.Errorf("NULL: %+v, %v", f, err)
+	}
+}
+
+func TestFlexIDs(t *testing.T) {
+	tests := []struct {
+		in   string
+		want FlexIDs
+	}{
+		{`[1, "5"]`, FlexIDs{"1", "5"}},
+		{`["", 3, null]`, FlexIDs{"3"}},
+		{`[]`, FlexIDs{}},
+		{`null`, FlexIDs{}},
+		{`false`, FlexIDs{}},
+		{`""`, FlexIDs{}},
+	}
+	for _, tc := range tests {
+		var ids FlexIDs
+		if err := json.Unmarshal([]byte(tc.in), &ids); err != nil {
+			t.Errorf("%s: %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(ids, tc.want) {
+			t.Errorf("%s: %q, kutilgan %q", tc.in, ids, tc.want)
+		}
+	}
+
+	var ids FlexIDs
+	if err := json.Unmarshal([]byte(`"5"`), &ids); err == nil {
+		t.Error(`"5": xatolik kutilgan edi`)
+	}
+}
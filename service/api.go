@@ -1,49 +1,79 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"bitrix/models"
 	"bitrix/storage"
 )
 
+// postForm - http.PostForm ning ctx bilan varianti, umumiy klient orqali (timeout va qayta urinishlar)
+func postForm(ctx context.Context, target string, data url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return sharedHTTPClient().Do(req)
+}
+
 // callBitrixMethod - universal funksiyamiz
-func callBitrixMethod(db *sql.DB, memberID, method string, params url.Values, clientID, clientSecret string) (map[string]interface{}, error) {
+func callBitrixMethod(ctx context.Context, db *sql.DB, memberID, method string, params url.Values, clientID, clientSecret string) (result map[string]interface{}, err error) {
+	started := time.Now()
+	outcome := "ok"
+	defer func() {
+		bitrixRequests.Inc(method, outcome)
+		bitrixRequestDuration.Since(started, method)
+	}()
+
 	// 1) DB dan tokenni olish
-	tokenInfo, err := storage.GetTokenByMemberID(db, memberID)
+	tokenInfo, err := storage.GetTokenByMemberID(ctx, db, memberID)
 	if err != nil {
+		outcome = "token_error"
 		return nil, fmt.Errorf("Token topilmadi yoki DB xatolik: %v", err)
 	}
 
 	// 2) Token eskirgan bo‘lsa, yangilash
 	if IsTokenExpired(tokenInfo) {
-		if err := RefreshToken(db, tokenInfo, clientID, clientSecret); err != nil {
+		if err := RefreshToken(ctx, db, tokenInfo, clientID, clientSecret); err != nil {
+			outcome = "token_error"
 			return nil, fmt.Errorf("Tokenni yangilashda xatolik: %v", err)
 		}
 	}
 
 	// 3) Endi token yaroqli, so‘rovni yuboramiz
+	// Token URL da emas, forma tanasida: tarmoq xatoligi (*url.Error) matni so'rov URL ini o'z ichiga oladi
+	// va sinxronizatsiya holatida saqlanadi
 	endpoint := tokenInfo.ClientEndpoint // masalan: https://yourdomain.bitrix24.ru/rest/
-	fullURL := fmt.Sprintf("%s%s?auth=%s", endpoint, method, tokenInfo.AccessToken)
+	form := url.Values{}
+	for k, v := range params {
+		form[k] = v
+	}
+	form.Set("auth", tokenInfo.AccessToken)
 
-	resp, err := http.PostForm(fullURL, params)
+	resp, err := postForm(ctx, endpoint+method, form)
 	if err != nil {
+		outcome = "network_error"
 		return nil, fmt.Errorf("Bitrix API so'rovda xatolik: %v", err)
 	}
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != http.StatusOK {
+		outcome = "http_error"
 		return nil, fmt.Errorf("Bitrix API status: %d, body: %s", resp.StatusCode, string(body))
 	}
 
-	var result map[string]interface{}
 	if err := json.Unmarshal(body, &result); err != nil {
+		outcome = "invalid_response"
 		return nil, fmt.Errorf("JSON parse xatolik: %v", err)
 	}
 
@@ -51,11 +81,11 @@ func callBitrixMethod(db *sql.DB, memberID, method string, params url.Values, cl
 }
 
 // GetUserInfo - user.get
-func GetUserInfo(db *sql.DB, memberID, userID, clientID, clientSecret string) (*models.User, error) {
+func GetUserInfo(ctx context.Context, db *sql.DB, memberID, userID, clientID, clientSecret string) (*models.User, error) {
 	params := url.Values{}
 	params.Set("id", userID)
 
-	res, err := callBitrixMethod(db, memberID, "user.get", params, clientID, clientSecret)
+	res, err := callBitrixMethod(WithRetry(ctx), db, memberID, "user.get", params, clientID, clientSecret)
 	if err != nil {
 		return nil, err
 	}
@@ -74,15 +104,16 @@ func GetUserInfo(db *sql.DB, memberID, userID, clientID, clientSecret string) (*
 	if err := json.Unmarshal(data, &user); err != nil {
 		return nil, err
 	}
+	user.MemberID = memberID
 	return &user, nil
 }
 
 // GetCallInfo - voximplant.statistic.get
-func GetCallInfo(db *sql.DB, memberID, callID, clientID, clientSecret string) (*models.CallInfo, error) {
+func GetCallInfo(ctx context.Context, db *sql.DB, memberID, callID, clientID, clientSecret string) (*models.CallInfo, error) {
 	params := url.Values{}
 	params.Set("FILTER[ID]", callID)
 
-	res, err := callBitrixMethod(db, memberID, "voximplant.statistic.get", params, clientID, clientSecret)
+	res, err := callBitrixMethod(WithRetry(ctx), db, memberID, "voximplant.statistic.get", params, clientID, clientSecret)
 	if err != nil {
 		return nil, err
 	}
@@ -101,5 +132,6 @@ func GetCallInfo(db *sql.DB, memberID, callID, clientID, clientSecret string) (*
 	if err := json.Unmarshal(data, &callInfo); err != nil {
 		return nil, err
 	}
+	callInfo.MemberID = memberID
 	return &callInfo, nil
 }
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+const apiKeyPrefix = "bxk_"
+
+var knownScopes = map[string]bool{
+	models.ScopeCallsRead:      true,
+	models.ScopeRecordingsRead: true,
+	models.ScopeAdmin:          true,
+}
+
+// HashSecret - kalit/sessiya tokenining sha256 hashi (DB da faqat shu saqlanadi)
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomSecret - tasodifiy 32 baytli token
+func randomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("tasodifiy token yaratishda xatolik: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateAPIKey - portal uchun yangi API kaliti yaratadi; kalitning o'zi faqat bir marta qaytariladi
+func GenerateAPIKey(ctx context.Context, db *sql.DB, memberID, name string, scopes []string) (string, *models.APIKey, error) {
+	if memberID == "" {
+		return "", nil, fmt.Errorf("member_id bo'sh bo'lishi mumkin emas")
+	}
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("kamida bitta scope kerak")
+	}
+	for _, s := range scopes {
+		if !knownScopes[s] {
+			return "", nil, fmt.Errorf("noma'lum scope: %s", s)
+		}
+	}
+
+	secret, err := randomSecret()
+	if err != nil {
+		return "", nil, err
+	}
+	raw := apiKeyPrefix + secret
+
+	key := &models.APIKey{MemberID: memberID, Name: name, Scopes: scopes}
+	if err := storage.InsertAPIKey(ctx, db, key, HashSecret(raw)); err != nil {
+		return "", nil, fmt.Errorf("API kalitni saqlashda xatolik: %v", err)
+	}
+	return raw, key, nil
+}
+
+// AuthenticateAPIKey - kalitni tekshirib, unga tegishli yozuvni qaytaradi
+func AuthenticateAPIKey(ctx context.Context, db *sql.DB, raw string) (*models.APIKey, error) {
+	if !strings.HasPrefix(raw, apiKeyPrefix) {
+		return nil, fmt.Errorf("API kalit formati noto'g'ri")
+	}
+	key, err := storage.GetAPIKeyByHash(ctx, db, HashSecret(raw))
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.TouchAPIKey(ctx, db, key.ID); err != nil {
+		return nil, fmt.Errorf("API kalitni yangilashda xatolik: %v", err)
+	}
+	return key, nil
+}
+
+// HasScope - ruxsatlar ro'yxatida kerakli scope (yoki admin) bormi
+func HasScope(scopes []string, want string) bool {
+	for _, s := range scopes {
+		if s == want || s == models.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
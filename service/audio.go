@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -9,17 +10,21 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"bitrix/models"
 )
 
 type AudioFile struct {
-	ID          string `json:"ID"`
-	Name        string `json:"NAME"`
-	DownloadURL string `json:"DOWNLOAD_URL"`
+	ID          string          `json:"ID"`
+	Name        string          `json:"NAME"`
+	DownloadURL string          `json:"DOWNLOAD_URL"`
+	CreateTime  models.FlexTime `json:"CREATE_TIME"`
 }
 
 // GetAllAudioFiles - disk.folder.getchildren
-func GetAllAudioFiles(db *sql.DB, memberID, folderID, clientID, clientSecret string) ([]AudioFile, error) {
+func GetAllAudioFiles(ctx context.Context, db *sql.DB, memberID, folderID, clientID, clientSecret string) ([]AudioFile, error) {
 	var allAudioFiles []AudioFile
 	offset := 0
 	limit := 50
@@ -33,8 +38,9 @@ func GetAllAudioFiles(db *sql.DB, memberID, folderID, clientID, clientSecret str
 		params.Add("select[]", "ID")
 		params.Add("select[]", "NAME")
 		params.Add("select[]", "DOWNLOAD_URL")
+		params.Add("select[]", "CREATE_TIME")
 
-		res, err := callBitrixMethod(db, memberID, "disk.folder.getchildren", params, clientID, clientSecret)
+		res, err := callBitrixMethod(WithRetry(ctx), db, memberID, "disk.folder.getchildren", params, clientID, clientSecret)
 		if err != nil {
 			return nil, err
 		}
@@ -57,31 +63,104 @@ func GetAllAudioFiles(db *sql.DB, memberID, folderID, clientID, clientSecret str
 		if len(allAudioFiles) >= response.Total {
 			break
 		}
-		time.Sleep(1 * time.Second)
+		if !pause(ctx, time.Second) {
+			return nil, errStopped
+		}
 	}
 
 	return allAudioFiles, nil
 }
 
-// DownloadAudio - oddiy GET bilan faylni yuklab olish
-func DownloadAudio(downloadURL, fileName string) (string, error) {
-	resp, err := http.Get(downloadURL)
+// downloadsDir - yuklab olingan yozuvlar papkasi; har bir portal o'z ichki papkasiga ega
+const downloadsDir = "downloads"
+
+// RecordingDir - portal yozuvlari papkasi: downloads/<member_id>
+func RecordingDir(memberID string) string {
+	return filepath.Join(downloadsDir, safeFileName(memberID))
+}
+
+// RecordingPath - yozuv fayli yo'li: downloads/<member_id>/<fayl ID>_<nom>. Bitrix24 diskidagi nom portal ichida ham
+// takrorlanishi mumkin, shuning uchun fayl ID si qo'shiladi; nom tashqaridan keladi - tozalanadi.
+func RecordingPath(memberID, fileID, name string) string {
+	return filepath.Join(RecordingDir(memberID), safeFileName(fileID)+"_"+safeFileName(name))
+}
+
+// RecordingOwnedBy - fayl portal papkasi ichidami (boshqa portal yoki umumiy downloads/ dagi fayl berilmaydi)
+func RecordingOwnedBy(memberID, audioPath string) bool {
+	if memberID == "" || audioPath == "" {
+		return false
+	}
+	return strings.HasPrefix(filepath.Clean(audioPath), RecordingDir(memberID)+string(filepath.Separator))
+}
+
+// safeFileName - faqat lotin harflari, raqamlar, ".", "-" va "_" qoldiriladi; bosh nuqtalar olib tashlanadi
+// (yashirin fayl va ".." bo'lmasin), uzunlik cheklanadi
+func safeFileName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '-', c == '_':
+		default:
+			b[i] = '_'
+		}
+	}
+	s := strings.TrimLeft(string(b), ".")
+	if len(s) > 120 {
+		s = s[len(s)-120:]
+	}
+	if s == "" {
+		return "_"
+	}
+	return s
+}
+
+// DownloadAudio - umumiy klient orqali GET bilan faylni portal papkasiga yuklab olish. Fayl avval .part nomi bilan
+// yoziladi va faqat to'liq yuklangach qayta nomlanadi: uzilgan (ctx bekor qilingan) yuklash chala fayl qoldirmaydi.
+func DownloadAudio(ctx context.Context, memberID string, file *AudioFile) (path string, err error) {
+	started := time.Now()
+	defer func() {
+		if err != nil {
+			recordingDownloads.Inc("error")
+			return
+		}
+		recordingDownloads.Inc("ok")
+		recordingDownloadDuration.Since(started)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.DownloadURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := sharedHTTPClient().Do(req)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("yuklab olishda HTTP %d", resp.StatusCode)
+	}
 
-	os.MkdirAll("downloads", os.ModePerm)
-	filePath := filepath.Join("downloads", fileName)
+	if err := os.MkdirAll(RecordingDir(memberID), 0o755); err != nil {
+		return "", err
+	}
+	filePath := RecordingPath(memberID, file.ID, file.Name)
 
-	out, err := os.Create(filePath)
+	out, err := os.Create(filePath + ".part")
 	if err != nil {
 		return "", err
 	}
-	defer out.Close()
-
-	_, err = io.Copy(out, resp.Body)
+	n, err := io.Copy(out, resp.Body)
+	recordingBytes.Add(float64(n))
 	if err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := os.Rename(out.Name(), filePath); err != nil {
 		return "", err
 	}
 
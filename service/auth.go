@@ -1,21 +1,96 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"bitrix/models"
 	"bitrix/storage"
 )
 
-const oauthURL = "https://oauth.bitrix.info/oauth/token/"
+// defaultOAuthServer - bulut Bitrix24 OAuth serveri; sozlamalardagi bitrix.oauth_server bilan almashtiriladi
+var defaultOAuthServer = "https://oauth.bitrix.info"
 
-// ExchangeCodeForToken - code → access_token
-func ExchangeCodeForToken(db *sql.DB, code, clientID, clientSecret, redirectURI string) (*models.TokenInfo, error) {
+// SetDefaultOAuthServer - server_endpoint va qo'lda berilgan server bo'lmagan portallar uchun (main da)
+func SetDefaultOAuthServer(server string) error {
+	s, err := NormalizeOAuthServer(server)
+	if err != nil {
+		return err
+	}
+	if s != "" {
+		defaultOAuthServer = s
+	}
+	return nil
+}
+
+// NormalizeOAuthServer - "host", "https://host/", ".../oauth/token/" yoki ".../rest/" ko'rinishidan "https://host[/yo'l]"
+func NormalizeOAuthServer(raw string) (string, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return "", nil
+	}
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("OAuth server manzili noto'g'ri: %s", raw)
+	}
+	p := strings.TrimRight(u.Path, "/")
+	p = strings.TrimSuffix(p, "/oauth/token")
+	p = strings.TrimSuffix(p, "/rest")
+	return u.Scheme + "://" + strings.ToLower(u.Host) + p, nil
+}
+
+// PortalOAuthServer - portal tokenlari uchun server: qo'lda berilgan, token javobidagi server_endpoint yoki standart
+func PortalOAuthServer(t *models.TokenInfo) string {
+	for _, raw := range []string{t.OAuthServer, t.ServerEndpoint} {
+		if s, err := NormalizeOAuthServer(raw); err == nil && s != "" {
+			return s
+		}
+	}
+	return defaultOAuthServer
+}
+
+// TrustedOAuthServer - o'rnatish so'rovidan kelgan server (server_domain, SERVER_ENDPOINT) standart server yoki
+// box domenlaridan biri bo'lishi shart: aks holda client_secret begona manzilga yuborilishi mumkin.
+// Bo'sh qiymat - standart server.
+func TrustedOAuthServer(raw string, boxDomains []string) (string, error) {
+	s, err := NormalizeOAuthServer(raw)
+	if err != nil {
+		return "", err
+	}
+	if s == "" {
+		return defaultOAuthServer, nil
+	}
+	host := serverHost(s)
+	if host == serverHost(defaultOAuthServer) {
+		return defaultOAuthServer, nil
+	}
+	for _, d := range boxDomains {
+		if box, err := NormalizeOAuthServer(d); err == nil && box != "" && serverHost(box) == host {
+			return box, nil
+		}
+	}
+	return "", fmt.Errorf("ishonchsiz OAuth server: %s", host)
+}
+
+func serverHost(server string) string {
+	if u, err := url.Parse(server); err == nil {
+		return u.Host
+	}
+	return server
+}
+
+// ExchangeCodeForToken - code → access_token. server - kod bergan OAuth server (bulutda oauth.bitrix.info,
+// box da portalning o'zi; TrustedOAuthServer orqali tekshirilgan)
+func ExchangeCodeForToken(ctx context.Context, db *sql.DB, server, code, clientID, clientSecret, redirectURI string) (*models.TokenInfo, error) {
 	data := url.Values{}
 	data.Set("grant_type", "authorization_code")
 	data.Set("client_id", clientID)
@@ -23,7 +98,11 @@ func ExchangeCodeForToken(db *sql.DB, code, clientID, clientSecret, redirectURI
 	data.Set("redirect_uri", redirectURI)
 	data.Set("code", code)
 
-	resp, err := http.PostForm(oauthURL, data)
+	if server == "" {
+		server = defaultOAuthServer
+	}
+	// Kod bir martalik: javob yo'qolsa qayta yuborish baribir invalid_grant qaytaradi
+	resp, err := postForm(WithoutRetry(ctx), server+"/oauth/token/", data)
 	if err != nil {
 		return nil, fmt.Errorf("token so'rovda xatolik: %v", err)
 	}
@@ -56,24 +135,38 @@ func ExchangeCodeForToken(db *sql.DB, code, clientID, clientSecret, redirectURI
 		Scope:          result.Scope,
 		LastUpdate:     time.Now(),
 		ClientEndpoint: result.ClientEndpoint,
+		ServerEndpoint: result.ServerEndpoint,
+	}
+	// Standart bo'lmagan server (box): token yangilash ham shu serverga
+	if server != defaultOAuthServer {
+		tokenInfo.OAuthServer = server
 	}
 
-	if err := storage.InsertOrUpdateToken(db, tokenInfo); err != nil {
+	if err := storage.InsertOrUpdateToken(ctx, db, tokenInfo); err != nil {
 		return nil, fmt.Errorf("token saqlashda xatolik: %v", err)
 	}
 
 	return tokenInfo, nil
 }
 
-// RefreshToken - token muddati tugasa, yangilash
-func RefreshToken(db *sql.DB, t *models.TokenInfo, clientID, clientSecret string) error {
+// RefreshToken - token muddati tugasa, yangilash (portal OAuth serverida, PortalOAuthServer)
+func RefreshToken(ctx context.Context, db *sql.DB, t *models.TokenInfo, clientID, clientSecret string) (err error) {
+	defer func() {
+		if err != nil {
+			tokenRefreshes.Inc("error")
+		} else {
+			tokenRefreshes.Inc("ok")
+		}
+	}()
+
 	data := url.Values{}
 	data.Set("grant_type", "refresh_token")
 	data.Set("client_id", clientID)
 	data.Set("client_secret", clientSecret)
 	data.Set("refresh_token", t.RefreshToken)
 
-	resp, err := http.PostForm(oauthURL, data)
+	// Refresh token bir martalik: birinchi javob yo'qolib, qayta yuborilsa invalid_grant va portal tokensiz qoladi
+	resp, err := postForm(WithoutRetry(ctx), PortalOAuthServer(t)+"/oauth/token/", data)
 	if err != nil {
 		return fmt.Errorf("refresh token so'rovda xatolik: %v", err)
 	}
@@ -89,6 +182,7 @@ func RefreshToken(db *sql.DB, t *models.TokenInfo, clientID, clientSecret string
 		ExpiresIn      int    `json:"expires_in"`
 		Scope          string `json:"scope"`
 		Domain         string `json:"domain"`
+		ServerEndpoint string `json:"server_endpoint"`
 		ClientEndpoint string `json:"client_endpoint"`
 		MemberID       string `json:"member_id"`
 	}
@@ -104,8 +198,11 @@ func RefreshToken(db *sql.DB, t *models.TokenInfo, clientID, clientSecret string
 	if result.ClientEndpoint != "" {
 		t.ClientEndpoint = result.ClientEndpoint
 	}
+	if result.ServerEndpoint != "" {
+		t.ServerEndpoint = result.ServerEndpoint
+	}
 
-	if err := storage.InsertOrUpdateToken(db, t); err != nil {
+	if err := storage.InsertOrUpdateToken(ctx, db, t); err != nil {
 		return fmt.Errorf("token yangilashda xatolik: %v", err)
 	}
 	return nil
@@ -116,3 +213,70 @@ func IsTokenExpired(t *models.TokenInfo) bool {
 	expireTime := t.LastUpdate.Add(time.Duration(t.ExpiresIn) * time.Second)
 	return time.Now().After(expireTime)
 }
+
+// LocalInstall - box dagi lokal ilova o'rnatilganda Bitrix24 yuboradigan forma (AUTH_ID, REFRESH_ID, ...)
+type LocalInstall struct {
+	Domain         string
+	HTTPS          bool
+	MemberID       string
+	AuthID         string
+	RefreshID      string
+	ExpiresIn      int
+	ServerEndpoint string
+}
+
+// InstallLocalApp - box portaldan kelgan o'rnatish: domen box domenlaridan biri bo'lishi, AUTH_ID portalning
+// o'zida (app.info) tasdiqlanishi va member_id boshqa domendagi portalga tegishli bo'lmasligi shart
+func InstallLocalApp(ctx context.Context, db *sql.DB, in LocalInstall, boxDomains []string) (*models.TokenInfo, error) {
+	if in.Domain == "" || in.MemberID == "" || in.AuthID == "" || in.RefreshID == "" {
+		return nil, fmt.Errorf("DOMAIN, member_id, AUTH_ID yoki REFRESH_ID yo'q")
+	}
+	scheme := "http"
+	if in.HTTPS {
+		scheme = "https"
+	}
+	portal, err := TrustedOAuthServer(scheme+"://"+in.Domain, boxDomains)
+	if err != nil || portal == defaultOAuthServer {
+		return nil, fmt.Errorf("box domenlari ro'yxatida yo'q: %s", in.Domain)
+	}
+	// Forma ma'lumotiga ishonmasdan: server ham ishonchli ro'yxatdan, aks holda portalning o'zi
+	server, err := TrustedOAuthServer(in.ServerEndpoint, boxDomains)
+	if err != nil || in.ServerEndpoint == "" {
+		server = portal
+	}
+
+	if existing, err := storage.GetTokenByMemberID(ctx, db, in.MemberID); err == nil &&
+		!strings.EqualFold(existing.PortalDomain, in.Domain) {
+		return nil, fmt.Errorf("member_id boshqa domenga tegishli: %s", existing.PortalDomain)
+	} else if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	t := &models.TokenInfo{
+		PortalDomain:   in.Domain,
+		MemberID:       in.MemberID,
+		AccessToken:    in.AuthID,
+		RefreshToken:   in.RefreshID,
+		ExpiresIn:      in.ExpiresIn,
+		LastUpdate:     time.Now(),
+		ClientEndpoint: portal + "/rest/",
+		ServerEndpoint: server + "/rest/",
+	}
+
+	resp, err := postForm(WithRetry(ctx), t.ClientEndpoint+"app.info", url.Values{"auth": {in.AuthID}})
+	if err != nil {
+		return nil, fmt.Errorf("app.info so'rovda xatolik: %v", err)
+	}
+	defer resp.Body.Close()
+	var info struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil || resp.StatusCode != http.StatusOK || info.Error != "" {
+		return nil, fmt.Errorf("AUTH_ID tasdiqlanmadi, status: %d %s", resp.StatusCode, info.Error)
+	}
+
+	if err := storage.InsertOrUpdateToken(ctx, db, t); err != nil {
+		return nil, fmt.Errorf("token saqlashda xatolik: %v", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+const (
+	// BackfillStaleAfter - shuncha vaqt yangilanmagan "running" vazifa uzilgan deb hisoblanadi
+	BackfillStaleAfter = 15 * time.Minute
+	// backfillLockWait - portal boshqa joyda sinxronlanayotganda lock ni qayta tekshirish oralig'i
+	backfillLockWait = 10 * time.Second
+)
+
+// NewBackfillJob - [since, until) oralig'i uchun vazifa yaratadi; oynalar server vaqt mintaqasidagi
+// kun chegaralari bo'yicha (since kun boshiga tushiriladi). Portalda tugallanmagan vazifa bo'lsa xatolik.
+func NewBackfillJob(ctx context.Context, db *sql.DB, memberID string, since, until time.Time) (*models.BackfillJob, error) {
+	if until.IsZero() {
+		until = time.Now()
+	}
+	since = dayStart(since)
+	if !since.Before(until) {
+		return nil, fmt.Errorf("since until dan oldin bo'lishi kerak")
+	}
+
+	job := &models.BackfillJob{MemberID: memberID, Since: since, Until: until}
+	for t := since; t.Before(until); t = nextWindow(t) {
+		job.WindowsTotal++
+	}
+	if err := storage.CreateBackfillJob(ctx, db, job); err != nil {
+		return nil, fmt.Errorf("backfill vazifasini yaratishda xatolik: %v", err)
+	}
+	return job, nil
+}
+
+// RunBackfill - band qilingan (running) vazifani cursor dan boshlab oyna-ma-oyna bajaradi.
+// Har oynadan keyin checkpoint saqlanadi; vazifa pauza yoki bekor qilinsa keyingi oyna boshlanmaydi.
+// Har oyna portal sinxronizatsiya lock i ostida bajariladi (SyncPortal bilan bir vaqtda emas). Jarayon to'xtatilsa (Stopping) vazifa navbatga qaytariladi va keyingi ishga tushirishda davom etadi.
+func RunBackfill(ctx context.Context, db *sql.DB, job *models.BackfillJob, opts SyncOptions) error {
+	l := backfillLog.With("job_id", job.ID, "member_id", job.MemberID)
+	l.Info("backfill boshlandi", "cursor", job.Cursor.Format("2006-01-02"), "until", job.Until.Format("2006-01-02"),
+		"windows_done", job.WindowsDone, "windows_total", job.WindowsTotal)
+
+	for job.Cursor.Before(job.Until) {
+		end := nextWindow(job.Cursor)
+		if end.After(job.Until) {
+			end = job.Until
+		}
+
+		if Stopping(ctx) {
+			return requeueBackfill(ctx, db, job)
+		}
+		unlock, err := lockBackfillWindow(ctx, db, job)
+		if err == errBackfillStopped {
+			l.Info("backfill to'xtatildi", "cursor", job.Cursor.Format("2006-01-02"))
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("backfill #%d: sinxronizatsiya lock xatolik: %v", job.ID, err)
+		}
+		if unlock == nil {
+			return requeueBackfill(ctx, db, job)
+		}
+
+		started := time.Now()
+		calls, recordings, err := backfillWindow(ctx, db, job, job.Cursor, end, opts)
+		unlock()
+		if err == errBackfillStopped {
+			l.Info("backfill to'xtatildi, oyna keyingi safar qaytadan", "cursor", job.Cursor.Format("2006-01-02"))
+			return nil
+		}
+		if Stopping(ctx) {
+			// Oyna oxirigacha yetmadi (yoki muddat tugab so'rov uzildi) - checkpoint o'zgarmaydi
+			return requeueBackfill(ctx, db, job)
+		}
+		if err != nil {
+			if ferr := storage.FinishBackfillJob(ctx, db, job.ID, models.BackfillFailed, err.Error()); ferr != nil {
+				l.Error("FinishBackfillJob xatolik", "err", ferr)
+			}
+			return fmt.Errorf("backfill #%d, %s oynasi: %v", job.ID, job.Cursor.Format("2006-01-02"), err)
+		}
+
+		job.Cursor = end
+		job.WindowsDone++
+		job.CallsIngested += calls
+		job.Recordings += recordings
+		job.ElapsedMs += time.Since(started).Milliseconds()
+		status, err := storage.SaveBackfillCheckpoint(ctx, db, job)
+		if err != nil {
+			return fmt.Errorf("backfill checkpoint saqlashda xatolik: %v", err)
+		}
+		logBackfillProgress(job)
+		if status != models.BackfillRunning && job.Cursor.Before(job.Until) {
+			l.Info("backfill to'xtatildi", "status", status)
+			return nil
+		}
+	}
+
+	if err := storage.FinishBackfillJob(ctx, db, job.ID, models.BackfillDone, ""); err != nil {
+		return fmt.Errorf("FinishBackfillJob: %v", err)
+	}
+	l.Info("backfill tugadi", "calls", job.CallsIngested, "recordings", job.Recordings)
+	return nil
+}
+
+// errBackfillStopped - vazifa bajarilish paytida pauza yoki bekor qilindi
+var errBackfillStopped = errors.New("backfill to'xtatildi")
+
+// lockBackfillWindow - portal sinxronizatsiya lock i; band bo'lsa bo'shaguncha kutadi. Kutish paytida vazifa
+// yangilanib turadi (uzilgan deb olinmasin); pauza/bekor qilinsa errBackfillStopped,
+// to'xtatish boshlansa (nil, nil).
+func lockBackfillWindow(ctx context.Context, db *sql.DB, job *models.BackfillJob) (func(), error) {
+	for {
+		unlock, ok, err := storage.TryLockPortalSync(ctx, db, job.MemberID)
+		if err != nil || ok {
+			return unlock, err
+		}
+		backfillLog.Debug("portal sinxronlanmoqda, oyna kutadi", "job_id", job.ID, "member_id", job.MemberID)
+		select {
+		case <-time.After(backfillLockWait):
+		case <-Stopped(ctx):
+			return nil, nil
+		}
+		status, err := storage.TouchBackfillJob(ctx, db, job.ID)
+		if err != nil {
+			return nil, err
+		}
+		if status != models.BackfillRunning {
+			return nil, errBackfillStopped
+		}
+	}
+}
+
+// requeueBackfill - jarayon to'xtatilganda vazifani oxirgi checkpoint dan davom ettirish uchun navbatga qaytarish
+func requeueBackfill(ctx context.Context, db *sql.DB, job *models.BackfillJob) error {
+	if _, err := storage.SetBackfillStatus(context.WithoutCancel(ctx), db, job.MemberID, job.ID,
+		models.BackfillQueued, models.BackfillRunning); err != nil {
+		return fmt.Errorf("backfill #%d ni navbatga qaytarishda xatolik: %v", job.ID, err)
+	}
+	backfillLog.Info("backfill navbatga qaytarildi", "job_id", job.ID, "member_id", job.MemberID, "cursor", job.Cursor.Format("2006-01-02"))
+	return nil
+}
+
+// backfillWindow - bitta oynadagi barcha qo'ng'iroqlar (sahifalab); qo'ng'iroqlar va yuklangan yozuvlar soni
+func backfillWindow(ctx context.Context, db *sql.DB, job *models.BackfillJob, from, to time.Time, opts SyncOptions) (int, int, error) {
+	var calls, recordings int
+	start := 0
+	for {
+		page, next, err := ListCallStatistics(ctx, db, job.MemberID, from, to, start, opts.ClientID, opts.ClientSecret)
+		if err != nil {
+			return calls, recordings, err
+		}
+
+		for i := range page {
+			if Stopping(ctx) {
+				return calls, recordings, nil
+			}
+			call := &page[i]
+			call.MemberID = job.MemberID
+			audio, err := backfillAudio(ctx, db, job.MemberID, call, opts)
+			if err != nil {
+				backfillLog.Warn("yozuv topilmadi", "job_id", job.ID, "member_id", job.MemberID, "call_id", call.ID, "err", err)
+			}
+			if err := ingestCall(ctx, db, job.MemberID, call, audio, opts); err != nil {
+				backfillLog.Error("qo'ng'iroqni saqlab bo'lmadi", "job_id", job.ID, "member_id", job.MemberID, "call_id", call.ID, "err", err)
+			} else if audio != nil {
+				recordings++
+			}
+			calls++
+		}
+
+		// Sahifa orasida: boshqa jarayon vazifani uzilgan deb olib ketmasligi va pauzani sezish uchun
+		status, err := storage.TouchBackfillJob(ctx, db, job.ID)
+		if err != nil {
+			return calls, recordings, err
+		}
+		if status != models.BackfillRunning {
+			return calls, recordings, errBackfillStopped
+		}
+
+		if next == 0 || len(page) == 0 {
+			return calls, recordings, nil
+		}
+		start = next
+		if !pause(ctx, 500*time.Millisecond) {
+			return calls, recordings, nil
+		}
+	}
+}
+
+// backfillAudio - qo'ng'iroq yozuvi (Bitrix24 Disk dagi fayl yoki tashqi havola);
+// yozuv yo'q yoki allaqachon yuklab olingan bo'lsa nil
+func backfillAudio(ctx context.Context, db *sql.DB, memberID string, call *models.CallInfo, opts SyncOptions) (*AudioFile, error) {
+	if call.RecordFileID == "" && call.CallRecordURL == "" {
+		return nil, nil
+	}
+	if !opts.Force {
+		done, err := storage.IsCallDownloaded(ctx, db, memberID, call.ID)
+		if err != nil || done {
+			return nil, err
+		}
+	}
+	if call.RecordFileID != "" && call.RecordFileID != "0" {
+		return GetDiskFile(ctx, db, memberID, call.RecordFileID, opts.ClientID, opts.ClientSecret)
+	}
+	return &AudioFile{ID: call.ID, Name: call.ID + ".mp3", DownloadURL: call.CallRecordURL}, nil
+}
+
+// ListCallStatistics - voximplant.statistic.get: [from, to) oralig'idagi qo'ng'iroqlar, boshlanish vaqti bo'yicha.
+// Keyingi sahifa uchun start qiymatini qaytaradi (0 - oxirgi sahifa).
+func ListCallStatistics(ctx context.Context, db *sql.DB, memberID string, from, to time.Time, start int, clientID, clientSecret string) ([]models.CallInfo, int, error) {
+	params := url.Values{}
+	params.Set("FILTER[>=CALL_START_DATE]", from.Format(time.RFC3339))
+	params.Set("FILTER[<CALL_START_DATE]", to.Format(time.RFC3339))
+	params.Set("SORT", "CALL_START_DATE")
+	params.Set("ORDER", "ASC")
+	params.Set("start", strconv.Itoa(start))
+
+	res, err := callBitrixMethod(WithRetry(ctx), db, memberID, "voximplant.statistic.get", params, clientID, clientSecret)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var response struct {
+		Result []models.CallInfo `json:"result"`
+		Next   int               `json:"next"`
+	}
+	bytesRes, _ := json.Marshal(res)
+	if err := json.Unmarshal(bytesRes, &response); err != nil {
+		return nil, 0, fmt.Errorf("voximplant.statistic.get JSON parse xatolik: %v", err)
+	}
+	return response.Result, response.Next, nil
+}
+
+// GetDiskFile - disk.file.get: yozuv faylining nomi va yuklab olish havolasi
+func GetDiskFile(ctx context.Context, db *sql.DB, memberID, fileID, clientID, clientSecret string) (*AudioFile, error) {
+	params := url.Values{}
+	params.Set("id", fileID)
+
+	res, err := callBitrixMethod(WithRetry(ctx), db, memberID, "disk.file.get", params, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Result AudioFile `json:"result"`
+	}
+	bytesRes, _ := json.Marshal(res)
+	if err := json.Unmarshal(bytesRes, &response); err != nil {
+		return nil, fmt.Errorf("disk.file.get JSON parse xatolik: %v", err)
+	}
+	if response.Result.DownloadURL == "" {
+		return nil, fmt.Errorf("fayl topilmadi, ID: %s", fileID)
+	}
+	return &response.Result, nil
+}
+
+func logBackfillProgress(job *models.BackfillJob) {
+	eta := "-"
+	if d, ok := job.ETA(); ok {
+		eta = d.Round(time.Second).String()
+	}
+	backfillLog.Info("backfill jarayoni", "job_id", job.ID, "member_id", job.MemberID, "windows_done", job.WindowsDone,
+		"windows_total", job.WindowsTotal, "calls", job.CallsIngested, "recordings", job.Recordings, "eta", eta)
+}
+
+func dayStart(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// nextWindow - keyingi kun boshi (yozgi/qishki vaqt o'tishida ham kalendar kuni)
+func nextWindow(t time.Time) time.Time {
+	return dayStart(t).AddDate(0, 0, 1)
+}
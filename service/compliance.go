@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+// ComplianceAlerts - buzilish ogohlantirishlari qayerga yuboriladi: portal Telegram chati (Telegram nil bo'lsa
+// o'chirilgan) va xodim bo'limlari rahbarlari - Bitrix24 bildirishnomasi (ClientID bo'sh bo'lsa o'chirilgan).
+// PublicURL - xabardagi havolalar uchun ilova manzili.
+type ComplianceAlerts struct {
+	Telegram     *TelegramNotifier
+	ClientID     string
+	ClientSecret string
+	PublicURL    string
+}
+
+// EvaluateCompliance - portal qoidalarini transkriptga qo'llab, bahoni saqlaydi.
+// Buzilish bo'lsa va alerts berilgan bo'lsa, bir marta ogohlantirish yuboriladi.
+// Qoidalar bo'lmasa hech narsa saqlanmaydi (nil, nil).
+func EvaluateCompliance(ctx context.Context, db *sql.DB, t *models.Transcript, alerts *ComplianceAlerts) (*models.CallCompliance, error) {
+	rules, err := storage.ListComplianceRules(ctx, db, t.MemberID, true)
+	if err != nil {
+		return nil, fmt.Errorf("qoidalarni olishda xatolik: %v", err)
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	result := scoreTranscript(t, rules)
+	if err := storage.SaveCallCompliance(ctx, db, result); err != nil {
+		return nil, fmt.Errorf("bahoni saqlashda xatolik: %v", err)
+	}
+
+	if alerts != nil {
+		if err := sendComplianceAlert(ctx, db, alerts, result, rules); err != nil {
+			transcribeLog.Warn("compliance ogohlantirish xatolik", "member_id", t.MemberID, "call_id", t.CallID, "err", err)
+		}
+	}
+	return result, nil
+}
+
+// scoreTranscript - har bir qoida uchun bayroq va og'irlik bo'yicha baho (0..100)
+func scoreTranscript(t *models.Transcript, rules []models.ComplianceRule) *models.CallCompliance {
+	c := &models.CallCompliance{MemberID: t.MemberID, CallID: t.CallID, Score: 100, EvaluatedAt: time.Now()}
+
+	total, passed := 0, 0
+	for _, r := range rules {
+		matched, startMs := findPhrase(t, r)
+		found := matched != ""
+		ok := found
+		if r.Kind != models.RuleRequired {
+			ok = !found
+		}
+
+		c.Flags = append(c.Flags, models.ComplianceFlag{
+			RuleID:   r.ID,
+			RuleName: r.Name,
+			Kind:     r.Kind,
+			Passed:   ok,
+			Matched:  matched,
+			StartMs:  startMs,
+		})
+
+		weight := r.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		total += weight
+		if ok {
+			passed += weight
+		} else {
+			c.Violations++
+		}
+	}
+	if total > 0 {
+		c.Score = float64(passed) * 100 / float64(total)
+	}
+	return c
+}
+
+// findPhrase - qoidaning birinchi topilgan iborasi va uning vaqti. Bo'laklar bo'lmasa (faqat matn),
+// kanal va vaqt cheklovlari qo'llab bo'lmaydi - butun matn tekshiriladi.
+func findPhrase(t *models.Transcript, r models.ComplianceRule) (string, *int64) {
+	phrases := make([]string, 0, len(r.Phrases))
+	for _, p := range r.Phrases {
+		if p = normalizeText(p); p != "" {
+			phrases = append(phrases, p)
+		}
+	}
+
+	if len(t.Segments) == 0 {
+		text := normalizeText(t.Text)
+		for i, p := range phrases {
+			if strings.Contains(text, p) {
+				return r.Phrases[i], nil
+			}
+		}
+		return "", nil
+	}
+
+	for _, s := range t.Segments {
+		if r.Channel != nil && (s.Channel == nil || *s.Channel != *r.Channel) {
+			continue
+		}
+		if r.Kind == models.RuleRequired && r.WithinSeconds != nil && s.StartMs > int64(*r.WithinSeconds)*1000 {
+			break
+		}
+		text := normalizeText(s.Text)
+		for i, p := range phrases {
+			if strings.Contains(text, p) {
+				startMs := s.StartMs
+				return r.Phrases[i], &startMs
+			}
+		}
+	}
+	return "", nil
+}
+
+// normalizeText - kichik harflar, tinish belgilari o'rniga probel; so'z chegarasi uchun atrofida probel.
+// O'zbek lotin yozuvidagi turli apostroflar (o‘, g', oʻ) bir xil ko'rinishga keltiriladi.
+func normalizeText(s string) string {
+	s = strings.NewReplacer("‘", "'", "’", "'", "ʻ", "'", "ʼ", "'", "`", "'").Replace(strings.ToLower(s))
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	if len(fields) == 0 {
+		return ""
+	}
+	return " " + strings.Join(fields, " ") + " "
+}
+
+// sendComplianceAlert - buzilishlar ro'yxati (faqat alert=true qoidalar bo'yicha) portal chatiga va
+// xodim bo'limlari rahbarlariga. Hech bir manzilga yetib bormasa, keyingi baholashda qayta yuboriladi.
+func sendComplianceAlert(ctx context.Context, db *sql.DB, alerts *ComplianceAlerts, c *models.CallCompliance, rules []models.ComplianceRule) error {
+	alertRules := map[int]bool{}
+	for _, r := range rules {
+		alertRules[r.ID] = r.Alert
+	}
+	var failed []models.ComplianceFlag
+	for _, f := range c.Flags {
+		if !f.Passed && alertRules[f.RuleID] {
+			failed = append(failed, f)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	call, err := storage.GetCall(ctx, db, c.MemberID, c.CallID)
+	if err != nil {
+		return fmt.Errorf("qo'ng'iroq topilmadi: %v", err)
+	}
+	var chatID string
+	if alerts.Telegram != nil {
+		if chatID, err = storage.GetTelegramChatID(ctx, db, c.MemberID); err != nil {
+			return err
+		}
+	}
+	var heads []string
+	if alerts.ClientID != "" && call.PortalUserID != "" {
+		if heads, err = storage.GetUserDepartmentHeads(ctx, db, c.MemberID, call.PortalUserID); err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("bo'lim rahbarlarini olishda xatolik: %v", err)
+		}
+	}
+	if chatID == "" && len(heads) == 0 {
+		return nil
+	}
+
+	claimed, err := storage.ClaimComplianceAlert(ctx, db, c.MemberID, c.CallID)
+	if err != nil || !claimed {
+		return err
+	}
+
+	// Havola Bitrix24 frame sessiyasisiz ochilishi kerak (Telegram, bildirishnoma)
+	link := ""
+	if alerts.PublicURL != "" {
+		if link, err = CallShareURL(ctx, db, alerts.PublicURL, c.MemberID, c.CallID); err != nil {
+			transcribeLog.Warn("CallShareURL xatolik", "member_id", c.MemberID, "call_id", c.CallID, "err", err)
+		}
+	}
+
+	var errs []error
+	delivered := 0
+	if chatID != "" {
+		if err := alerts.Telegram.Send(ctx, chatID, complianceMessage(call, c, failed, link)); err != nil {
+			errs = append(errs, err)
+		} else {
+			delivered++
+		}
+	}
+	for _, head := range heads {
+		if err := notifyBitrixUser(ctx, db, c.MemberID, head, complianceNotice(call, c, failed, link), alerts.ClientID, alerts.ClientSecret); err != nil {
+			errs = append(errs, fmt.Errorf("rahbar %s: %v", head, err))
+		} else {
+			delivered++
+		}
+	}
+	if delivered == 0 {
+		if rerr := storage.ReleaseComplianceAlert(ctx, db, c.MemberID, c.CallID); rerr != nil {
+			transcribeLog.Error("ReleaseComplianceAlert xatolik", "member_id", c.MemberID, "call_id", c.CallID, "err", rerr)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifyBitrixUser - xodimga Bitrix24 tizim bildirishnomasi (im.notify.system.add, "im" ruxsati kerak)
+func notifyBitrixUser(ctx context.Context, db *sql.DB, memberID, userID, message, clientID, clientSecret string) error {
+	params := url.Values{}
+	params.Set("USER_ID", userID)
+	params.Set("MESSAGE", message)
+	_, err := callBitrixMethod(ctx, db, memberID, "im.notify.system.add", params, clientID, clientSecret)
+	return err
+}
+
+// complianceMessage - Telegram HTML formatidagi xabar; link - ulashiladigan havola (bo'sh bo'lsa qo'shilmaydi)
+func complianceMessage(call *models.CallListItem, c *models.CallCompliance, failed []models.ComplianceFlag, link string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "⚠️ <b>Skript buzilishi</b> — baho %.0f/100\n", c.Score)
+	if !call.CallStartDate.IsZero() {
+		fmt.Fprintf(&b, "Sana: %s\n", call.CallStartDate.Format("2006-01-02 15:04"))
+	}
+	if call.PhoneNumber != "" {
+		fmt.Fprintf(&b, "Telefon: %s\n", html.EscapeString(call.PhoneNumber))
+	}
+	if name := strings.TrimSpace(call.UserName + " " + call.UserLastName); name != "" {
+		fmt.Fprintf(&b, "Xodim: %s\n", html.EscapeString(name))
+	}
+	for _, f := range failed {
+		switch f.Kind {
+		case models.RuleRequired:
+			fmt.Fprintf(&b, "• %s: aytilmadi\n", html.EscapeString(f.RuleName))
+		default:
+			fmt.Fprintf(&b, "• %s: «%s»", html.EscapeString(f.RuleName), html.EscapeString(f.Matched))
+			if f.StartMs != nil {
+				fmt.Fprintf(&b, " (%d:%02d)", *f.StartMs/60000, *f.StartMs/1000%60)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if link != "" {
+		fmt.Fprintf(&b, "<a href=\"%s\">Yozuvni ochish</a>\n", html.EscapeString(link))
+	}
+	return b.String()
+}
+
+// complianceNotice - Bitrix24 bildirishnomasi uchun BBCode formatidagi xabar
+func complianceNotice(call *models.CallListItem, c *models.CallCompliance, failed []models.ComplianceFlag, link string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[b]Skript buzilishi[/b] — baho %.0f/100\n", c.Score)
+	if !call.CallStartDate.IsZero() {
+		fmt.Fprintf(&b, "Sana: %s\n", call.CallStartDate.Format("2006-01-02 15:04"))
+	}
+	if call.PhoneNumber != "" {
+		fmt.Fprintf(&b, "Telefon: %s\n", call.PhoneNumber)
+	}
+	if name := strings.TrimSpace(call.UserName + " " + call.UserLastName); name != "" {
+		fmt.Fprintf(&b, "Xodim: %s\n", name)
+	}
+	for _, f := range failed {
+		if f.Kind == models.RuleRequired {
+			fmt.Fprintf(&b, "• %s: aytilmadi\n", f.RuleName)
+		} else {
+			fmt.Fprintf(&b, "• %s: «%s»\n", f.RuleName, f.Matched)
+		}
+	}
+	if link != "" {
+		fmt.Fprintf(&b, "[url=%s]Yozuvni ochish[/url]\n", link)
+	}
+	return b.String()
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+// crmSnapshotTTL - shu muddat ichida olingan snapshot qayta so'ralmaydi
+const crmSnapshotTTL = time.Hour
+
+var crmGetMethods = map[string]string{
+	"LEAD":    "crm.lead.get",
+	"CONTACT": "crm.contact.get",
+	"COMPANY": "crm.company.get",
+	"DEAL":    "crm.deal.get",
+}
+
+// crmRecord - lid/kontakt/kompaniya/bitim uchun umumiy maydonlar
+type crmRecord struct {
+	ID           string `json:"ID"`
+	Title        string `json:"TITLE"`
+	Name         string `json:"NAME"`
+	LastName     string `json:"LAST_NAME"`
+	CompanyTitle string `json:"COMPANY_TITLE"`
+	AssignedByID string `json:"ASSIGNED_BY_ID"`
+	StageID      string `json:"STAGE_ID"`
+	StatusID     string `json:"STATUS_ID"`
+	ContactID    string `json:"CONTACT_ID"`
+	CompanyID    string `json:"COMPANY_ID"`
+	Phone        []struct {
+		Value string `json:"VALUE"`
+	} `json:"PHONE"`
+	Email []struct {
+		Value string `json:"VALUE"`
+	} `json:"EMAIL"`
+}
+
+func (r *crmRecord) personName() string {
+	return strings.TrimSpace(r.Name + " " + r.LastName)
+}
+
+// fetchCRMRecord - crm.*.get chaqirib, natijani va xom JSON ni qaytaradi
+func fetchCRMRecord(ctx context.Context, db *sql.DB, memberID, entityType, entityID, clientID, clientSecret string) (*crmRecord, []byte, error) {
+	method, ok := crmGetMethods[entityType]
+	if !ok {
+		return nil, nil, fmt.Errorf("noma'lum CRM turi: %s", entityType)
+	}
+
+	params := url.Values{}
+	params.Set("id", entityID)
+	res, err := callBitrixMethod(WithRetry(ctx), db, memberID, method, params, clientID, clientSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw, err := json.Marshal(res["result"])
+	if err != nil {
+		return nil, nil, err
+	}
+	var rec crmRecord
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, nil, fmt.Errorf("%s JSON parse xatolik: %v", method, err)
+	}
+	if rec.ID == "" {
+		return nil, nil, fmt.Errorf("%s #%s topilmadi", entityType, entityID)
+	}
+	return &rec, raw, nil
+}
+
+// GetCRMEntity - CRM obyekti snapshoti: yangi bo'lsa DB dan, aks holda Bitrix24 dan olib saqlaydi
+func GetCRMEntity(ctx context.Context, db *sql.DB, memberID, entityType, entityID, clientID, clientSecret string) (*models.CRMEntity, error) {
+	entityType = strings.ToUpper(entityType)
+	cached, err := storage.GetCRMEntity(ctx, db, memberID, entityType, entityID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if cached != nil && time.Since(cached.FetchedAt) < crmSnapshotTTL {
+		return cached, nil
+	}
+
+	rec, raw, err := fetchCRMRecord(ctx, db, memberID, entityType, entityID, clientID, clientSecret)
+	if err != nil {
+		if cached != nil {
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	e := &models.CRMEntity{
+		MemberID:      memberID,
+		EntityType:    entityType,
+		EntityID:      entityID,
+		Title:         rec.Title,
+		ResponsibleID: rec.AssignedByID,
+		Raw:           raw,
+		FetchedAt:     time.Now(),
+	}
+	if len(rec.Phone) > 0 {
+		e.Phone = rec.Phone[0].Value
+	}
+	if len(rec.Email) > 0 {
+		e.Email = rec.Email[0].Value
+	}
+
+	switch entityType {
+	case "LEAD":
+		e.StageID = rec.StatusID
+		e.CustomerName = rec.personName()
+		if e.CustomerName == "" {
+			e.CustomerName = rec.CompanyTitle
+		}
+	case "CONTACT":
+		e.CustomerName = rec.personName()
+	case "COMPANY":
+		e.CustomerName = rec.Title
+	case "DEAL":
+		e.StageID = rec.StageID
+		// Bitimning mijozi - bog'langan kontakt, bo'lmasa kompaniya
+		if rec.ContactID != "" && rec.ContactID != "0" {
+			if c, err := GetCRMEntity(ctx, db, memberID, "CONTACT", rec.ContactID, clientID, clientSecret); err == nil {
+				e.CustomerName = c.CustomerName
+			}
+		}
+		if e.CustomerName == "" && rec.CompanyID != "" && rec.CompanyID != "0" {
+			if c, err := GetCRMEntity(ctx, db, memberID, "COMPANY", rec.CompanyID, clientID, clientSecret); err == nil {
+				e.CustomerName = c.CustomerName
+			}
+		}
+	}
+	if e.CustomerName == "" {
+		e.CustomerName = e.Title
+	}
+
+	if err := storage.UpsertCRMEntity(ctx, db, e); err != nil {
+		return nil, fmt.Errorf("CRM snapshot saqlashda xatolik: %v", err)
+	}
+	return e, nil
+}
+
+// syncCRMActivity - crm.activity.get natijasini saqlaydi
+func syncCRMActivity(ctx context.Context, db *sql.DB, memberID, activityID, clientID, clientSecret string) error {
+	fetchedAt, err := storage.GetCRMActivityFetchedAt(ctx, db, memberID, activityID)
+	if err != nil {
+		return err
+	}
+	if time.Since(fetchedAt) < crmSnapshotTTL {
+		return nil
+	}
+
+	params := url.Values{}
+	params.Set("id", activityID)
+	res, err := callBitrixMethod(WithRetry(ctx), db, memberID, "crm.activity.get", params, clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(res["result"])
+	if err != nil {
+		return err
+	}
+
+	var a models.CRMActivity
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return fmt.Errorf("crm.activity.get JSON parse xatolik: %v", err)
+	}
+	a.MemberID = memberID
+	a.ID = activityID
+	a.Raw = raw
+	a.FetchedAt = time.Now()
+	return storage.UpsertCRMActivity(ctx, db, &a)
+}
+
+// EnrichCallCRM - qo'ng'iroqning CRM obyekti va faoliyatini olib saqlaydi
+func EnrichCallCRM(ctx context.Context, db *sql.DB, memberID string, call *models.CallInfo, clientID, clientSecret string) error {
+	if call.CRMEntityType != "" && call.CRMEntityID != "" && call.CRMEntityID != "0" {
+		if _, err := GetCRMEntity(ctx, db, memberID, call.CRMEntityType, call.CRMEntityID, clientID, clientSecret); err != nil {
+			return err
+		}
+	}
+	if call.CRMActivityID != "" && call.CRMActivityID != "0" {
+		if err := syncCRMActivity(ctx, db, memberID, call.CRMActivityID, clientID, clientSecret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+// SyncDepartments - department.get orqali tashkiliy tuzilmani to'liq sinxronlaydi
+func SyncDepartments(ctx context.Context, db *sql.DB, memberID, clientID, clientSecret string) (int, error) {
+	var seen []string
+	start := 0
+
+	for {
+		params := url.Values{}
+		params.Set("start", strconv.Itoa(start))
+
+		res, err := callBitrixMethod(WithRetry(ctx), db, memberID, "department.get", params, clientID, clientSecret)
+		if err != nil {
+			return len(seen), err
+		}
+
+		var response struct {
+			Result []models.Department `json:"result"`
+			Next   int                 `json:"next"`
+		}
+		bytesRes, _ := json.Marshal(res)
+		if err := json.Unmarshal(bytesRes, &response); err != nil {
+			return len(seen), fmt.Errorf("department.get JSON parse xatolik: %v", err)
+		}
+
+		for i := range response.Result {
+			d := &response.Result[i]
+			if d.HeadUserID == "0" {
+				d.HeadUserID = ""
+			}
+			if err := storage.UpsertDepartment(ctx, db, memberID, d); err != nil {
+				return len(seen), fmt.Errorf("bo'limni saqlashda xatolik (ID: %s): %v", d.ID, err)
+			}
+			seen = append(seen, d.ID)
+		}
+
+		if response.Next == 0 || len(response.Result) == 0 {
+			break
+		}
+		start = response.Next
+		// To'xtatilsa ro'yxat to'liq emas: eski bo'limlar o'chirilmaydi
+		if !pause(ctx, 500*time.Millisecond) {
+			return len(seen), errStopped
+		}
+	}
+
+	if len(seen) > 0 {
+		n, err := storage.DeleteMissingDepartments(ctx, db, memberID, seen)
+		if err != nil {
+			return len(seen), fmt.Errorf("eski bo'limlarni o'chirishda xatolik: %v", err)
+		}
+		if n > 0 {
+			syncLog.Info("bo'limlar o'chirildi", "member_id", memberID, "departments", n)
+		}
+	}
+	return len(seen), nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+
+	"bitrix/export"
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+var exportColumns = []export.Column{
+	{Name: "ID"},
+	{Name: "Sana"},
+	{Name: "Telefon"},
+	{Name: "Portal raqami"},
+	{Name: "Turi"},
+	{Name: "Davomiyligi (s)", Numeric: true},
+	{Name: "Narxi", Numeric: true},
+	{Name: "Valyuta"},
+	{Name: "Xodim ID"},
+	{Name: "Xodim"},
+	{Name: "Bo'lim"},
+	{Name: "CRM turi"},
+	{Name: "CRM ID"},
+	{Name: "Mijoz"},
+	{Name: "Mas'ul"},
+	{Name: "Bosqich"},
+	{Name: "Yozuv"},
+}
+
+// ExportCalls - CallInfo + users qatorlarini CSV/XLSX ga stream qiladi.
+// baseURL bo'sh bo'lmasa, yozuv ustuniga API orqali to'liq havola qo'yiladi.
+func ExportCalls(ctx context.Context, db *sql.DB, memberID string, f models.CallFilter, format, baseURL string, w io.Writer) (int, error) {
+	rw, err := export.New(format, w)
+	if err != nil {
+		return 0, err
+	}
+	if err := rw.WriteHeader(exportColumns); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = storage.StreamCalls(ctx, db, memberID, f, func(c *models.CallListItem) error {
+		recording := ""
+		if c.AudioPath != "" {
+			recording = c.AudioPath
+			if baseURL != "" {
+				recording = strings.TrimSuffix(baseURL, "/") + "/api/calls/" + c.ID + "/recording"
+			}
+		}
+		departments := c.DepartmentNames
+		if len(departments) == 0 {
+			departments = c.UserDepartments
+		}
+		count++
+		return rw.WriteRow([]string{
+			c.ID,
+			formatExportTime(c.CallStartDate),
+			c.PhoneNumber,
+			c.PortalNumber,
+			c.CallType.String(),
+			c.CallDuration.String(),
+			c.Cost.String(),
+			c.CostCurrency,
+			c.PortalUserID,
+			strings.TrimSpace(c.UserName + " " + c.UserLastName),
+			strings.Join(departments, ", "),
+			c.CRMEntityType,
+			c.CRMEntityID,
+			c.CRMCustomerName,
+			c.CRMResponsibleName,
+			c.CRMStageID,
+			recording,
+		})
+	})
+	if err != nil {
+		return count, fmt.Errorf("eksportda xatolik: %v", err)
+	}
+	return count, rw.Close()
+}
+
+func formatExportTime(t models.FlexTime) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
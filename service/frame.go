@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+const frameSessionTTL = 8 * time.Hour
+
+// VerifyFrameAuth - Bitrix24 placement orqali kelgan AUTH_ID ni portalning o'z endpointida tekshiradi.
+// Domen DB dagi portal domeni bilan mos kelishi shart, aks holda boshqa portal ma'lumotlari ochilib qolishi mumkin.
+func VerifyFrameAuth(ctx context.Context, db *sql.DB, memberID, domain, authID string) (*models.FrameSession, error) {
+	if memberID == "" || authID == "" {
+		return nil, fmt.Errorf("member_id yoki AUTH_ID yo'q")
+	}
+
+	tokenInfo, err := storage.GetTokenByMemberID(ctx, db, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("portal topilmadi: %v", err)
+	}
+	if domain != "" && !strings.EqualFold(domain, tokenInfo.PortalDomain) {
+		return nil, fmt.Errorf("domen mos kelmadi: %s", domain)
+	}
+
+	resp, err := postForm(WithRetry(ctx), tokenInfo.ClientEndpoint+"profile", url.Values{"auth": {authID}})
+	if err != nil {
+		return nil, fmt.Errorf("profile so'rovda xatolik: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("AUTH_ID yaroqsiz, status: %d", resp.StatusCode)
+	}
+
+	var profile struct {
+		Result struct {
+			ID    json.Number `json:"ID"`
+			Admin bool        `json:"ADMIN"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &profile); err != nil {
+		return nil, fmt.Errorf("profile JSON parse xatolik: %v", err)
+	}
+
+	scopes := []string{models.ScopeCallsRead, models.ScopeRecordingsRead}
+	if profile.Result.Admin {
+		scopes = append(scopes, models.ScopeAdmin)
+	}
+
+	return &models.FrameSession{
+		MemberID:  memberID,
+		UserID:    profile.Result.ID.String(),
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(frameSessionTTL),
+	}, nil
+}
+
+// CreateFrameSession - sessiyani saqlab, cookie uchun tokenni qaytaradi
+func CreateFrameSession(ctx context.Context, db *sql.DB, s *models.FrameSession) (string, error) {
+	token, err := randomSecret()
+	if err != nil {
+		return "", err
+	}
+	if err := storage.InsertFrameSession(ctx, db, HashSecret(token), s); err != nil {
+		return "", fmt.Errorf("sessiyani saqlashda xatolik: %v", err)
+	}
+	return token, nil
+}
+
+// AuthenticateFrameSession - cookie dagi token orqali sessiyani olish
+func AuthenticateFrameSession(ctx context.Context, db *sql.DB, token string) (*models.FrameSession, error) {
+	return storage.GetFrameSession(ctx, db, HashSecret(token))
+}
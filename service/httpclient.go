@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPOptions - tashqi so'rovlar (Bitrix24 REST, OAuth, yozuvlarni yuklab olish) klienti sozlamalari
+type HTTPOptions struct {
+	// ConnectTimeout - TCP ulanish va TLS handshake
+	ConnectTimeout time.Duration
+	// ReadTimeout - javob sarlavhasini kutish va javob tanasidagi har bir o'qish orasidagi jimlik
+	// (katta fayl uzoq yuklanishi mumkin, faqat "osilib qolgan" ulanish uziladi)
+	ReadTimeout time.Duration
+	// Retries - tarmoq xatoligi yoki 5xx javobdan keyin qayta urinishlar soni (faqat takrorlash xavfsiz
+	// so'rovlar uchun, qarang: WithRetry)
+	Retries int
+	// RetryWait - birinchi qayta urinishdan oldingi kutish; har safar ikki baravar, tasodifiy jitter bilan
+	RetryWait time.Duration
+	// Proxy - proxy URL (bo'sh bo'lsa HTTPS_PROXY/HTTP_PROXY/NO_PROXY environment dan)
+	Proxy string
+	// CAFile - qo'shimcha ishonchli sertifikatlar (PEM), tizim sertifikatlariga qo'shiladi
+	CAFile string
+}
+
+// HTTPClient - timeout va qayta urinishlar bilan umumiy klient
+type HTTPClient struct {
+	client      *http.Client
+	readTimeout time.Duration
+	retries     int
+	retryWait   time.Duration
+}
+
+// NewHTTPClient - sozlamalardan klient; proxy yoki CA fayli noto'g'ri bo'lsa xatolik
+func NewHTTPClient(opts HTTPOptions) (*HTTPClient, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: opts.ConnectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = opts.ConnectTimeout
+	// Javob sarlavhasini kutish do() da: muddat so'rov bo'yicha uzaytirilishi mumkin (WithResponseTimeout)
+
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("proxy URL noto'g'ri: %s", opts.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("CA faylini o'qishda xatolik: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA faylida sertifikat topilmadi: %s", opts.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return &HTTPClient{
+		client:      &http.Client{Transport: transport},
+		readTimeout: opts.ReadTimeout,
+		retries:     opts.Retries,
+		retryWait:   opts.RetryWait,
+	}, nil
+}
+
+var (
+	httpMu     sync.RWMutex
+	httpClient = mustHTTPClient(HTTPOptions{
+		ConnectTimeout: 10 * time.Second,
+		ReadTimeout:    time.Minute,
+		Retries:        3,
+		RetryWait:      time.Second,
+	})
+)
+
+func mustHTTPClient(opts HTTPOptions) *HTTPClient {
+	c, err := NewHTTPClient(opts)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// SetHTTPClient - barcha tashqi so'rovlar uchun klientni almashtirish (main da, sozlamalar yuklangach)
+func SetHTTPClient(c *HTTPClient) {
+	httpMu.Lock()
+	defer httpMu.Unlock()
+	httpClient = c
+}
+
+func sharedHTTPClient() *HTTPClient {
+	httpMu.RLock()
+	defer httpMu.RUnlock()
+	return httpClient
+}
+
+type retryKey struct{}
+
+type retryPolicy int
+
+const (
+	retryIdempotent retryPolicy = iota // GET, HEAD, ... (standart)
+	retryAlways                        // POST ham: faqat o'qiydigan REST metodlar
+	retryNever                         // bir martalik amallar: OAuth token olish
+)
+
+// WithRetry - ctx bilan yuborilgan POST so'rovlar ham qayta yuboriladi. Faqat takrorlash natijani
+// o'zgartirmaydigan chaqiruvlar (masalan, *.get REST metodlari) uchun.
+func WithRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryKey{}, retryAlways)
+}
+
+// WithoutRetry - hech qanday qayta urinish yo'q (ichkaridagi WithRetry ni ham bekor qiladi)
+func WithoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryKey{}, retryNever)
+}
+
+type responseTimeoutKey struct{}
+
+// WithResponseTimeout - ctx bilan yuborilgan so'rovlarda javobni kutish va javob tanasidagi jimlik chegarasi
+// ReadTimeout o'rniga d (masalan, javobni bir necha daqiqa tayyorlaydigan transkripsiya serveri uchun)
+func WithResponseTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, responseTimeoutKey{}, d)
+}
+
+// canRetry - javob (yoki server) ga yetib borgan so'rovni qayta yuborish mumkinmi
+func canRetry(req *http.Request) bool {
+	policy, _ := req.Context().Value(retryKey{}).(retryPolicy)
+	switch policy {
+	case retryAlways:
+		return true
+	case retryNever:
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// notSent - ulanish o'rnatilmagan (DNS, connect): so'rov serverga yetmagan, har qanday so'rovni qayta yuborish xavfsiz
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// Do - so'rovni yuborish. Takrorlash xavfsiz so'rovlar (GET yoki WithRetry) tarmoq xatoligi va 5xx javobda,
+// boshqalari faqat ulanish o'rnatilmaganda qayta yuboriladi (WithoutRetry bo'lsa hech qachon); so'rov tanasi
+// req.GetBody orqali qayta o'qiladi. Javob tanasini o'qishda ReadTimeout dan uzoq jimlik bo'lsa so'rov uziladi.
+func (c *HTTPClient) Do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("so'rov tanasini qayta yuborib bo'lmaydi: %s", req.URL.Redacted())
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := c.do(req)
+		var retry bool
+		if err != nil {
+			retry = canRetry(req) || (notSent(err) && req.Context().Value(retryKey{}) != retryNever)
+		} else {
+			retry = resp.StatusCode >= 500 && canRetry(req)
+		}
+		if !retry || attempt >= c.retries || req.Context().Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(c.backoff(attempt)):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// do - bitta urinish. Javob sarlavhasi so'rov tanasi to'liq yuborilgach timeout davomida kelishi kerak
+// (http.Transport.ResponseHeaderTimeout kabi); javob tanasi jimlik taymeri bilan o'raladi.
+func (c *HTTPClient) do(req *http.Request) (*http.Response, error) {
+	timeout := c.readTimeout
+	if d, ok := req.Context().Value(responseTimeoutKey{}).(time.Duration); ok {
+		timeout = d
+	}
+	if timeout <= 0 {
+		return c.client.Do(req)
+	}
+	ctx, cancel := context.WithCancel(req.Context())
+	var expired atomic.Bool
+	header := time.AfterFunc(timeout, func() {
+		expired.Store(true)
+		cancel()
+	})
+	header.Stop()
+
+	sent := req.WithContext(ctx)
+	if req.Body == nil || req.Body == http.NoBody {
+		header.Reset(timeout)
+	} else {
+		sent.Body = &sentBody{ReadCloser: req.Body, done: func() { header.Reset(timeout) }}
+	}
+	resp, err := c.client.Do(sent)
+	header.Stop()
+	if err != nil {
+		cancel()
+		if expired.Load() {
+			return nil, fmt.Errorf("javobni kutishda timeout (%s): %s", timeout, req.URL.Redacted())
+		}
+		return nil, err
+	}
+	body := &idleTimeoutBody{ReadCloser: resp.Body, timeout: timeout, cancel: cancel}
+	body.timer = time.AfterFunc(timeout, func() {
+		body.expired.Store(true)
+		cancel()
+	})
+	resp.Body = body
+	return resp, nil
+}
+
+// sentBody - so'rov tanasi oxirigacha o'qilganda (serverga yuborilganda) done bir marta chaqiriladi
+type sentBody struct {
+	io.ReadCloser
+	done func()
+	once sync.Once
+}
+
+func (b *sentBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.done)
+	}
+	return n, err
+}
+
+// backoff - RetryWait * 2^attempt, [d/2, d) oralig'ida tasodifiy (bir vaqtda qaytgan so'rovlar tarqalsin)
+func (c *HTTPClient) backoff(attempt int) time.Duration {
+	d := c.retryWait << attempt
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// idleTimeoutBody - har bir Read dan keyin taymer qayta boshlanadi; muddat o'tsa so'rov ctx i bekor qilinadi
+type idleTimeoutBody struct {
+	io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	expired atomic.Bool
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && b.expired.Load() {
+		return n, fmt.Errorf("javobni o'qishda timeout (%s davomida ma'lumot kelmadi)", b.timeout)
+	}
+	b.timer.Reset(b.timeout)
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,12 @@
+package service
+
+import "bitrix/logging"
+
+// Quyi tizim loggerlari (darajasi [log] levels orqali alohida sozlanadi)
+var (
+	bitrixLog     = logging.For("bitrix")
+	syncLog       = logging.For("sync")
+	backfillLog   = logging.For("backfill")
+	schedulerLog  = logging.For("scheduler")
+	transcribeLog = logging.For("transcribe")
+)
@@ -0,0 +1,19 @@
+package service
+
+import "bitrix/metrics"
+
+// Tashqi so'rovlar va yuklab olish metrikalari (/metrics)
+var (
+	bitrixRequests = metrics.NewCounter("bitrix_api_requests_total",
+		"Bitrix24 REST so'rovlari: metod va natija (ok, token_error, network_error, http_error, invalid_response)", "method", "outcome")
+	bitrixRequestDuration = metrics.NewHistogram("bitrix_api_request_duration_seconds",
+		"Bitrix24 REST so'rovi davomiyligi (qayta urinishlar bilan)", metrics.DefBuckets, "method")
+	tokenRefreshes = metrics.NewCounter("bitrix_token_refreshes_total",
+		"OAuth token yangilashlar: ok yoki error", "outcome")
+	recordingDownloads = metrics.NewCounter("bitrix_recording_downloads_total",
+		"Yozuvlarni yuklab olish: ok yoki error", "outcome")
+	recordingBytes = metrics.NewCounter("bitrix_recording_download_bytes_total",
+		"Yuklab olingan yozuvlar hajmi (bayt)")
+	recordingDownloadDuration = metrics.NewHistogram("bitrix_recording_download_duration_seconds",
+		"Bitta yozuvni yuklab olish davomiyligi", []float64{.25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300})
+)
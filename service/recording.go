@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"bitrix/audio"
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+// Fayl davomiyligi CallInfo.RecordDuration dan shuncha (yoki 5%) ko'p farq qilsa - mismatch
+const recordingDurationTolerance = 2 * time.Second
+
+// InspectRecording - yuklab olingan faylning sarlavhasini tahlil qilib, natijani saqlaydi.
+// Fayl muammoli bo'lsa ham xatolik qaytarilmaydi - holat (status) va sabab (problem) yoziladi.
+func InspectRecording(ctx context.Context, db *sql.DB, memberID string, call *models.CallInfo, audioPath string) (*models.RecordingMeta, error) {
+	meta := &models.RecordingMeta{
+		MemberID:  memberID,
+		CallID:    call.ID,
+		AudioPath: audioPath,
+		Status:    models.RecordingOK,
+		CheckedAt: time.Now(),
+	}
+
+	info, err := audio.Probe(audioPath)
+	switch {
+	case os.IsNotExist(err):
+		meta.Status = models.RecordingMissing
+		meta.Problem = "fayl topilmadi"
+	case errors.Is(err, audio.ErrEmpty):
+		meta.Status = models.RecordingEmpty
+		meta.Problem = err.Error()
+	case err != nil:
+		meta.Status = models.RecordingCorrupt
+		meta.Problem = err.Error()
+	default:
+		meta.Format = info.Format
+		meta.Codec = info.Codec
+		meta.DurationMs = info.Duration.Milliseconds()
+		meta.Bitrate = info.Bitrate
+		meta.SampleRate = info.SampleRate
+		meta.Channels = info.Channels
+		meta.SizeBytes = info.Size
+		meta.Status, meta.Problem = checkRecording(info, time.Duration(call.RecordDuration.Int64)*time.Second)
+	}
+	if st, err := os.Stat(audioPath); err == nil {
+		meta.SizeBytes = st.Size()
+	}
+
+	if err := storage.SaveRecordingMeta(ctx, db, meta); err != nil {
+		return meta, fmt.Errorf("yozuv ma'lumotlarini saqlashda xatolik: %v", err)
+	}
+	return meta, nil
+}
+
+// checkRecording - kesilgan/ortiqcha baytli fayllar va Bitrix24 dagi davomiylik bilan solishtirish
+func checkRecording(info *audio.Info, expected time.Duration) (string, string) {
+	if info.Duration == 0 {
+		return models.RecordingEmpty, "audio ma'lumot yo'q"
+	}
+	if info.Truncated {
+		return models.RecordingCorrupt, "fayl oxiri kesilgan"
+	}
+	if info.Size > 0 && info.JunkBytes*20 > info.Size {
+		return models.RecordingCorrupt, fmt.Sprintf("%d bayt tanib bo'lmadi", info.JunkBytes)
+	}
+	if expected > 0 {
+		diff := info.Duration - expected
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > recordingDurationTolerance && diff*20 > expected {
+			return models.RecordingMismatch, fmt.Sprintf("davomiylik %s, Bitrix24 da %s",
+				info.Duration.Round(time.Second), expected)
+		}
+	}
+	return models.RecordingOK, ""
+}
+
+// talkWindowMs - nutq faolligini aniqlash oynasi
+const talkWindowMs = 50
+
+// waveformBuckets - pleyer uchun to'lqin shaklidagi bo'laklar soni (ko'pi bilan)
+const waveformBuckets = 1000
+
+// AnalyzeRecording - yozuvni bir marta o'qib, suhbat dinamikasi va to'lqin shaklini saqlaydi.
+// Stereo yozuvda 0-kanal operator, 1-kanal mijoz deb olinadi.
+func AnalyzeRecording(ctx context.Context, db *sql.DB, meta *models.RecordingMeta) (*models.CallTalkStats, error) {
+	env, err := audio.ReadEnvelope(meta.AudioPath, talkWindowMs)
+	if err != nil {
+		return nil, fmt.Errorf("yozuvni o'qib bo'lmadi: %v", err)
+	}
+	if _, err := saveWaveform(ctx, db, meta, env); err != nil {
+		return nil, err
+	}
+	ts := audio.AnalyzeTalk(env)
+
+	stats := &models.CallTalkStats{
+		MemberID:           meta.MemberID,
+		CallID:             meta.CallID,
+		DurationMs:         ts.DurationMs,
+		SilenceMs:          ts.SilenceMs,
+		OverlapMs:          ts.OverlapMs,
+		LongestMonologueMs: ts.LongestMonologueMs,
+		Approximate:        env.Approximate,
+		AnalyzedAt:         time.Now(),
+	}
+	if len(ts.TalkMs) > 0 {
+		stats.OperatorTalkMs = ts.TalkMs[0]
+	}
+	if len(ts.TalkMs) == 2 {
+		stats.CustomerTalkMs = &ts.TalkMs[1]
+		stats.MonologueChannel = &ts.MonologueChannel
+	}
+	for _, s := range ts.Silences {
+		stats.Silences = append(stats.Silences, models.SilenceRange{StartMs: s.StartMs, EndMs: s.EndMs})
+	}
+
+	if err := storage.SaveTalkStats(ctx, db, stats); err != nil {
+		return nil, fmt.Errorf("tahlilni saqlashda xatolik: %v", err)
+	}
+	return stats, nil
+}
+
+// GenerateWaveform - faqat to'lqin shakli (oldin yuklab olingan, hali tahlil qilinmagan yozuvlar uchun)
+func GenerateWaveform(ctx context.Context, db *sql.DB, meta *models.RecordingMeta) (*models.Waveform, error) {
+	env, err := audio.ReadEnvelope(meta.AudioPath, talkWindowMs)
+	if err != nil {
+		return nil, fmt.Errorf("yozuvni o'qib bo'lmadi: %v", err)
+	}
+	return saveWaveform(ctx, db, meta, env)
+}
+
+func saveWaveform(ctx context.Context, db *sql.DB, meta *models.RecordingMeta, env *audio.Envelope) (*models.Waveform, error) {
+	bucketMs, peaks := audio.Peaks(env, waveformBuckets)
+	w := &models.Waveform{
+		MemberID:    meta.MemberID,
+		CallID:      meta.CallID,
+		BucketMs:    bucketMs,
+		Peaks:       peaks,
+		Approximate: env.Approximate,
+		CreatedAt:   time.Now(),
+	}
+	if err := storage.SaveWaveform(ctx, db, w); err != nil {
+		return nil, fmt.Errorf("to'lqin shaklini saqlashda xatolik: %v", err)
+	}
+	return w, nil
+}
+
+// VerifyRecordings - portalning barcha yuklab olingan fayllarini qayta tekshiradi (InspectRecording).
+// Holatlar bo'yicha sonlarni qaytaradi; fn har bir muammoli yozuv uchun chaqiriladi (nil bo'lishi mumkin).
+func VerifyRecordings(ctx context.Context, db *sql.DB, memberID string, fn func(*models.RecordingMeta)) (map[string]int, error) {
+	list, err := storage.ListDownloadedRecordings(ctx, db, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("yozuvlar ro'yxatini olishda xatolik: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, r := range list {
+		call := &models.CallInfo{ID: r.CallID, MemberID: memberID, RecordDuration: models.NewFlexInt(r.RecordDuration)}
+		meta, err := InspectRecording(ctx, db, memberID, call, r.AudioPath)
+		if err != nil {
+			syncLog.Error("InspectRecording xatolik", "member_id", memberID, "call_id", r.CallID, "err", err)
+		}
+		if meta == nil {
+			continue
+		}
+		counts[meta.Status]++
+		if meta.Status != models.RecordingOK && fn != nil {
+			fn(meta)
+		}
+	}
+	return counts, nil
+}
+
+// RelocateLegacyRecordings - ilgari barcha portallar uchun umumiy downloads/<nom> ga yozilgan fayllarni
+// downloads/<member_id>/ ga ko'chiradi. Bir xil nomli fayllar bir-birini ustidan yozgan bo'lishi mumkin: faylga
+// bitta portalning bitta qo'ng'iroqi bog'langan bo'lsagina ko'chiriladi, aks holda (yoki fayl yo'q bo'lsa) bog'langan
+// yozuvlar o'chiriladi va qo'ng'iroq keyingi sinxronizatsiyada qayta yuklanadi. Egasi aniqlanmagan fayl diskda
+// qoldiriladi, lekin endi hech bir portalga berilmaydi.
+func RelocateLegacyRecordings(ctx context.Context, db *sql.DB) (moved, dropped int, err error) {
+	list, err := storage.ListLegacyRecordings(ctx, db, downloadsDir+"/")
+	if err != nil {
+		return 0, 0, fmt.Errorf("eski yozuvlar ro'yxatini olishda xatolik: %v", err)
+	}
+
+	for _, r := range list {
+		l := syncLog.With("path", r.AudioPath, "member_ids", r.MemberIDs, "call_ids", r.CallIDs)
+		if len(r.MemberIDs) == 1 && len(r.CallIDs) == 1 {
+			memberID := r.MemberIDs[0]
+			newPath := filepath.Join(RecordingDir(memberID), safeFileName(filepath.Base(r.AudioPath)))
+			err := os.MkdirAll(RecordingDir(memberID), 0o755)
+			if err == nil {
+				err = os.Rename(r.AudioPath, newPath)
+			}
+			if err == nil {
+				if err := storage.MoveRecordingPath(ctx, db, memberID, r.AudioPath, newPath); err != nil {
+					return moved, dropped, fmt.Errorf("%s: yo'lni yangilashda xatolik: %v", r.AudioPath, err)
+				}
+				moved++
+				continue
+			}
+			if !errors.Is(err, os.ErrNotExist) {
+				return moved, dropped, fmt.Errorf("%s: faylni ko'chirishda xatolik: %v", r.AudioPath, err)
+			}
+			l.Warn("eski yozuv fayli topilmadi, qayta yuklanadi")
+		} else {
+			l.Warn("eski yozuv bir nechta qo'ng'iroqqa bog'langan, qayta yuklanadi")
+		}
+
+		if err := storage.ForgetRecordingPath(ctx, db, r.AudioPath); err != nil {
+			return moved, dropped, fmt.Errorf("%s: yozuvlarni o'chirishda xatolik: %v", r.AudioPath, err)
+		}
+		dropped++
+	}
+	return moved, dropped, nil
+}
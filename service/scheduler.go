@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"bitrix/cron"
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+// schedulerTick - jadval va "hozir sinxronlash" so'rovlarini tekshirish oralig'i
+const schedulerTick = 15 * time.Second
+
+// ErrSyncRunning - portal sinxronizatsiyasi boshqa joyda (shu yoki boshqa jarayonda) bajarilmoqda
+var ErrSyncRunning = errors.New("portal sinxronizatsiyasi allaqachon bajarilmoqda")
+
+// SyncPortal - portal uchun bitta sinxronizatsiya: katalog (eskirgan bo'lsa) va yozuvlar.
+// Bir portal bir vaqtda faqat bir marta sinxronlanadi (DB advisory lock), aks holda ErrSyncRunning.
+func SyncPortal(ctx context.Context, db *sql.DB, memberID, folderID string, opts SyncOptions) (int, error) {
+	if folderID == "" {
+		return 0, fmt.Errorf("portal %s: folder_id belgilanmagan", memberID)
+	}
+	unlock, ok, err := storage.TryLockPortalSync(ctx, db, memberID)
+	if err != nil {
+		return 0, fmt.Errorf("sinxronizatsiya lock xatolik: %v", err)
+	}
+	if !ok {
+		return 0, ErrSyncRunning
+	}
+	defer unlock()
+
+	if err := storage.MarkSyncStarted(ctx, db, memberID); err != nil {
+		syncLog.Error("MarkSyncStarted xatolik", "member_id", memberID, "err", err)
+	}
+	if !opts.SkipDirectory {
+		// Bo'limlar va xodimlar katalogi (har UserSyncInterval da bir marta)
+		if err := SyncDirectoryIfStale(ctx, db, memberID, opts.ClientID, opts.ClientSecret); err != nil {
+			syncLog.Warn("SyncDirectory xatolik", "member_id", memberID, "err", err)
+		}
+	}
+	return SyncRecordings(ctx, db, memberID, folderID, opts)
+}
+
+// NextSyncRun - portalning keyingi rejalashtirilgan sinxronizatsiyasi. Jadval bo'sh bo'lsa oxirgi
+// boshlanishdan fallback keyin; hech qachon sinxronlanmagan bo'lsa - hozir.
+func NextSyncRun(p *models.PortalSchedule, fallback time.Duration, now time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("vaqt mintaqasi noto'g'ri: %v", err)
+	}
+	if p.StartedAt == nil {
+		return now, nil
+	}
+	if len(p.Schedule) == 0 {
+		return p.StartedAt.Add(fallback).In(loc), nil
+	}
+	sched, err := cron.ParseAll(p.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := sched.Next(p.StartedAt.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("jadval bo'yicha keyingi vaqt topilmadi")
+	}
+	return next, nil
+}
+
+// ValidateSyncSettings - jadval ifodalari va vaqt mintaqasini tekshirish (saqlashdan oldin)
+func ValidateSyncSettings(s *models.SyncSettings) error {
+	if s.TimeZone == "" {
+		s.TimeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(s.TimeZone); err != nil {
+		return fmt.Errorf("timezone noto'g'ri: %s", s.TimeZone)
+	}
+	if s.Schedule == nil {
+		s.Schedule = []string{}
+	}
+	_, err := cron.ParseAll(s.Schedule)
+	return err
+}
+
+// Scheduler - portallarni o'z jadvali bo'yicha sinxronlaydi; har portal alohida goroutine da,
+// bir portal uchun bir vaqtda bittadan ortiq emas
+type Scheduler struct {
+	db       *sql.DB
+	opts     SyncOptions
+	fallback time.Duration
+
+	wake    chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// NewScheduler - fallback - jadvali bo'sh portallar uchun oraliq (sozlamalardagi sync.interval)
+func NewScheduler(db *sql.DB, opts SyncOptions, fallback time.Duration) *Scheduler {
+	return &Scheduler{db: db, opts: opts, fallback: fallback, wake: make(chan struct{}, 1), running: make(map[string]bool)}
+}
+
+// Run - to'xtatish boshlanguncha (Stopping) jadvalni kuzatadi, keyin bajarilayotgan sinxronizatsiyalar
+// tugashini (yoki ctx bekor qilinishini) kutadi. Alohida goroutine da ishga tushiriladi.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	for !Stopping(ctx) {
+		s.tick(ctx, time.Now())
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-Stopped(ctx):
+		case <-ctx.Done():
+		}
+	}
+	s.wg.Wait()
+}
+
+// Wake - navbatdagi tekshiruvni kutmasdan so'rovlarni ko'rib chiqish (storage.RequestSync dan keyin)
+func (s *Scheduler) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// NextRun - portalning keyingi rejalashtirilgan sinxronizatsiyasi (shu rejalashtiruvchi fallback i bilan)
+func (s *Scheduler) NextRun(p *models.PortalSchedule, now time.Time) (time.Time, error) {
+	return NextSyncRun(p, s.fallback, now)
+}
+
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	portals, err := storage.ListPortalSchedules(ctx, s.db)
+	if err != nil {
+		schedulerLog.Error("ListPortalSchedules xatolik", "err", err)
+		return
+	}
+	for i := range portals {
+		p := &portals[i]
+		// folder_id siz portal sinxronlanmaydi (install paytida berilmagan, qo'lda belgilanadi)
+		if p.Disabled || p.Running || p.FolderID == "" {
+			continue
+		}
+		due := p.RequestedAt != nil
+		if !due && !p.Paused {
+			next, err := NextSyncRun(p, s.fallback, now)
+			if err != nil {
+				schedulerLog.Warn("portal jadvali noto'g'ri", "member_id", p.MemberID, "err", err)
+				continue
+			}
+			due = !next.After(now)
+		}
+		if due {
+			s.start(ctx, p.MemberID, p.FolderID)
+		}
+	}
+}
+
+// start - portal shu jarayonda sinxronlanmayotgan bo'lsa, fonda ishga tushiradi
+func (s *Scheduler) start(ctx context.Context, memberID, folderID string) {
+	s.mu.Lock()
+	if s.running[memberID] {
+		s.mu.Unlock()
+		return
+	}
+	s.running[memberID] = true
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, memberID)
+			s.mu.Unlock()
+		}()
+		n, err := SyncPortal(ctx, s.db, memberID, folderID, s.opts)
+		switch {
+		case err == ErrSyncRunning:
+		case err != nil:
+			schedulerLog.Error("sinxronizatsiya xatolik", "member_id", memberID, "err", err)
+		default:
+			schedulerLog.Info("sinxronizatsiya tugadi", "member_id", memberID, "downloaded", n)
+		}
+	}()
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"bitrix/storage"
+)
+
+const shareTokenPrefix = "bxs_"
+
+const (
+	// callShareTTL - ulashilgan havolaning amal qilish muddati
+	callShareTTL = 30 * 24 * time.Hour
+	// callShareMinTTL - yangi izoh yoki ogohlantirishga shundan kam muddati qolgan havola qo'yilmaydi (yangisi yaratiladi)
+	callShareMinTTL = 7 * 24 * time.Hour
+)
+
+// CallShareURL - qo'ng'iroq sahifasiga Bitrix24 frame sessiyasisiz ochiladigan havola (publicURL/share/<token>).
+// Havola egasi yozuvni tinglay oladi, shuning uchun faqat CRM va ogohlantirishlarda ishlatiladi. Qo'ng'iroqning
+// amaldagi havolasi qayta ishlatiladi; havola callShareTTL dan keyin eskiradi va RevokeCallShares bilan bekor qilinadi.
+func CallShareURL(ctx context.Context, db *sql.DB, publicURL, memberID, callID string) (string, error) {
+	token, err := storage.GetLiveCallShare(ctx, db, memberID, callID, time.Now().Add(callShareMinTTL))
+	if err == sql.ErrNoRows {
+		var secret string
+		if secret, err = randomSecret(); err != nil {
+			return "", err
+		}
+		token = shareTokenPrefix + secret
+		err = storage.InsertCallShare(ctx, db, memberID, callID, token, HashSecret(token), time.Now().Add(callShareTTL))
+	}
+	if err != nil {
+		return "", fmt.Errorf("havolani saqlashda xatolik: %v", err)
+	}
+	return strings.TrimSuffix(publicURL, "/") + "/share/" + url.PathEscape(token), nil
+}
+
+// AuthenticateCallShare - havola tokeni bo'yicha portal va qo'ng'iroq; topilmasa, eskirgan yoki bekor qilingan
+// bo'lsa sql.ErrNoRows
+func AuthenticateCallShare(ctx context.Context, db *sql.DB, token string) (memberID, callID string, err error) {
+	if !strings.HasPrefix(token, shareTokenPrefix) {
+		return "", "", sql.ErrNoRows
+	}
+	return storage.GetCallShare(ctx, db, HashSecret(token))
+}
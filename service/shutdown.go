@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// stopKey - ctx dagi "to'xtatish boshlandi" kanali kaliti
+type stopKey struct{}
+
+// WithStop - ctx ga yumshoq to'xtatish signalini biriktiradi. stop yopilgach yangi ish (fayl, oyna,
+// vazifa) boshlanmaydi, joriysi tugatiladi; ctx ning o'zi bekor qilinsa (muddat tugadi) joriy ish ham uziladi.
+func WithStop(ctx context.Context, stop <-chan struct{}) context.Context {
+	return context.WithValue(ctx, stopKey{}, stop)
+}
+
+// Stopped - yumshoq to'xtatish kanali (WithStop berilmagan bo'lsa ctx.Done())
+func Stopped(ctx context.Context) <-chan struct{} {
+	if stop, ok := ctx.Value(stopKey{}).(<-chan struct{}); ok {
+		return stop
+	}
+	return ctx.Done()
+}
+
+// Stopping - yangi ish boshlamaslik kerakmi (to'xtatish boshlangan yoki ctx bekor qilingan)
+func Stopping(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	select {
+	case <-Stopped(ctx):
+		return true
+	default:
+		return false
+	}
+}
+
+// errStopped - sahifalash to'xtatish boshlangani uchun oxirigacha yetmadi (natija to'liq emas)
+var errStopped = errors.New("to'xtatish boshlandi")
+
+// pause - sahifalar orasidagi kutish (Bitrix24 so'rovlar limiti); to'xtatish boshlansa darhol false qaytaradi
+func pause(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-Stopped(ctx):
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
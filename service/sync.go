@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+// SyncOptions - yozuvlarni sinxronlash parametrlari
+type SyncOptions struct {
+	ClientID     string
+	ClientSecret string
+	PublicURL    string
+	// Since/Until - faqat shu oraliqda yaratilgan fayllar (nol qiymat - cheklovsiz)
+	Since time.Time
+	Until time.Time
+	// Force - oldin yuklab olingan yozuvlarni ham qayta ishlash
+	Force bool
+	// SkipDirectory - SyncPortal da bo'limlar va xodimlar katalogini yangilamaslik
+	SkipDirectory bool
+}
+
+// SyncRecordings - portal papkasidagi yozuvlarni yuklab oladi: call info, CRM, audio tekshiruvi/tahlili,
+// xodim va transkripsiya navbati. Yuklab olingan fayllar sonini qaytaradi;
+// natija portals.last_sync_* ga ham yoziladi.
+func SyncRecordings(ctx context.Context, db *sql.DB, memberID, folderID string, opts SyncOptions) (int, error) {
+	l := syncLog.With("member_id", memberID)
+	l.Info("yozuvlar papkasi tekshirilmoqda", "folder_id", folderID)
+
+	// 1) Disk papkadan audio fayllar
+	audioFiles, err := GetAllAudioFiles(ctx, db, memberID, folderID, opts.ClientID, opts.ClientSecret)
+	if err == errStopped {
+		l.Info("sinxronizatsiya to'xtatildi, papka keyingi safar")
+		return 0, nil
+	}
+	if err != nil {
+		if serr := storage.UpdatePortalSyncStatus(ctx, db, memberID, 0, err); serr != nil {
+			l.Error("UpdatePortalSyncStatus xatolik", "err", serr)
+		}
+		return 0, fmt.Errorf("GetAllAudioFiles: %v", err)
+	}
+
+	var pending []AudioFile
+	for _, audio := range audioFiles {
+		if !opts.Since.IsZero() && audio.CreateTime.Before(opts.Since) {
+			continue
+		}
+		if !opts.Until.IsZero() && !audio.CreateTime.Before(opts.Until) {
+			continue
+		}
+		if !opts.Force {
+			done, err := storage.IsCallDownloaded(ctx, db, memberID, audio.ID)
+			if err != nil {
+				l.Error("IsCallDownloaded xatolik", "file_id", audio.ID, "err", err)
+			} else if done {
+				continue
+			}
+		}
+		pending = append(pending, audio)
+	}
+	if len(pending) == 0 {
+		l.Info("yangi yozuv yo'q")
+		if err := storage.UpdatePortalSyncStatus(ctx, db, memberID, 0, nil); err != nil {
+			l.Error("UpdatePortalSyncStatus xatolik", "err", err)
+		}
+		return 0, nil
+	}
+	l.Info("yangi yozuvlar topildi", "files", len(pending))
+
+	// 2) Har bir audio fayl uchun call info, user info, yuklab olish
+	downloaded := 0
+	for i, audio := range pending {
+		// To'xtatish boshlangan: joriy fayl tugadi, qolganlari keyingi sinxronizatsiyada
+		if Stopping(ctx) {
+			l.Info("sinxronizatsiya to'xtatildi, qolganlari keyingi safar", "remaining", len(pending)-i)
+			break
+		}
+		if err := syncRecording(ctx, db, memberID, audio, opts); err != nil {
+			l.Error("yozuvni yuklab olib bo'lmadi", "file_id", audio.ID, "err", err)
+			continue
+		}
+		downloaded++
+	}
+
+	// ctx muddati tugagan bo'lsa ham natija yozilsin
+	if err := storage.UpdatePortalSyncStatus(context.WithoutCancel(ctx), db, memberID, downloaded, nil); err != nil {
+		l.Error("UpdatePortalSyncStatus xatolik", "err", err)
+	}
+	return downloaded, nil
+}
+
+// syncRecording - papkadagi bitta fayl; xatolik faqat yozuv umuman saqlanmaganda qaytariladi
+func syncRecording(ctx context.Context, db *sql.DB, memberID string, audio AudioFile, opts SyncOptions) error {
+	callInfo, err := GetCallInfo(ctx, db, memberID, audio.ID, opts.ClientID, opts.ClientSecret)
+	if err != nil {
+		return fmt.Errorf("GetCallInfo xatolik: %v", err)
+	}
+	return ingestCall(ctx, db, memberID, callInfo, &audio, opts)
+}
+
+// ingestCall - qo'ng'iroqni saqlash (call info, CRM), audio berilgan bo'lsa yuklab olish: tekshiruv/tahlil,
+// xodim va transkripsiya navbati
+func ingestCall(ctx context.Context, db *sql.DB, memberID string, callInfo *models.CallInfo, audio *AudioFile, opts SyncOptions) error {
+	l := syncLog.With("member_id", memberID, "call_id", callInfo.ID)
+	// DB ga call_info yozish
+	if err := storage.InsertCallInfo(ctx, memberID, callInfo, db); err != nil {
+		l.Error("InsertCallInfo xatolik", "err", err)
+	}
+	// Bog'langan lid/kontakt/kompaniya/bitim va faoliyat
+	if err := EnrichCallCRM(ctx, db, memberID, callInfo, opts.ClientID, opts.ClientSecret); err != nil {
+		l.Warn("EnrichCallCRM xatolik", "err", err)
+	}
+	if audio == nil {
+		return nil
+	}
+	l = l.With("file_id", audio.ID)
+
+	// Audio faylni yuklab olish
+	audioPath, err := DownloadAudio(ctx, memberID, audio)
+	if err != nil {
+		return fmt.Errorf("DownloadAudio xatolik: %v", err)
+	}
+	// Fayl sarlavhasi: haqiqiy davomiylik, bitrate; bo'sh/buzilgan fayllarni belgilash
+	meta, err := InspectRecording(ctx, db, memberID, callInfo, audioPath)
+	if err != nil {
+		l.Error("InspectRecording xatolik", "err", err)
+	} else if meta.Status != models.RecordingOK {
+		l.Warn("yozuvda muammo", "status", meta.Status, "problem", meta.Problem)
+	}
+	// Jimlik, gapirish ulushi va to'lqin shakli (fayl o'qiladigan bo'lsa)
+	if meta != nil && (meta.Status == models.RecordingOK || meta.Status == models.RecordingMismatch) {
+		if _, err := AnalyzeRecording(ctx, db, meta); err != nil {
+			l.Error("AnalyzeRecording xatolik", "err", err)
+		}
+	}
+
+	// Foydalanuvchini olish (avval DB keshidan)
+	userInfo, err := GetUserCached(ctx, db, memberID, callInfo.PortalUserID, opts.ClientID, opts.ClientSecret)
+	if err != nil {
+		l.Warn("xodim ma'lumotini olib bo'lmadi", "user_id", callInfo.PortalUserID, "err", err)
+		// Vaqtinchalik yozuv: total jadvali users ga bog'langan; keyingi sinxronizatsiyada to'ldiriladi
+		userInfo = &models.User{ID: callInfo.PortalUserID, MemberID: memberID, Name: "Noma'lum"}
+		if err := storage.InsertUser(ctx, memberID, userInfo, db); err != nil {
+			l.Error("InsertUser xatolik", "err", err)
+		}
+	}
+
+	// Total jadvaliga yozish (qayta ishlashda takrorlanmaydi)
+	downloaded, err := storage.IsCallDownloaded(ctx, db, memberID, callInfo.ID)
+	if err != nil {
+		l.Error("IsCallDownloaded xatolik", "err", err)
+	}
+	if !downloaded {
+		total := models.Total{
+			MemberID:  memberID,
+			AudioPath: audioPath,
+			UserID:    userInfo.ID,
+			CallID:    callInfo.ID,
+		}
+		if err := storage.InsertTotal(ctx, memberID, total, db); err != nil {
+			l.Error("InsertTotal xatolik", "err", err)
+		}
+	}
+
+	// Matnga o'girish navbatiga qo'shish; CRM timeline izohi vazifa yakunlangach yuboriladi
+	if err := storage.EnqueueTranscriptionJob(ctx, db, memberID, callInfo.ID, audioPath); err != nil {
+		l.Error("EnqueueTranscriptionJob xatolik", "err", err)
+	}
+
+	l.Info("yozuv yuklab olindi", "path", audioPath, "user_id", userInfo.ID)
+	return nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+const telegramAPI = "https://api.telegram.org/bot"
+
+// TelegramNotifier - Bot API orqali xabar yuborish
+type TelegramNotifier struct {
+	BotToken string
+}
+
+// NewTelegramNotifier - token bo'sh bo'lsa nil (Telegram ogohlantirishlari o'chirilgan)
+func NewTelegramNotifier(botToken string) *TelegramNotifier {
+	if botToken == "" {
+		return nil
+	}
+	return &TelegramNotifier{BotToken: botToken}
+}
+
+// Send - HTML formatidagi xabarni chatga yuborish
+func (t *TelegramNotifier) Send(ctx context.Context, chatID, text string) error {
+	resp, err := postForm(ctx, telegramAPI+t.BotToken+"/sendMessage", url.Values{
+		"chat_id":                  {chatID},
+		"text":                     {text},
+		"parse_mode":               {"HTML"},
+		"disable_web_page_preview": {"true"},
+	})
+	if err != nil {
+		// URL da token bor - xatolik matniga chiqmasligi kerak
+		if uerr, ok := err.(*url.Error); ok {
+			err = uerr.Err
+		}
+		return fmt.Errorf("telegram so'rov xatolik: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("telegram javobini o'qib bo'lmadi (HTTP %d): %v", resp.StatusCode, err)
+	}
+	if !res.OK {
+		return fmt.Errorf("telegram xatolik: %s", res.Description)
+	}
+	return nil
+}
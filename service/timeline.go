@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+// timelineClaimTimeout - yuborilmay qolgan (jarayon uzilgan) izohni qayta yuborishdan oldin kutish
+const timelineClaimTimeout = 15 * time.Minute
+
+// PushCallToTimeline - qo'ng'iroq haqida CRM obyekti timeline iga izoh qo'shadi (crm.timeline.comment.add).
+// Transkripsiya vazifasi yakunlangach chaqiriladi: transkript bo'lsa, izohga uning boshi qo'shiladi.
+// Portal yoqmagan bo'lsa yoki izoh allaqachon yuborilgan bo'lsa, hech narsa qilmaydi.
+func PushCallToTimeline(ctx context.Context, db *sql.DB, memberID, callID, publicURL, clientID, clientSecret string) error {
+	enabled, err := storage.IsTimelineEnabled(ctx, db, memberID)
+	if err != nil || !enabled {
+		return err
+	}
+
+	call, err := storage.GetCall(ctx, db, memberID, callID)
+	if err != nil {
+		return fmt.Errorf("qo'ng'iroq topilmadi: %v", err)
+	}
+	entityType := strings.ToLower(call.CRMEntityType)
+	if entityType == "" || call.CRMEntityID == "" || call.CRMEntityID == "0" {
+		return nil
+	}
+
+	claimed, err := storage.ClaimTimelineComment(ctx, db, memberID, callID, entityType, call.CRMEntityID, timelineClaimTimeout)
+	if err != nil {
+		return fmt.Errorf("timeline izohini band qilishda xatolik: %v", err)
+	}
+	if !claimed {
+		return nil
+	}
+
+	res, err := postTimelineComment(ctx, db, memberID, call, entityType, publicURL, clientID, clientSecret)
+	if err != nil {
+		if rerr := storage.ReleaseTimelineComment(ctx, db, memberID, callID); rerr != nil {
+			bitrixLog.Error("ReleaseTimelineComment xatolik", "member_id", memberID, "call_id", callID, "err", rerr)
+		}
+		return err
+	}
+
+	commentID := fmt.Sprint(res["result"])
+	if err := storage.CompleteTimelineComment(ctx, db, memberID, callID, commentID); err != nil {
+		return fmt.Errorf("timeline izoh ID sini saqlashda xatolik: %v", err)
+	}
+	return nil
+}
+
+func postTimelineComment(ctx context.Context, db *sql.DB, memberID string, call *models.CallListItem, entityType, publicURL, clientID, clientSecret string) (map[string]interface{}, error) {
+	transcript, err := storage.GetTranscript(ctx, db, memberID, call.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("transkriptni olishda xatolik: %v", err)
+	}
+	link := ""
+	if publicURL != "" {
+		if link, err = CallShareURL(ctx, db, publicURL, memberID, call.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	params := url.Values{}
+	params.Set("fields[ENTITY_TYPE]", entityType)
+	params.Set("fields[ENTITY_ID]", call.CRMEntityID)
+	params.Set("fields[COMMENT]", timelineComment(call, transcript, link))
+	return callBitrixMethod(ctx, db, memberID, "crm.timeline.comment.add", params, clientID, clientSecret)
+}
+
+// timelineExcerpt - izohdagi transkript qismi (belgilar soni)
+const timelineExcerpt = 500
+
+// timelineComment - BBCode formatidagi izoh matni; link - ulashiladigan havola (bo'sh bo'lsa qo'shilmaydi)
+func timelineComment(call *models.CallListItem, transcript *models.Transcript, link string) string {
+	var b strings.Builder
+	b.WriteString("[b]Qo'ng'iroq yozuvi[/b]\n")
+	if !call.CallStartDate.IsZero() {
+		fmt.Fprintf(&b, "Sana: %s\n", call.CallStartDate.Format("2006-01-02 15:04"))
+	}
+	fmt.Fprintf(&b, "Turi: %s\n", models.CallTypeName(call.CallType))
+	if call.PhoneNumber != "" {
+		fmt.Fprintf(&b, "Telefon: %s\n", call.PhoneNumber)
+	}
+	if name := strings.TrimSpace(call.UserName + " " + call.UserLastName); name != "" {
+		fmt.Fprintf(&b, "Xodim: %s\n", name)
+	}
+	fmt.Fprintf(&b, "Davomiyligi: %d:%02d\n", call.CallDuration.Int64/60, call.CallDuration.Int64%60)
+	if transcript != nil {
+		if text := []rune(strings.TrimSpace(transcript.Text)); len(text) > 0 {
+			if len(text) > timelineExcerpt {
+				text = append(text[:timelineExcerpt], '…')
+			}
+			fmt.Fprintf(&b, "[b]Matn:[/b] %s\n", string(text))
+		}
+	}
+	if link != "" {
+		fmt.Fprintf(&b, "[url=%s]Yozuvni tinglash[/url]\n", link)
+	}
+	return b.String()
+}
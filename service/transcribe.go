@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+// Transcriber - yozuvni matnga o'giruvchi (hozircha faqat HTTP STT server). Bitrix24 telefoniyasining o'z
+// transkriptini olish uchun hujjatlashtirilgan REST metodi yo'q, shuning uchun bunday import qilinmaydi.
+type Transcriber interface {
+	// Name - provayder nomi (transcripts.provider ga yoziladi)
+	Name() string
+	// Supports - shu qo'ng'iroq uchun ishlatish mumkinmi
+	Supports(call *models.CallInfo) bool
+	Transcribe(ctx context.Context, call *models.CallInfo, audioPath string) (*models.Transcript, error)
+}
+
+const (
+	transcriptionMaxAttempts = 5
+	transcriptionBatch       = 5
+)
+
+// ProcessTranscriptionJobs - navbatdagi vazifalarni bajaradi: birinchi mos kelgan Transcriber ishlatiladi.
+// Hech biri mos kelmasa vazifa "skipped" bo'ladi. Tayyor transkript compliance qoidalari bilan baholanadi
+// (telegram nil bo'lsa faqat bo'lim rahbarlariga ogohlantirish). Vazifa yakunlangach (done, skipped yoki failed)
+// CRM timeline ga izoh yuboriladi. Bajarilgan vazifalar sonini qaytaradi.
+func ProcessTranscriptionJobs(ctx context.Context, db *sql.DB, transcribers []Transcriber, opts SyncOptions, telegram *TelegramNotifier) (int, error) {
+	alerts := &ComplianceAlerts{
+		Telegram:     telegram,
+		ClientID:     opts.ClientID,
+		ClientSecret: opts.ClientSecret,
+		PublicURL:    opts.PublicURL,
+	}
+	jobs, err := storage.ClaimTranscriptionJobs(ctx, db, transcriptionBatch)
+	if err != nil {
+		return 0, fmt.Errorf("vazifalarni olishda xatolik: %v", err)
+	}
+
+	// Holat yozuvlari ctx muddati tugagan bo'lsa ham bajarilsin (vazifa "running" da qolib ketmasin)
+	bg := context.WithoutCancel(ctx)
+	done := 0
+	for _, job := range jobs {
+		l := transcribeLog.With("job_id", job.ID, "member_id", job.MemberID, "call_id", job.CallID)
+		// To'xtatish boshlangan yoki o'girish uzilgan - vazifa urinish hisoblanmay navbatga qaytadi
+		if Stopping(ctx) {
+			if err := storage.ReleaseTranscriptionJob(bg, db, job.ID); err != nil {
+				l.Error("ReleaseTranscriptionJob xatolik", "err", err)
+			}
+			continue
+		}
+		if err := runTranscriptionJob(ctx, db, job, transcribers, opts, alerts); err != nil {
+			if ctx.Err() != nil {
+				if err := storage.ReleaseTranscriptionJob(bg, db, job.ID); err != nil {
+					l.Error("ReleaseTranscriptionJob xatolik", "err", err)
+				}
+				continue
+			}
+			l.Warn("transkripsiya xatolik", "attempt", job.Attempts, "err", err)
+			if job.Attempts >= transcriptionMaxAttempts {
+				if err = storage.FinishTranscriptionJob(bg, db, job.ID, "failed", "", err.Error()); err == nil {
+					pushTimeline(ctx, db, job, opts)
+				}
+			} else {
+				// 1, 4, 9, 16 daqiqa
+				err = storage.RetryTranscriptionJob(bg, db, job.ID, err.Error(), time.Duration(job.Attempts*job.Attempts)*time.Minute)
+			}
+			if err != nil {
+				l.Error("transkripsiya vazifa holatini yozishda xatolik", "err", err)
+			}
+			continue
+		}
+		done++
+	}
+	return done, nil
+}
+
+func runTranscriptionJob(ctx context.Context, db *sql.DB, job models.TranscriptionJob, transcribers []Transcriber, opts SyncOptions, alerts *ComplianceAlerts) error {
+	call, err := storage.GetCall(ctx, db, job.MemberID, job.CallID)
+	if err != nil {
+		return fmt.Errorf("qo'ng'iroq topilmadi: %v", err)
+	}
+
+	for _, t := range transcribers {
+		if !t.Supports(&call.CallInfo) {
+			continue
+		}
+		transcript, err := t.Transcribe(ctx, &call.CallInfo, job.AudioPath)
+		if err != nil {
+			return fmt.Errorf("%s: %v", t.Name(), err)
+		}
+		transcript.MemberID = job.MemberID
+		transcript.CallID = job.CallID
+		transcript.Provider = t.Name()
+		if transcript.CreatedAt.IsZero() {
+			transcript.CreatedAt = time.Now()
+		}
+		if err := storage.SaveTranscript(ctx, db, transcript); err != nil {
+			return fmt.Errorf("transkriptni saqlashda xatolik: %v", err)
+		}
+		transcribeLog.Info("transkript tayyor", "job_id", job.ID, "member_id", job.MemberID, "call_id", job.CallID,
+			"provider", t.Name(), "segments", len(transcript.Segments))
+
+		// Baholashdagi xatolik transkriptni qayta o'girishga sabab bo'lmasligi kerak
+		if _, err := EvaluateCompliance(ctx, db, transcript, alerts); err != nil {
+			transcribeLog.Error("EvaluateCompliance xatolik", "member_id", job.MemberID, "call_id", job.CallID, "err", err)
+		}
+		if err := storage.FinishTranscriptionJob(ctx, db, job.ID, "done", t.Name(), ""); err != nil {
+			return err
+		}
+		pushTimeline(ctx, db, job, opts)
+		return nil
+	}
+
+	if err := storage.FinishTranscriptionJob(ctx, db, job.ID, "skipped", "", "mos transcriber yo'q"); err != nil {
+		return err
+	}
+	pushTimeline(ctx, db, job, opts)
+	return nil
+}
+
+// pushTimeline - yakunlangan vazifa qo'ng'irog'i haqida CRM timeline izohi (xatolik faqat logga yoziladi)
+func pushTimeline(ctx context.Context, db *sql.DB, job models.TranscriptionJob, opts SyncOptions) {
+	if err := PushCallToTimeline(ctx, db, job.MemberID, job.CallID, opts.PublicURL, opts.ClientID, opts.ClientSecret); err != nil {
+		transcribeLog.Warn("PushCallToTimeline xatolik", "job_id", job.ID, "member_id", job.MemberID, "call_id", job.CallID, "err", err)
+	}
+}
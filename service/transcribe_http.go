@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bitrix/models"
+)
+
+// transcribeResponseTimeout - server yozuvni qabul qilgach javobni (matnni) tayyorlash muddati
+const transcribeResponseTimeout = 10 * time.Minute
+
+// HTTPTranscriber - Whisper/OpenAI-mos HTTP endpoint (masalan o'zimizdagi whisper server):
+// multipart "file" yuboriladi, response_format=verbose_json javobi kutiladi. So'rovlar umumiy klient
+// (proxy, CA fayli) orqali yuboriladi.
+type HTTPTranscriber struct {
+	URL      string
+	APIKey   string
+	Model    string
+	Language string
+}
+
+// NewHTTPTranscriber - url bo'sh bo'lsa nil (HTTP transkripsiya o'chirilgan)
+func NewHTTPTranscriber(url, apiKey, model, language string) *HTTPTranscriber {
+	if url == "" {
+		return nil
+	}
+	return &HTTPTranscriber{
+		URL:      url,
+		APIKey:   apiKey,
+		Model:    model,
+		Language: language,
+	}
+}
+
+func (h *HTTPTranscriber) Name() string { return "http" }
+
+func (h *HTTPTranscriber) Supports(call *models.CallInfo) bool { return true }
+
+// whisperResponse - verbose_json; channel/speaker ixtiyoriy (stereo ajratadigan serverlar uchun)
+type whisperResponse struct {
+	Text     string `json:"text"`
+	Language string `json:"language"`
+	Segments []struct {
+		Start      float64  `json:"start"`
+		End        float64  `json:"end"`
+		Text       string   `json:"text"`
+		AvgLogprob *float64 `json:"avg_logprob"`
+		Confidence *float64 `json:"confidence"`
+		Channel    *int     `json:"channel"`
+		Speaker    string   `json:"speaker"`
+	} `json:"segments"`
+}
+
+func (h *HTTPTranscriber) Transcribe(ctx context.Context, call *models.CallInfo, audioPath string) (*models.Transcript, error) {
+	f, err := os.Open(audioPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Yozuv xotiraga yig'ilmaydi: multipart tana pipe orqali so'rov yuborilayotganda yoziladi
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		defer f.Close()
+		pw.CloseWithError(h.writeForm(mw, f, filepath.Base(audioPath)))
+	}()
+	defer pr.Close()
+
+	// Yuklash takrorlanmaydi (tanani qayta o'qib bo'lmaydi, server uni qayta ishlagan bo'lishi mumkin):
+	// vazifa navbati o'zi keyinroq qayta urinadi
+	ctx = WithResponseTimeout(WithoutRetry(ctx), transcribeResponseTimeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, pr)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if h.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.APIKey)
+	}
+
+	resp, err := sharedHTTPClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("transkripsiya so'rovda xatolik: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("transkripsiya javobini o'qishda xatolik: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("transkripsiya status: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var wr whisperResponse
+	if err := json.Unmarshal(respBody, &wr); err != nil {
+		return nil, fmt.Errorf("transkripsiya JSON parse xatolik: %v", err)
+	}
+
+	t := &models.Transcript{Language: wr.Language, Text: strings.TrimSpace(wr.Text)}
+	for _, s := range wr.Segments {
+		seg := models.TranscriptSegment{
+			StartMs: int64(s.Start * 1000),
+			EndMs:   int64(s.End * 1000),
+			Channel: s.Channel,
+			Speaker: s.Speaker,
+			Text:    strings.TrimSpace(s.Text),
+		}
+		switch {
+		case s.Confidence != nil:
+			seg.Confidence = *s.Confidence
+		case s.AvgLogprob != nil:
+			seg.Confidence = math.Exp(*s.AvgLogprob)
+		}
+		t.Segments = append(t.Segments, seg)
+	}
+	return t, nil
+}
+
+// writeForm - multipart tanani yozadi: fayl va verbose_json parametrlari
+func (h *HTTPTranscriber) writeForm(mw *multipart.Writer, audio io.Reader, fileName string) error {
+	part, err := mw.CreateFormFile("file", fileName)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, audio); err != nil {
+		return err
+	}
+	fields := [][2]string{{"response_format", "verbose_json"}, {"model", h.Model}, {"language", h.Language}}
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		if err := mw.WriteField(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
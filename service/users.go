@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"bitrix/models"
+	"bitrix/storage"
+)
+
+const (
+	// userCacheTTL - bu muddatdan yangi sinxronlangan xodim uchun user.get chaqirilmaydi
+	userCacheTTL = 24 * time.Hour
+	// UserSyncInterval - xodimlar katalogini to'liq sinxronlash oralig'i
+	UserSyncInterval = 6 * time.Hour
+)
+
+// SyncUsers - user.get ni sahifalab (start=0, 50, ...) barcha xodimlarni upsert qiladi,
+// kelmagan xodimlarni nofaol deb belgilaydi
+func SyncUsers(ctx context.Context, db *sql.DB, memberID, clientID, clientSecret string) (int, error) {
+	var seen []string
+	start := 0
+
+	for {
+		params := url.Values{}
+		params.Set("start", strconv.Itoa(start))
+
+		res, err := callBitrixMethod(WithRetry(ctx), db, memberID, "user.get", params, clientID, clientSecret)
+		if err != nil {
+			return len(seen), err
+		}
+
+		var response struct {
+			Result []models.User `json:"result"`
+			Next   int           `json:"next"`
+			Total  int           `json:"total"`
+		}
+		bytesRes, _ := json.Marshal(res)
+		if err := json.Unmarshal(bytesRes, &response); err != nil {
+			return len(seen), fmt.Errorf("user.get JSON parse xatolik: %v", err)
+		}
+
+		for i := range response.Result {
+			u := &response.Result[i]
+			if err := storage.UpsertUser(ctx, db, memberID, u); err != nil {
+				return len(seen), err
+			}
+			seen = append(seen, u.ID)
+		}
+
+		if response.Next == 0 || len(response.Result) == 0 {
+			break
+		}
+		start = response.Next
+		// To'xtatilsa ro'yxat to'liq emas: nofaol xodimlar belgilanmaydi
+		if !pause(ctx, 500*time.Millisecond) {
+			return len(seen), errStopped
+		}
+	}
+
+	// Bo'sh javob xatolik bo'lishi mumkin - hammani nofaol qilib yubormaslik uchun
+	if len(seen) > 0 {
+		n, err := storage.DeactivateMissingUsers(ctx, db, memberID, seen)
+		if err != nil {
+			return len(seen), fmt.Errorf("nofaol xodimlarni belgilashda xatolik: %v", err)
+		}
+		if n > 0 {
+			syncLog.Info("xodimlar nofaol deb belgilandi", "member_id", memberID, "users", n)
+		}
+	}
+
+	if err := storage.SetUsersSyncedAt(ctx, db, memberID, time.Now()); err != nil {
+		return len(seen), err
+	}
+	return len(seen), nil
+}
+
+// SyncDirectoryIfStale - oxirgi to'liq sinxronizatsiyadan UserSyncInterval o'tgan bo'lsa,
+// bo'limlar va xodimlarni qayta sinxronlaydi
+func SyncDirectoryIfStale(ctx context.Context, db *sql.DB, memberID, clientID, clientSecret string) error {
+	last, err := storage.GetUsersSyncedAt(ctx, db, memberID)
+	if err != nil {
+		return err
+	}
+	if time.Since(last) < UserSyncInterval {
+		return nil
+	}
+
+	// Bo'limlar xatoligi xodimlar sinxronizatsiyasini to'xtatmaydi
+	if n, err := SyncDepartments(ctx, db, memberID, clientID, clientSecret); err == errStopped {
+		return nil
+	} else if err != nil {
+		syncLog.Warn("SyncDepartments xatolik", "member_id", memberID, "err", err)
+	} else {
+		syncLog.Info("bo'limlar sinxronlandi", "member_id", memberID, "departments", n)
+	}
+
+	n, err := SyncUsers(ctx, db, memberID, clientID, clientSecret)
+	if err == errStopped {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	syncLog.Info("xodimlar sinxronlandi", "member_id", memberID, "users", n)
+	return nil
+}
+
+// GetUserCached - avval DB dagi (yaqinda sinxronlangan) yozuv, bo'lmasa user.get
+func GetUserCached(ctx context.Context, db *sql.DB, memberID, userID, clientID, clientSecret string) (*models.User, error) {
+	cached, err := storage.GetUserByID(ctx, db, memberID, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if cached != nil && time.Since(cached.SyncedAt) < userCacheTTL {
+		return cached, nil
+	}
+
+	user, err := GetUserInfo(ctx, db, memberID, userID, clientID, clientSecret)
+	if err != nil {
+		if cached != nil {
+			// Bitrix javob bermasa ham eski ma'lumot yetarli
+			return cached, nil
+		}
+		return nil, err
+	}
+	if err := storage.UpsertUser(ctx, db, memberID, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"bitrix/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrAnalyticsQuery - so'rov parametrlari (bucket, group) noto'g'ri; matni foydalanuvchiga ko'rsatilishi mumkin
+var ErrAnalyticsQuery = errors.New("noto'g'ri so'rov")
+
+// call_type: 1 - chiquvchi, 2 - kiruvchi, 3 - kiruvchi (yo'naltirilgan), 4 - callback.
+// call_failed_code = '200' - muvaffaqiyatli; kiruvchi qo'ng'iroq boshqa kod bilan bo'lsa - o'tkazib yuborilgan.
+const analyticsQuery = `
+WITH RECURSIVE calls AS (
+	SELECT c.id, c.portal_user_id, c.phone_number, c.call_start_date,
+		%[1]s AS bucket,
+		c.call_type IN (2, 3) AS inbound,
+		c.call_type IN (1, 4) AS outbound,
+		COALESCE(c.call_failed_code, '') = '200' AS ok,
+		COALESCE(c.call_duration, 0) AS duration,
+		COALESCE(c.cost, 0) AS cost,
+		ts.operator_talk_ms::FLOAT / NULLIF(ts.operator_talk_ms + ts.customer_talk_ms, 0) AS talk_ratio,
+		ts.longest_monologue_ms / 1000.0 AS longest_monologue,
+		COALESCE(ts.overlap_ms, 0) AS overlap_ms,
+		cc.score AS compliance_score,
+		COALESCE(cc.violations, 0) > 0 AS compliance_violated
+	FROM CallInfo c
+	LEFT JOIN call_compliance cc ON cc.member_id = c.member_id AND cc.call_id = c.id
+	LEFT JOIN call_talk_stats ts ON ts.member_id = c.member_id AND ts.call_id = c.id
+	WHERE c.member_id = $1 AND c.call_start_date >= $2 AND c.call_start_date < $3
+),
+missed AS (
+	-- O'tkazib yuborilgan qo'ng'iroqqa birinchi qayta qo'ng'iroq (istalgan xodimdan)
+	SELECT m.id, (
+		SELECT MIN(o.call_start_date) FROM CallInfo o
+		WHERE o.member_id = $1 AND o.phone_number = m.phone_number
+			AND o.call_type IN (1, 4) AND o.call_start_date > m.call_start_date
+	) AS responded_at
+	FROM calls m
+	WHERE m.inbound AND NOT m.ok
+),
+tree AS (
+	-- Har bir bo'lim va uning barcha yuqori bo'limlari (rollup uchun)
+	SELECT id AS dept_id, id AS ancestor_id, 0 AS depth FROM departments WHERE member_id = $1
+	UNION ALL
+	SELECT tree.dept_id, d.parent_id, tree.depth + 1
+	FROM tree JOIN departments d ON d.member_id = $1 AND d.id = tree.ancestor_id
+	WHERE d.parent_id IS NOT NULL AND tree.depth < 50
+)
+SELECT calls.bucket, %[2]s AS key, %[3]s AS name,
+	COUNT(*),
+	COUNT(*) FILTER (WHERE inbound),
+	COUNT(*) FILTER (WHERE outbound),
+	COUNT(*) FILTER (WHERE inbound AND NOT ok),
+	COUNT(*) FILTER (WHERE inbound AND ok),
+	COALESCE(SUM(duration) FILTER (WHERE ok), 0),
+	COALESCE(AVG(duration) FILTER (WHERE ok), 0),
+	AVG(EXTRACT(EPOCH FROM (ms.responded_at - calls.call_start_date))) FILTER (WHERE ms.responded_at IS NOT NULL),
+	COUNT(*) FILTER (WHERE ms.id IS NOT NULL AND ms.responded_at IS NULL),
+	COALESCE(SUM(cost), 0),
+	AVG(talk_ratio),
+	AVG(longest_monologue),
+	COALESCE(SUM(overlap_ms), 0) / 1000,
+	AVG(compliance_score),
+	COUNT(*) FILTER (WHERE compliance_violated)
+FROM calls
+LEFT JOIN missed ms ON ms.id = calls.id
+%[4]s
+GROUP BY 1, 2, 3
+ORDER BY 1 NULLS FIRST, 2`
+
+var analyticsBuckets = map[string]string{
+	"":      "NULL::TIMESTAMP",
+	"day":   "date_trunc('day', c.call_start_date)",
+	"week":  "date_trunc('week', c.call_start_date)",
+	"month": "date_trunc('month', c.call_start_date)",
+}
+
+// CallStats - xodim yoki bo'lim kesimida qo'ng'iroqlar statistikasi
+func CallStats(ctx context.Context, db *sql.DB, memberID string, q models.AnalyticsQuery) ([]models.CallStats, error) {
+	bucket, ok := analyticsBuckets[q.Bucket]
+	if !ok {
+		return nil, fmt.Errorf("%w: noma'lum bucket: %s", ErrAnalyticsQuery, q.Bucket)
+	}
+
+	var key, name, join string
+	switch q.GroupBy {
+	case "", "user":
+		key = "COALESCE(calls.portal_user_id, '')"
+		name = "COALESCE(TRIM(u.name || ' ' || u.last_name), '')"
+		join = "LEFT JOIN users u ON u.member_id = $1 AND u.id = calls.portal_user_id"
+	case "department":
+		key = "d.dept"
+		name = "COALESCE(dep.name, d.dept)"
+		deptExpr := "ud.dept"
+		rollupJoin := ""
+		if q.Rollup {
+			// Xodim bir nechta bo'limda bo'lsa, umumiy yuqori bo'limda qo'ng'iroq bir necha marta hisoblanishi mumkin
+			deptExpr = "COALESCE(tree.ancestor_id, ud.dept)"
+			rollupJoin = "LEFT JOIN tree ON tree.dept_id = ud.dept"
+		}
+		join = fmt.Sprintf(`JOIN users u ON u.member_id = $1 AND u.id = calls.portal_user_id
+			CROSS JOIN LATERAL jsonb_array_elements_text(COALESCE(u.department_ids, '[]'::JSONB)) AS ud(dept)
+			%s
+			CROSS JOIN LATERAL (SELECT %s AS dept) AS d
+			LEFT JOIN departments dep ON dep.member_id = $1 AND dep.id = d.dept`, rollupJoin, deptExpr)
+	default:
+		return nil, fmt.Errorf("%w: noma'lum guruhlash: %s", ErrAnalyticsQuery, q.GroupBy)
+	}
+
+	query := fmt.Sprintf(analyticsQuery, bucket, key, name, join)
+	rows, err := db.QueryContext(ctx, query, memberID, q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.CallStats
+	for rows.Next() {
+		var s models.CallStats
+		var b sql.NullTime
+		var firstResponse, talkRatio, monologue, complianceScore sql.NullFloat64
+		if err := rows.Scan(&b, &s.Key, &s.Name,
+			&s.Total, &s.Inbound, &s.Outbound, &s.Missed, &s.Answered,
+			&s.TalkSeconds, &s.AvgTalkSeconds, &firstResponse, &s.MissedNotReturned, &s.Cost,
+			&talkRatio, &monologue, &s.OverlapSeconds,
+			&complianceScore, &s.ComplianceViolations,
+		); err != nil {
+			return nil, err
+		}
+		if b.Valid {
+			s.Bucket = &b.Time
+		}
+		if firstResponse.Valid {
+			s.AvgFirstResponseSec = &firstResponse.Float64
+		}
+		if talkRatio.Valid {
+			s.AvgTalkRatio = &talkRatio.Float64
+		}
+		if monologue.Valid {
+			s.AvgLongestMonologue = &monologue.Float64
+		}
+		if complianceScore.Valid {
+			s.AvgComplianceScore = &complianceScore.Float64
+		}
+		if s.Inbound > 0 {
+			s.AnswerRate = float64(s.Answered) / float64(s.Inbound)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
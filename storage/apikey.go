@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"bitrix/models"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// InsertAPIKey - yangi API kalitini (hash ko'rinishida) saqlash
+func InsertAPIKey(ctx context.Context, db *sql.DB, k *models.APIKey, keyHash string) error {
+	query := `
+		INSERT INTO api_keys (member_id, name, key_hash, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+	k.CreatedAt = time.Now()
+	return db.QueryRowContext(ctx, query, k.MemberID, k.Name, keyHash, pq.Array(k.Scopes), k.CreatedAt).Scan(&k.ID)
+}
+
+// GetAPIKeyByHash - hash orqali faol (bekor qilinmagan) kalitni olish
+func GetAPIKeyByHash(ctx context.Context, db *sql.DB, keyHash string) (*models.APIKey, error) {
+	query := `SELECT id, member_id, name, scopes, created_at, last_used_at, revoked_at
+			  FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL`
+
+	var k models.APIKey
+	var name sql.NullString
+	var lastUsed, revoked sql.NullTime
+	err := db.QueryRowContext(ctx, query, keyHash).Scan(&k.ID, &k.MemberID, &name, pq.Array(&k.Scopes), &k.CreatedAt, &lastUsed, &revoked)
+	if err != nil {
+		return nil, err
+	}
+	k.Name = name.String
+	if lastUsed.Valid {
+		k.LastUsedAt = &lastUsed.Time
+	}
+	if revoked.Valid {
+		k.RevokedAt = &revoked.Time
+	}
+	return &k, nil
+}
+
+// ListAPIKeys - portalning barcha kalitlari
+func ListAPIKeys(ctx context.Context, db *sql.DB, memberID string) ([]models.APIKey, error) {
+	query := `SELECT id, member_id, name, scopes, created_at, last_used_at, revoked_at
+			  FROM api_keys WHERE member_id = $1 ORDER BY id`
+	rows, err := db.QueryContext(ctx, query, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		var k models.APIKey
+		var name sql.NullString
+		var lastUsed, revoked sql.NullTime
+		if err := rows.Scan(&k.ID, &k.MemberID, &name, pq.Array(&k.Scopes), &k.CreatedAt, &lastUsed, &revoked); err != nil {
+			return nil, err
+		}
+		k.Name = name.String
+		if lastUsed.Valid {
+			k.LastUsedAt = &lastUsed.Time
+		}
+		if revoked.Valid {
+			k.RevokedAt = &revoked.Time
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// TouchAPIKey - kalit oxirgi marta qachon ishlatilganini yangilash
+func TouchAPIKey(ctx context.Context, db *sql.DB, id int) error {
+	_, err := db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, time.Now(), id)
+	return err
+}
+
+// RevokeAPIKey - kalitni bekor qilish (faqat o'z portali doirasida)
+func RevokeAPIKey(ctx context.Context, db *sql.DB, memberID string, id int) (bool, error) {
+	result, err := db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND member_id = $3 AND revoked_at IS NULL`,
+		time.Now(), id, memberID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// InsertFrameSession - Bitrix24 placement sessiyasini saqlash
+func InsertFrameSession(ctx context.Context, db *sql.DB, tokenHash string, s *models.FrameSession) error {
+	query := `
+		INSERT INTO frame_sessions (token_hash, member_id, user_id, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)`
+	_, err := db.ExecContext(ctx, query, tokenHash, s.MemberID, s.UserID, pq.Array(s.Scopes), s.ExpiresAt)
+	return err
+}
+
+// GetFrameSession - muddati o'tmagan sessiyani olish
+func GetFrameSession(ctx context.Context, db *sql.DB, tokenHash string) (*models.FrameSession, error) {
+	query := `SELECT member_id, user_id, scopes, expires_at
+			  FROM frame_sessions WHERE token_hash = $1 AND expires_at > $2`
+
+	var s models.FrameSession
+	var userID sql.NullString
+	err := db.QueryRowContext(ctx, query, tokenHash, time.Now()).Scan(&s.MemberID, &userID, pq.Array(&s.Scopes), &s.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	s.UserID = userID.String
+	return &s, nil
+}
+
+// DeleteExpiredFrameSessions - eskirgan sessiyalarni tozalash
+func DeleteExpiredFrameSessions(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM frame_sessions WHERE expires_at <= $1`, time.Now())
+	return err
+}
@@ -2,25 +2,30 @@ package storage
 
 import (
 	"bitrix/models"
+	"context"
 	"database/sql"
 	"time"
 )
 
-func InsertOrUpdateToken(db *sql.DB, t *models.TokenInfo) error {
-	// Misol uchun: unique bo'ladigan maydon: MemberID yoki PortalDomain
+// InsertOrUpdateToken - portals jadvali yagona portal reyestri, tokenlar ham shu yerda
+func InsertOrUpdateToken(ctx context.Context, db *sql.DB, t *models.TokenInfo) error {
 	query := `
-		INSERT INTO tokens (portal_domain, member_id, access_token, refresh_token, expires_in, scope, last_update, client_endpoint)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO portals (domain, member_id, access_token, refresh_token, expires_in, scope, last_update, client_endpoint,
+			server_endpoint, oauth_server)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''))
 		ON CONFLICT (member_id) 
 		DO UPDATE SET
+			domain = EXCLUDED.domain,
 			access_token = EXCLUDED.access_token,
 			refresh_token = EXCLUDED.refresh_token,
 			expires_in = EXCLUDED.expires_in,
 			scope = EXCLUDED.scope,
 			last_update = EXCLUDED.last_update,
-			client_endpoint = EXCLUDED.client_endpoint
+			client_endpoint = EXCLUDED.client_endpoint,
+			server_endpoint = COALESCE(EXCLUDED.server_endpoint, portals.server_endpoint),
+			oauth_server = COALESCE(EXCLUDED.oauth_server, portals.oauth_server)
 	`
-	_, err := db.Exec(query,
+	_, err := db.ExecContext(ctx, query,
 		t.PortalDomain,
 		t.MemberID,
 		t.AccessToken,
@@ -29,23 +34,37 @@ func InsertOrUpdateToken(db *sql.DB, t *models.TokenInfo) error {
 		t.Scope,
 		time.Now(), // last_update
 		t.ClientEndpoint,
+		t.ServerEndpoint,
+		t.OAuthServer,
 	)
 	return err
 }
 
 // GetTokenByMemberID - member_id orqali tokenni olish
-func GetTokenByMemberID(db *sql.DB, memberID string) (*models.TokenInfo, error) {
-	query := `SELECT portal_domain, member_id, access_token, refresh_token, expires_in, scope, last_update, client_endpoint 
-			  FROM tokens WHERE member_id = $1`
-	row := db.QueryRow(query, memberID)
+func GetTokenByMemberID(ctx context.Context, db *sql.DB, memberID string) (*models.TokenInfo, error) {
+	query := `SELECT domain, member_id, access_token, refresh_token, expires_in, scope, last_update, client_endpoint,
+				COALESCE(server_endpoint, ''), COALESCE(oauth_server, '')
+			  FROM portals WHERE member_id = $1`
+	row := db.QueryRowContext(ctx, query, memberID)
 
 	var t models.TokenInfo
 	var lastUpdate time.Time
 
-	err := row.Scan(&t.PortalDomain, &t.MemberID, &t.AccessToken, &t.RefreshToken, &t.ExpiresIn, &t.Scope, &lastUpdate, &t.ClientEndpoint)
+	err := row.Scan(&t.PortalDomain, &t.MemberID, &t.AccessToken, &t.RefreshToken, &t.ExpiresIn, &t.Scope, &lastUpdate, &t.ClientEndpoint,
+		&t.ServerEndpoint, &t.OAuthServer)
 	if err != nil {
 		return nil, err
 	}
 	t.LastUpdate = lastUpdate
 	return &t, nil
 }
+
+// SetPortalOAuthServer - portal OAuth serverini qo'lda belgilash (bo'sh - server_endpoint dan aniqlash); portal topilmasa false
+func SetPortalOAuthServer(ctx context.Context, db *sql.DB, memberID, server string) (bool, error) {
+	result, err := db.ExecContext(ctx, `UPDATE portals SET oauth_server = NULLIF($2, '') WHERE member_id = $1`, memberID, server)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
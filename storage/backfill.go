@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"bitrix/logging"
+	"bitrix/models"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const backfillColumns = `id, member_id, since, until, cursor, status, windows_total, windows_done, calls_ingested,
+	recordings, elapsed_ms, COALESCE(last_error, ''), created_at, updated_at, finished_at`
+
+func scanBackfill(row interface{ Scan(...interface{}) error }) (*models.BackfillJob, error) {
+	var j models.BackfillJob
+	var finished sql.NullTime
+	err := row.Scan(&j.ID, &j.MemberID, &j.Since, &j.Until, &j.Cursor, &j.Status, &j.WindowsTotal, &j.WindowsDone,
+		&j.CallsIngested, &j.Recordings, &j.ElapsedMs, &j.LastError, &j.CreatedAt, &j.UpdatedAt, &finished)
+	if err != nil {
+		return nil, err
+	}
+	if finished.Valid {
+		j.FinishedAt = &finished.Time
+	}
+	if eta, ok := j.ETA(); ok {
+		s := int64(eta.Seconds())
+		j.ETASeconds = &s
+	}
+	return &j, nil
+}
+
+// CreateBackfillJob - yangi vazifa (navbatda); portalda tugallanmagan vazifa bo'lsa unique xatolik
+func CreateBackfillJob(ctx context.Context, db *sql.DB, j *models.BackfillJob) error {
+	created, err := scanBackfill(db.QueryRowContext(ctx, `
+		INSERT INTO backfill_jobs (member_id, since, until, cursor, windows_total)
+		VALUES ($1, $2, $3, $2, $4)
+		RETURNING `+backfillColumns,
+		j.MemberID, j.Since, j.Until, j.WindowsTotal))
+	if err != nil {
+		return err
+	}
+	*j = *created
+	return nil
+}
+
+// GetBackfillJob - bitta vazifa (faqat shu portal doirasida)
+func GetBackfillJob(ctx context.Context, db *sql.DB, memberID string, id int) (*models.BackfillJob, error) {
+	return scanBackfill(db.QueryRowContext(ctx, `SELECT `+backfillColumns+` FROM backfill_jobs
+			WHERE member_id = $1 AND id = $2`, memberID, id))
+}
+
+// GetActiveBackfillJob - portalning tugallanmagan (queued/running/paused) vazifasi; yo'q bo'lsa sql.ErrNoRows
+func GetActiveBackfillJob(ctx context.Context, db *sql.DB, memberID string) (*models.BackfillJob, error) {
+	return scanBackfill(db.QueryRowContext(ctx, `SELECT `+backfillColumns+` FROM backfill_jobs
+			WHERE member_id = $1 AND status IN ('queued', 'running', 'paused')`, memberID))
+}
+
+// GetResumableBackfillJob - davom ettirish mumkin bo'lgan vazifa: tugallanmagan yoki portalning eng oxirgi
+// vazifasi xato bilan tugagan bo'lsa o'sha; bo'lmasa sql.ErrNoRows
+func GetResumableBackfillJob(ctx context.Context, db *sql.DB, memberID string) (*models.BackfillJob, error) {
+	return scanBackfill(db.QueryRowContext(ctx, `SELECT `+backfillColumns+` FROM backfill_jobs
+			WHERE member_id = $1 AND (status IN ('queued', 'running', 'paused')
+				OR (status = 'failed' AND id = (SELECT MAX(id) FROM backfill_jobs WHERE member_id = $1)))
+			ORDER BY id DESC LIMIT 1`, memberID))
+}
+
+// ListBackfillJobs - portal vazifalari, yangilari birinchi
+func ListBackfillJobs(ctx context.Context, db *sql.DB, memberID string, limit int) ([]models.BackfillJob, error) {
+	rows, err := db.QueryContext(ctx, `SELECT `+backfillColumns+` FROM backfill_jobs
+			WHERE member_id = $1 ORDER BY id DESC LIMIT $2`, memberID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []models.BackfillJob
+	for rows.Next() {
+		j, err := scanBackfill(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *j)
+	}
+	return jobs, rows.Err()
+}
+
+// ClaimBackfillJob - vazifani bajarish uchun band qilish (navbatdagi, to'xtatilgan, xato bilan tugagan yoki
+// staleAfter dan beri yangilanmagan "running"); boshqa jarayon bajarayotgan bo'lsa false
+func ClaimBackfillJob(ctx context.Context, db *sql.DB, id int, staleAfter time.Duration) (bool, error) {
+	result, err := db.ExecContext(ctx, `UPDATE backfill_jobs SET status = 'running', last_error = NULL, updated_at = NOW()
+			WHERE id = $1 AND (status IN ('queued', 'paused', 'failed') OR (status = 'running' AND updated_at < $2))`,
+		id, time.Now().Add(-staleAfter))
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// ClaimNextBackfillJob - server worker uchun: navbatdagi yoki uzilib qolgan vazifa; yo'q bo'lsa nil
+func ClaimNextBackfillJob(ctx context.Context, db *sql.DB, staleAfter time.Duration) (*models.BackfillJob, error) {
+	j, err := scanBackfill(db.QueryRowContext(ctx, `
+		UPDATE backfill_jobs SET status = 'running', last_error = NULL, updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM backfill_jobs
+			WHERE status = 'queued' OR (status = 'running' AND updated_at < $1)
+			ORDER BY id LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+backfillColumns, time.Now().Add(-staleAfter)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return j, err
+}
+
+// SaveBackfillCheckpoint - oyna tugagach cursor va hisoblagichlarni saqlash.
+// Joriy holatni qaytaradi: "running" bo'lmasa (pauza/bekor qilingan) bajarish to'xtatilishi kerak.
+func SaveBackfillCheckpoint(ctx context.Context, db *sql.DB, j *models.BackfillJob) (string, error) {
+	var status string
+	err := db.QueryRowContext(ctx, `UPDATE backfill_jobs SET cursor = $2, windows_done = $3, calls_ingested = $4,
+			recordings = $5, elapsed_ms = $6, updated_at = NOW()
+			WHERE id = $1 RETURNING status`,
+		j.ID, j.Cursor, j.WindowsDone, j.CallsIngested, j.Recordings, j.ElapsedMs).Scan(&status)
+	return status, err
+}
+
+// TouchBackfillJob - uzun oyna davomida "tirik" ekanini bildirish; joriy holatni qaytaradi
+func TouchBackfillJob(ctx context.Context, db *sql.DB, id int) (string, error) {
+	var status string
+	err := db.QueryRowContext(ctx, `UPDATE backfill_jobs SET updated_at = NOW() WHERE id = $1 RETURNING status`, id).Scan(&status)
+	return status, err
+}
+
+// FinishBackfillJob - yakuniy holat (done yoki failed); bekor qilingan vazifa o'zgartirilmaydi,
+// pauza qilingan vazifa faqat oxirgi oyna tugagan bo'lsa (done) yopiladi
+func FinishBackfillJob(ctx context.Context, db *sql.DB, id int, status, lastError string) error {
+	_, err := db.ExecContext(ctx, `UPDATE backfill_jobs SET status = $2, last_error = NULLIF($3, ''), updated_at = NOW(),
+			finished_at = CASE WHEN $2 = 'done' THEN NOW() END
+			WHERE id = $1 AND (status = 'running' OR ($2 = 'done' AND status = 'paused'))`, id, status, logging.Redact(lastError))
+	return err
+}
+
+// SetBackfillStatus - pauza, davom ettirish (queued) yoki bekor qilish; faqat from holatlaridan.
+// Vazifa topilmasa yoki holati mos kelmasa false.
+func SetBackfillStatus(ctx context.Context, db *sql.DB, memberID string, id int, status string, from ...string) (bool, error) {
+	result, err := db.ExecContext(ctx, `UPDATE backfill_jobs SET status = $3, updated_at = NOW()
+			WHERE member_id = $1 AND id = $2 AND status = ANY($4)`, memberID, id, status, pq.Array(from))
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
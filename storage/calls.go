@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"bitrix/logging"
+	"bitrix/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// callSelect - CallInfo + users + total; matnli NULL qiymatlar bo'sh satrga aylantiriladi,
+// sonli ustunlar esa models.Flex* turlari orqali o'qiladi
+const callSelect = `
+	SELECT c.id, COALESCE(c.member_id, ''), COALESCE(c.portal_user_id, ''), COALESCE(c.portal_number, ''),
+		COALESCE(c.phone_number, ''), COALESCE(c.call_id, ''), COALESCE(c.call_category, ''),
+		c.call_duration, c.call_start_date, c.call_vote,
+		c.cost, COALESCE(c.cost_currency, ''), COALESCE(c.call_failed_code, ''),
+		COALESCE(c.call_failed_reason, ''), COALESCE(c.crm_entity_type, ''), COALESCE(c.crm_entity_id, ''),
+		COALESCE(c.crm_activity_id, ''), COALESCE(c.comment, ''), c.record_duration,
+		COALESCE(c.record_file_id, ''), c.call_type,
+		COALESCE(c.transcript_id, ''), c.transcript_pending,
+		COALESCE(u.name, ''), COALESCE(u.last_name, ''), COALESCE(u.department_ids, '[]'::JSONB),
+		ARRAY(
+			SELECT COALESCE(dep.name, dep.id) FROM departments dep
+			WHERE dep.member_id = c.member_id AND dep.id IN (SELECT jsonb_array_elements_text(u.department_ids))
+			ORDER BY 1
+		),
+		COALESCE(t.audio_path, ''),
+		COALESCE(e.title, ''), COALESCE(e.customer_name, ''), COALESCE(e.responsible_id, ''),
+		COALESCE(TRIM(r.name || ' ' || r.last_name), ''), COALESCE(e.stage_id, '')
+	FROM CallInfo c
+	LEFT JOIN users u ON u.member_id = c.member_id AND u.id = c.portal_user_id
+	LEFT JOIN LATERAL (
+		SELECT audio_path FROM total WHERE total.member_id = c.member_id AND total.call_id = c.id LIMIT 1
+	) t ON TRUE
+	LEFT JOIN crm_entities e ON e.member_id = c.member_id
+		AND e.entity_type = UPPER(c.crm_entity_type) AND e.entity_id = c.crm_entity_id
+	LEFT JOIN users r ON r.member_id = c.member_id AND r.id = e.responsible_id`
+
+func scanCall(row interface{ Scan(...interface{}) error }) (*models.CallListItem, error) {
+	var c models.CallListItem
+	var departments []byte
+	err := row.Scan(
+		&c.ID, &c.MemberID, &c.PortalUserID, &c.PortalNumber,
+		&c.PhoneNumber, &c.CallID, &c.CallCategory,
+		&c.CallDuration, &c.CallStartDate, &c.CallVote,
+		&c.Cost, &c.CostCurrency, &c.CallFailedCode,
+		&c.CallFailedReason, &c.CRMEntityType, &c.CRMEntityID,
+		&c.CRMActivityID, &c.Comment, &c.RecordDuration,
+		&c.RecordFileID, &c.CallType,
+		&c.TranscriptID, &c.TranscriptPending,
+		&c.UserName, &c.UserLastName, &departments, pq.Array(&c.DepartmentNames), &c.AudioPath,
+		&c.CRMTitle, &c.CRMCustomerName, &c.CRMResponsibleID,
+		&c.CRMResponsibleName, &c.CRMStageID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(departments, &c.UserDepartments); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// likeEscaper - foydalanuvchi matnidagi LIKE maxsus belgilari (%, _) oddiy belgi sifatida qidirilsin
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// callWhere - filtr bo'yicha WHERE sharti; birinchi parametr har doim member_id
+func callWhere(memberID string, f models.CallFilter) (string, []interface{}) {
+	where := []string{"c.member_id = $1"}
+	args := []interface{}{memberID}
+
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if !f.From.IsZero() {
+		add("c.call_start_date >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("c.call_start_date < $%d", f.To)
+	}
+	if f.UserID != "" {
+		add("c.portal_user_id = $%d", f.UserID)
+	}
+	if f.Phone != "" {
+		add(`c.phone_number LIKE $%d ESCAPE '\'`, "%"+likeEscaper.Replace(f.Phone)+"%")
+	}
+	if f.CallType != 0 {
+		add("c.call_type = $%d", f.CallType)
+	}
+	if f.MinOverlapSec > 0 {
+		add(`EXISTS (SELECT 1 FROM call_talk_stats ts WHERE ts.member_id = c.member_id AND ts.call_id = c.id
+			AND ts.overlap_ms >= $%d * 1000)`, f.MinOverlapSec)
+	}
+	return strings.Join(where, " AND "), args
+}
+
+// ListCalls - portal qo'ng'iroqlarini filtr bo'yicha olish (yangilari birinchi)
+func ListCalls(ctx context.Context, db *sql.DB, memberID string, f models.CallFilter) ([]models.CallListItem, error) {
+	where, args := callWhere(memberID, f)
+
+	limit := f.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	offset := max(f.Offset, 0)
+	args = append(args, limit, offset)
+	query := fmt.Sprintf("%s WHERE %s ORDER BY c.call_start_date DESC NULLS LAST LIMIT $%d OFFSET $%d",
+		callSelect, where, len(args)-1, len(args))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var calls []models.CallListItem
+	for rows.Next() {
+		c, err := scanCall(rows)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, *c)
+	}
+	return calls, rows.Err()
+}
+
+// StreamCalls - filtr bo'yicha barcha qo'ng'iroqlarni birma-bir fn ga beradi (eksport uchun, limit yo'q)
+func StreamCalls(ctx context.Context, db *sql.DB, memberID string, f models.CallFilter, fn func(*models.CallListItem) error) error {
+	where, args := callWhere(memberID, f)
+	query := fmt.Sprintf("%s WHERE %s ORDER BY c.call_start_date, c.id", callSelect, where)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanCall(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetCall - bitta qo'ng'iroq (faqat shu portal doirasida)
+func GetCall(ctx context.Context, db *sql.DB, memberID, id string) (*models.CallListItem, error) {
+	row := db.QueryRowContext(ctx, callSelect+" WHERE c.member_id = $1 AND c.id = $2", memberID, id)
+	return scanCall(row)
+}
+
+// UpdatePortalSyncStatus - har bir sinxronizatsiyadan keyin natijani yozish. Xatolik matni API da ko'rsatiladi,
+// shuning uchun undagi tokenlar (so'rov URL lari) yashiriladi.
+func UpdatePortalSyncStatus(ctx context.Context, db *sql.DB, memberID string, files int, syncErr error) error {
+	var errText sql.NullString
+	if syncErr != nil {
+		errText = sql.NullString{String: logging.Redact(syncErr.Error()), Valid: true}
+	}
+	query := `UPDATE portals SET last_sync_at = $1, last_sync_error = $2, last_sync_files = $3,
+				last_sync_success_at = CASE WHEN $2::text IS NULL THEN $1 ELSE last_sync_success_at END
+			  WHERE member_id = $4`
+	_, err := db.ExecContext(ctx, query, time.Now(), errText, files, memberID)
+	return err
+}
+
+// LastSuccessfulSyncs - yoqilgan portallarning oxirgi muvaffaqiyatli sinxronizatsiyasi (hali bo'lmagan bo'lsa nil)
+func LastSuccessfulSyncs(ctx context.Context, db *sql.DB) (map[string]*time.Time, error) {
+	rows, err := db.QueryContext(ctx, `SELECT member_id, last_sync_success_at FROM portals WHERE NOT disabled`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[string]*time.Time{}
+	for rows.Next() {
+		var memberID string
+		var at sql.NullTime
+		if err := rows.Scan(&memberID, &at); err != nil {
+			return nil, err
+		}
+		result[memberID] = nil
+		if at.Valid {
+			result[memberID] = &at.Time
+		}
+	}
+	return result, rows.Err()
+}
+
+// GetSyncStatus - portalning oxirgi sinxronizatsiya holati
+func GetSyncStatus(ctx context.Context, db *sql.DB, memberID string) (*models.SyncStatus, error) {
+	query := `SELECT member_id, COALESCE(domain, ''), COALESCE(folder_id, ''), last_sync_at,
+				COALESCE(last_sync_error, ''), COALESCE(last_sync_files, 0)
+			  FROM portals WHERE member_id = $1`
+
+	var s models.SyncStatus
+	var lastSync sql.NullTime
+	err := db.QueryRowContext(ctx, query, memberID).Scan(&s.MemberID, &s.Domain, &s.FolderID, &lastSync, &s.LastError, &s.LastFiles)
+	if err != nil {
+		return nil, err
+	}
+	if lastSync.Valid {
+		s.LastSync = &lastSync.Time
+	}
+	return &s, nil
+}
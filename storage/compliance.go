@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"bitrix/models"
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const ruleColumns = `id, member_id, name, kind, phrases, channel, within_seconds, weight, alert, enabled, created_at`
+
+func scanRule(row interface{ Scan(...interface{}) error }) (models.ComplianceRule, error) {
+	var r models.ComplianceRule
+	var channel, within sql.NullInt64
+	err := row.Scan(&r.ID, &r.MemberID, &r.Name, &r.Kind, pq.Array(&r.Phrases), &channel, &within,
+		&r.Weight, &r.Alert, &r.Enabled, &r.CreatedAt)
+	if channel.Valid {
+		c := int(channel.Int64)
+		r.Channel = &c
+	}
+	if within.Valid {
+		s := int(within.Int64)
+		r.WithinSeconds = &s
+	}
+	return r, err
+}
+
+// ListComplianceRules - portal qoidalari (onlyEnabled bo'lsa faqat yoqilganlari)
+func ListComplianceRules(ctx context.Context, db *sql.DB, memberID string, onlyEnabled bool) ([]models.ComplianceRule, error) {
+	rows, err := db.QueryContext(ctx, `SELECT `+ruleColumns+` FROM compliance_rules
+			WHERE member_id = $1 AND (enabled OR NOT $2) ORDER BY id`, memberID, onlyEnabled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.ComplianceRule
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// InsertComplianceRule - yangi qoida; ID va created_at to'ldiriladi
+func InsertComplianceRule(ctx context.Context, db *sql.DB, r *models.ComplianceRule) error {
+	return db.QueryRowContext(ctx, `
+		INSERT INTO compliance_rules (member_id, name, kind, phrases, channel, within_seconds, weight, alert, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at`,
+		r.MemberID, r.Name, r.Kind, pq.Array(r.Phrases), r.Channel, r.WithinSeconds, r.Weight, r.Alert, r.Enabled,
+	).Scan(&r.ID, &r.CreatedAt)
+}
+
+// UpdateComplianceRule - qoidani o'zgartirish; topilmasa false
+func UpdateComplianceRule(ctx context.Context, db *sql.DB, r *models.ComplianceRule) (bool, error) {
+	err := db.QueryRowContext(ctx, `
+		UPDATE compliance_rules SET name = $3, kind = $4, phrases = $5, channel = $6, within_seconds = $7,
+			weight = $8, alert = $9, enabled = $10
+		WHERE member_id = $1 AND id = $2
+		RETURNING created_at`,
+		r.MemberID, r.ID, r.Name, r.Kind, pq.Array(r.Phrases), r.Channel, r.WithinSeconds, r.Weight, r.Alert, r.Enabled,
+	).Scan(&r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteComplianceRule - qoidani o'chirish (natijalardagi bayroqlari ham o'chadi)
+func DeleteComplianceRule(ctx context.Context, db *sql.DB, memberID string, id int) (bool, error) {
+	result, err := db.ExecContext(ctx, `DELETE FROM compliance_rules WHERE member_id = $1 AND id = $2`, memberID, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// SaveCallCompliance - qo'ng'iroq bahosini saqlash (qayta baholansa almashtiriladi, alerted_at saqlanib qoladi)
+func SaveCallCompliance(ctx context.Context, db *sql.DB, c *models.CallCompliance) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO call_compliance (member_id, call_id, score, violations, evaluated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (member_id, call_id) DO UPDATE SET
+			score = EXCLUDED.score,
+			violations = EXCLUDED.violations,
+			evaluated_at = EXCLUDED.evaluated_at`,
+		c.MemberID, c.CallID, c.Score, c.Violations, c.EvaluatedAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM call_compliance_flags WHERE member_id = $1 AND call_id = $2`, c.MemberID, c.CallID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO call_compliance_flags (member_id, call_id, rule_id, passed, matched, start_ms)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, f := range c.Flags {
+		if _, err := stmt.ExecContext(ctx, c.MemberID, c.CallID, f.RuleID, f.Passed, f.Matched, f.StartMs); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCallCompliance - qo'ng'iroq bahosi va qoidalar bo'yicha natijalar
+func GetCallCompliance(ctx context.Context, db *sql.DB, memberID, callID string) (*models.CallCompliance, error) {
+	c := models.CallCompliance{MemberID: memberID, CallID: callID}
+	err := db.QueryRowContext(ctx, `SELECT score, violations, evaluated_at FROM call_compliance
+			WHERE member_id = $1 AND call_id = $2`, memberID, callID).
+		Scan(&c.Score, &c.Violations, &c.EvaluatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT f.rule_id, r.name, r.kind, f.passed, COALESCE(f.matched, ''), f.start_ms
+		FROM call_compliance_flags f
+		JOIN compliance_rules r ON r.id = f.rule_id
+		WHERE f.member_id = $1 AND f.call_id = $2
+		ORDER BY f.passed, r.id`, memberID, callID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var f models.ComplianceFlag
+		var startMs sql.NullInt64
+		if err := rows.Scan(&f.RuleID, &f.RuleName, &f.Kind, &f.Passed, &f.Matched, &startMs); err != nil {
+			return nil, err
+		}
+		if startMs.Valid {
+			f.StartMs = &startMs.Int64
+		}
+		c.Flags = append(c.Flags, f)
+	}
+	return &c, rows.Err()
+}
+
+// ClaimComplianceAlert - ogohlantirish yuborilmagan bo'lsa belgilaydi va true qaytaradi
+func ClaimComplianceAlert(ctx context.Context, db *sql.DB, memberID, callID string) (bool, error) {
+	result, err := db.ExecContext(ctx, `UPDATE call_compliance SET alerted_at = NOW()
+			WHERE member_id = $1 AND call_id = $2 AND alerted_at IS NULL`, memberID, callID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// ReleaseComplianceAlert - yuborish muvaffaqiyatsiz bo'lsa, keyingi baholashda qayta urinish uchun
+func ReleaseComplianceAlert(ctx context.Context, db *sql.DB, memberID, callID string) error {
+	_, err := db.ExecContext(ctx, `UPDATE call_compliance SET alerted_at = NULL WHERE member_id = $1 AND call_id = $2`, memberID, callID)
+	return err
+}
+
+// GetTelegramChatID - portal ogohlantirishlari yuboriladigan chat (bo'sh - o'chirilgan)
+func GetTelegramChatID(ctx context.Context, db *sql.DB, memberID string) (string, error) {
+	var chatID string
+	err := db.QueryRowContext(ctx, `SELECT COALESCE(telegram_chat_id, '') FROM portals WHERE member_id = $1`, memberID).Scan(&chatID)
+	return chatID, err
+}
+
+// SetTelegramChatID - chatni saqlash (bo'sh satr - o'chirish)
+func SetTelegramChatID(ctx context.Context, db *sql.DB, memberID, chatID string) error {
+	_, err := db.ExecContext(ctx, `UPDATE portals SET telegram_chat_id = NULLIF($1, '') WHERE member_id = $2`, chatID, memberID)
+	return err
+}
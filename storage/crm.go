@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"bitrix/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// UpsertCRMEntity - CRM obyekti snapshotini saqlash
+func UpsertCRMEntity(ctx context.Context, db *sql.DB, e *models.CRMEntity) error {
+	query := `
+		INSERT INTO crm_entities (member_id, entity_type, entity_id, title, customer_name, responsible_id,
+			stage_id, phone, email, raw, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, $11)
+		ON CONFLICT (member_id, entity_type, entity_id) DO UPDATE SET
+			title = EXCLUDED.title,
+			customer_name = EXCLUDED.customer_name,
+			responsible_id = EXCLUDED.responsible_id,
+			stage_id = EXCLUDED.stage_id,
+			phone = EXCLUDED.phone,
+			email = EXCLUDED.email,
+			raw = EXCLUDED.raw,
+			fetched_at = EXCLUDED.fetched_at`
+	_, err := db.ExecContext(ctx, query, e.MemberID, e.EntityType, e.EntityID, e.Title, e.CustomerName, e.ResponsibleID,
+		e.StageID, e.Phone, e.Email, string(e.Raw), e.FetchedAt)
+	return err
+}
+
+// GetCRMEntity - saqlangan snapshot (topilmasa sql.ErrNoRows)
+func GetCRMEntity(ctx context.Context, db *sql.DB, memberID, entityType, entityID string) (*models.CRMEntity, error) {
+	query := `SELECT member_id, entity_type, entity_id, COALESCE(title, ''), COALESCE(customer_name, ''),
+				COALESCE(responsible_id, ''), COALESCE(stage_id, ''), COALESCE(phone, ''), COALESCE(email, ''),
+				fetched_at
+			  FROM crm_entities WHERE member_id = $1 AND entity_type = $2 AND entity_id = $3`
+
+	var e models.CRMEntity
+	err := db.QueryRowContext(ctx, query, memberID, entityType, entityID).Scan(&e.MemberID, &e.EntityType, &e.EntityID,
+		&e.Title, &e.CustomerName, &e.ResponsibleID, &e.StageID, &e.Phone, &e.Email, &e.FetchedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// UpsertCRMActivity - faoliyat snapshotini saqlash
+func UpsertCRMActivity(ctx context.Context, db *sql.DB, a *models.CRMActivity) error {
+	query := `
+		INSERT INTO crm_activities (member_id, id, subject, responsible_id, direction, owner_type_id,
+			owner_id, start_time, raw, fetched_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (member_id, id) DO UPDATE SET
+			subject = EXCLUDED.subject,
+			responsible_id = EXCLUDED.responsible_id,
+			direction = EXCLUDED.direction,
+			owner_type_id = EXCLUDED.owner_type_id,
+			owner_id = EXCLUDED.owner_id,
+			start_time = EXCLUDED.start_time,
+			raw = EXCLUDED.raw,
+			fetched_at = EXCLUDED.fetched_at`
+	_, err := db.ExecContext(ctx, query, a.MemberID, a.ID, a.Subject, a.ResponsibleID, a.Direction, a.OwnerTypeID,
+		a.OwnerID, a.StartTime, string(a.Raw), a.FetchedAt)
+	return err
+}
+
+// GetCRMActivityFetchedAt - faoliyat oxirgi marta qachon olingan (topilmasa nol vaqt)
+func GetCRMActivityFetchedAt(ctx context.Context, db *sql.DB, memberID, id string) (time.Time, error) {
+	var t time.Time
+	err := db.QueryRowContext(ctx, `SELECT fetched_at FROM crm_activities WHERE member_id = $1 AND id = $2`, memberID, id).Scan(&t)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return t, err
+}
+
+// GetCRMActivity - saqlangan faoliyat
+func GetCRMActivity(ctx context.Context, db *sql.DB, memberID, id string) (*models.CRMActivity, error) {
+	query := `SELECT member_id, id, COALESCE(subject, ''), COALESCE(responsible_id, ''), COALESCE(direction, 0),
+				COALESCE(owner_type_id, 0), COALESCE(owner_id, ''), start_time, fetched_at
+			  FROM crm_activities WHERE member_id = $1 AND id = $2`
+
+	var a models.CRMActivity
+	err := db.QueryRowContext(ctx, query, memberID, id).Scan(&a.MemberID, &a.ID, &a.Subject, &a.ResponsibleID,
+		&a.Direction, &a.OwnerTypeID, &a.OwnerID, &a.StartTime, &a.FetchedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
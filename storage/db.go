@@ -1,16 +1,30 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
-	_ "github.com/lib/pq"
+	"time"
+
+	"bitrix/logging"
+	"bitrix/metrics"
+
+	"github.com/lib/pq"
 )
 
+var dbLog = logging.For("storage")
+
+// dbQueryDuration - barcha so'rovlar davomiyligi (query - javob boshlanguncha, exec, begin)
+var dbQueryDuration = metrics.NewHistogram("bitrix_db_query_duration_seconds",
+	"PostgreSQL so'rovlari davomiyligi", []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 5}, "op")
+
 func OpenDatabase(connStr string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", connStr)
+	connector, err := pq.NewConnector(connStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
+	db := sql.OpenDB(timedConnector{connector})
 
 	// Bazaga bog'lanishni tekshiramiz
 	err = db.Ping()
@@ -18,6 +32,79 @@ func OpenDatabase(connStr string) (*sql.DB, error) {
 		return nil, fmt.Errorf("database is not reachable: %v", err)
 	}
 
-	fmt.Println("Database connected successfully!")
+	dbLog.Info("DB ga ulanildi")
 	return db, nil
 }
+
+// timedConnector - lib/pq ulanishlarini so'rov vaqtini o'lchaydigan qobiqqa o'raydi
+type timedConnector struct {
+	driver.Connector
+}
+
+func (c timedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{Conn: conn}, nil
+}
+
+// timedConn - lib/pq conn ning context interfeyslarini o'tkazadi; ichkisida bo'lmasa database/sql
+// standart yo'liga qaytadi (driver.ErrSkip, Prepare, Begin)
+type timedConn struct {
+	driver.Conn
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer dbQueryDuration.Since(time.Now(), "query")
+	return q.QueryContext(ctx, query, args)
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer dbQueryDuration.Since(time.Now(), "exec")
+	return e.ExecContext(ctx, query, args)
+}
+
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *timedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	defer dbQueryDuration.Since(time.Now(), "begin")
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *timedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
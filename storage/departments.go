@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"bitrix/models"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// UpsertDepartment - bo'limni qo'shish yoki yangilash
+func UpsertDepartment(ctx context.Context, db *sql.DB, memberID string, d *models.Department) error {
+	d.MemberID = memberID
+	query := `
+		INSERT INTO departments (member_id, id, name, sort, parent_id, head_user_id, synced_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7)
+		ON CONFLICT (member_id, id) DO UPDATE SET
+			name = EXCLUDED.name,
+			sort = EXCLUDED.sort,
+			parent_id = EXCLUDED.parent_id,
+			head_user_id = EXCLUDED.head_user_id,
+			synced_at = EXCLUDED.synced_at`
+	_, err := db.ExecContext(ctx, query, memberID, d.ID, d.Name, d.Sort, d.ParentID, d.HeadUserID, time.Now())
+	return err
+}
+
+// DeleteMissingDepartments - Bitrix24 da o'chirilgan bo'limlarni olib tashlash
+func DeleteMissingDepartments(ctx context.Context, db *sql.DB, memberID string, seenIDs []string) (int, error) {
+	result, err := db.ExecContext(ctx, `DELETE FROM departments WHERE member_id = $1 AND NOT (id = ANY($2))`,
+		memberID, pq.Array(seenIDs))
+	if err != nil {
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+// ListDepartments - portalning barcha bo'limlari
+func ListDepartments(ctx context.Context, db *sql.DB, memberID string) ([]models.Department, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id, member_id, COALESCE(name, ''), COALESCE(sort, 0),
+				COALESCE(parent_id, ''), COALESCE(head_user_id, '')
+			FROM departments WHERE member_id = $1 ORDER BY sort, id`, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var departments []models.Department
+	for rows.Next() {
+		var d models.Department
+		if err := rows.Scan(&d.ID, &d.MemberID, &d.Name, &d.Sort, &d.ParentID, &d.HeadUserID); err != nil {
+			return nil, err
+		}
+		departments = append(departments, d)
+	}
+	return departments, rows.Err()
+}
+
+// GetDepartmentHead - bo'lim rahbari; rahbar belgilanmagan (yoki excludeUserID ning o'zi) bo'lsa,
+// eng yaqin yuqori bo'lim rahbari. Topilmasa "" qaytaradi.
+func GetDepartmentHead(ctx context.Context, db *sql.DB, memberID, departmentID, excludeUserID string) (string, error) {
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_id, head_user_id, 0 AS depth
+			FROM departments WHERE member_id = $1 AND id = $2
+			UNION ALL
+			SELECT d.id, d.parent_id, d.head_user_id, chain.depth + 1
+			FROM departments d JOIN chain ON d.member_id = $1 AND d.id = chain.parent_id
+			WHERE chain.depth < 50
+		)
+		SELECT head_user_id FROM chain
+		WHERE head_user_id IS NOT NULL AND head_user_id <> $3
+		ORDER BY depth LIMIT 1`
+
+	var head string
+	err := db.QueryRowContext(ctx, query, memberID, departmentID, excludeUserID).Scan(&head)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return head, err
+}
+
+// GetUserDepartmentHeads - xodim bo'limlari rahbarlari. Xodim o'zi bo'lim rahbari bo'lsa,
+// yuqori bo'lim rahbariga ko'tariladi (xodimning o'zi hech qachon qaytarilmaydi).
+func GetUserDepartmentHeads(ctx context.Context, db *sql.DB, memberID, userID string) ([]string, error) {
+	user, err := GetUserByID(ctx, db, memberID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var heads []string
+	seen := map[string]bool{}
+	for _, dept := range user.Department {
+		head, err := GetDepartmentHead(ctx, db, memberID, dept, userID)
+		if err != nil {
+			return nil, err
+		}
+		if head != "" && !seen[head] {
+			seen[head] = true
+			heads = append(heads, head)
+		}
+	}
+	return heads, nil
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Migration - bitta migratsiya fayli va holati
+type Migration struct {
+	Name    string
+	Applied bool
+}
+
+// ListMigrations - fayllar tartib bo'yicha, qaysilari allaqachon qo'llanganligi bilan
+func ListMigrations(ctx context.Context, db *sql.DB, files fs.FS) ([]Migration, error) {
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		return nil, err
+	}
+	names, err := fs.Glob(files, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	applied := make(map[string]bool)
+	rows, err := db.QueryContext(ctx, `SELECT name FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		applied[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	list := make([]Migration, 0, len(names))
+	for _, n := range names {
+		name := strings.TrimSuffix(path.Base(n), ".sql")
+		list = append(list, Migration{Name: name, Applied: applied[name]})
+	}
+	return list, nil
+}
+
+// Migrate - bo'sh bazaga db.sql ni, keyin qo'llanmagan migratsiyalarni tartib bilan qo'llaydi.
+// Har bir migratsiya alohida tranzaksiyada; xatolikda to'xtaydi. Qo'llanganlar nomini qaytaradi.
+//
+// Jadval mavjud-u, schema_migrations bo'sh bo'lsa (migratsiyalar ilgari qo'lda qo'llangan),
+// baseline=true bilan barcha fayllar bajarilmasdan "qo'llangan" deb belgilanadi.
+func Migrate(ctx context.Context, db *sql.DB, files fs.FS, baseline bool) ([]string, error) {
+	list, err := ListMigrations(ctx, db, files)
+	if err != nil {
+		return nil, err
+	}
+
+	var fresh, tracked bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass('portals') IS NULL, EXISTS (SELECT 1 FROM schema_migrations)`).
+		Scan(&fresh, &tracked); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case baseline:
+		var done []string
+		for _, m := range list {
+			if m.Applied {
+				continue
+			}
+			if _, err := db.ExecContext(ctx, `INSERT INTO schema_migrations (name) VALUES ($1)`, m.Name); err != nil {
+				return done, err
+			}
+			done = append(done, m.Name)
+		}
+		return done, nil
+	case fresh:
+		if err := execFile(ctx, db, files, "db.sql", ""); err != nil {
+			return nil, fmt.Errorf("db.sql: %v", err)
+		}
+	case !tracked:
+		return nil, fmt.Errorf("baza mavjud, lekin migratsiyalar tarixi yo'q: avval `migrate --baseline` bilan belgilang")
+	}
+
+	var done []string
+	for _, m := range list {
+		if m.Applied {
+			continue
+		}
+		if err := execFile(ctx, db, files, "migrations/"+m.Name+".sql", m.Name); err != nil {
+			return done, fmt.Errorf("%s: %v", m.Name, err)
+		}
+		done = append(done, m.Name)
+	}
+	return done, nil
+}
+
+func ensureMigrationsTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+			name VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`)
+	return err
+}
+
+// execFile - faylni bitta tranzaksiyada bajaradi; name bo'sh bo'lmasa tarixga yoziladi
+func execFile(ctx context.Context, db *sql.DB, files fs.FS, file, name string) error {
+	body, err := fs.ReadFile(files, file)
+	if err != nil {
+		return err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+		return err
+	}
+	if name != "" {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (name) VALUES ($1)`, name); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
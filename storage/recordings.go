@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"bitrix/models"
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const recordingColumns = `call_id, audio_path, COALESCE(format, ''), COALESCE(codec, ''), COALESCE(duration_ms, 0),
+	COALESCE(bitrate, 0), COALESCE(sample_rate, 0), COALESCE(channels, 0), size_bytes, status, COALESCE(problem, ''), checked_at`
+
+func scanRecording(row interface{ Scan(...interface{}) error }, memberID string) (models.RecordingMeta, error) {
+	m := models.RecordingMeta{MemberID: memberID}
+	err := row.Scan(&m.CallID, &m.AudioPath, &m.Format, &m.Codec, &m.DurationMs,
+		&m.Bitrate, &m.SampleRate, &m.Channels, &m.SizeBytes, &m.Status, &m.Problem, &m.CheckedAt)
+	return m, err
+}
+
+// SaveRecordingMeta - tekshiruv natijasini yozish (qayta tekshirilsa almashtiriladi)
+func SaveRecordingMeta(ctx context.Context, db *sql.DB, m *models.RecordingMeta) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO recordings (member_id, call_id, audio_path, format, codec, duration_ms, bitrate, sample_rate,
+			channels, size_bytes, status, problem, checked_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9, $10, $11, NULLIF($12, ''), $13)
+		ON CONFLICT (member_id, call_id) DO UPDATE SET
+			audio_path = EXCLUDED.audio_path,
+			format = EXCLUDED.format,
+			codec = EXCLUDED.codec,
+			duration_ms = EXCLUDED.duration_ms,
+			bitrate = EXCLUDED.bitrate,
+			sample_rate = EXCLUDED.sample_rate,
+			channels = EXCLUDED.channels,
+			size_bytes = EXCLUDED.size_bytes,
+			status = EXCLUDED.status,
+			problem = EXCLUDED.problem,
+			checked_at = EXCLUDED.checked_at`,
+		m.MemberID, m.CallID, m.AudioPath, m.Format, m.Codec, m.DurationMs, m.Bitrate, m.SampleRate,
+		m.Channels, m.SizeBytes, m.Status, m.Problem, m.CheckedAt)
+	return err
+}
+
+// GetRecordingMeta - qo'ng'iroq yozuvi ma'lumotlari
+func GetRecordingMeta(ctx context.Context, db *sql.DB, memberID, callID string) (*models.RecordingMeta, error) {
+	m, err := scanRecording(db.QueryRowContext(ctx, `SELECT `+recordingColumns+` FROM recordings
+			WHERE member_id = $1 AND call_id = $2`, memberID, callID), memberID)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ListRecordingProblems - holati "ok" bo'lmagan yozuvlar (qayta yuklash yoki tekshirish uchun)
+func ListRecordingProblems(ctx context.Context, db *sql.DB, memberID string, limit int) ([]models.RecordingMeta, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := db.QueryContext(ctx, `SELECT `+recordingColumns+` FROM recordings
+			WHERE member_id = $1 AND status <> 'ok'
+			ORDER BY checked_at DESC LIMIT $2`, memberID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.RecordingMeta
+	for rows.Next() {
+		m, err := scanRecording(rows, memberID)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
+
+// LegacyRecording - portal papkasidan tashqarida (umumiy downloads/ da) saqlangan fayl va unga bog'langan
+// portal/qo'ng'iroqlar. Bir nechta bo'lsa, fayl ularning qaysi biriga tegishli ekanini aniqlab bo'lmaydi.
+type LegacyRecording struct {
+	AudioPath string
+	MemberIDs []string
+	CallIDs   []string
+}
+
+// ListLegacyRecordings - yo'li downloads/<member_id>/ ko'rinishida bo'lmagan total yozuvlari, fayl bo'yicha guruhlangan
+func ListLegacyRecordings(ctx context.Context, db *sql.DB, prefix string) ([]LegacyRecording, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT audio_path, array_agg(DISTINCT member_id), array_agg(DISTINCT call_id)
+		FROM total
+		WHERE audio_path <> '' AND audio_path NOT LIKE $1
+		GROUP BY audio_path
+		ORDER BY audio_path`, prefix+"%/%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []LegacyRecording
+	for rows.Next() {
+		var r LegacyRecording
+		if err := rows.Scan(&r.AudioPath, pq.Array(&r.MemberIDs), pq.Array(&r.CallIDs)); err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+// MoveRecordingPath - fayl ko'chirilgach portalning total, recordings va transcription_jobs yozuvlarida yo'lni almashtiradi
+func MoveRecordingPath(ctx context.Context, db *sql.DB, memberID, oldPath, newPath string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"total", "recordings", "transcription_jobs"} {
+		if _, err := tx.ExecContext(ctx, `UPDATE `+table+` SET audio_path = $3
+			WHERE member_id = $1 AND audio_path = $2`, memberID, oldPath, newPath); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ForgetRecordingPath - fayl egasi aniqlanmasa unga bog'langan yozuvlarni o'chiradi: qo'ng'iroq "yuklab olinmagan"
+// hisoblanadi va keyingi sinxronizatsiya (yoki backfill) uni portal papkasiga qayta yuklaydi
+func ForgetRecordingPath(ctx context.Context, db *sql.DB, audioPath string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"total", "recordings", "transcription_jobs"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE audio_path = $1`, audioPath); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
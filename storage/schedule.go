@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"bitrix/models"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// syncLockClass - portal sinxronizatsiyasi advisory lock larining nomlar fazosi (ikkinchi kalit - hashtext(member_id))
+const syncLockClass = 7301
+
+var scheduleColumns = `member_id, COALESCE(folder_id, ''), sync_schedule, sync_timezone, sync_paused, disabled,
+	sync_requested_at, sync_started_at,
+	EXISTS (SELECT 1 FROM pg_locks WHERE locktype = 'advisory' AND classid = ` + strconv.Itoa(syncLockClass) + ` AND objsubid = 2
+		AND objid = hashtext(member_id)::oid)`
+
+func scanSchedule(row interface{ Scan(...interface{}) error }) (*models.PortalSchedule, error) {
+	var p models.PortalSchedule
+	var requested, started sql.NullTime
+	err := row.Scan(&p.MemberID, &p.FolderID, pq.Array(&p.Schedule), &p.TimeZone, &p.Paused, &p.Disabled,
+		&requested, &started, &p.Running)
+	if err != nil {
+		return nil, err
+	}
+	if requested.Valid {
+		p.RequestedAt = &requested.Time
+	}
+	if started.Valid {
+		p.StartedAt = &started.Time
+	}
+	if p.Schedule == nil {
+		p.Schedule = []string{}
+	}
+	return &p, nil
+}
+
+// ListPortalSchedules - barcha portallar jadvali (o'chirilganlar ham)
+func ListPortalSchedules(ctx context.Context, db *sql.DB) ([]models.PortalSchedule, error) {
+	rows, err := db.QueryContext(ctx, `SELECT `+scheduleColumns+` FROM portals ORDER BY member_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.PortalSchedule
+	for rows.Next() {
+		p, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *p)
+	}
+	return list, rows.Err()
+}
+
+// GetPortalSchedule - bitta portal jadvali
+func GetPortalSchedule(ctx context.Context, db *sql.DB, memberID string) (*models.PortalSchedule, error) {
+	return scanSchedule(db.QueryRowContext(ctx, `SELECT `+scheduleColumns+` FROM portals WHERE member_id = $1`, memberID))
+}
+
+// UpdateSyncSettings - jadval, vaqt mintaqasi va pauza; portal topilmasa false
+func UpdateSyncSettings(ctx context.Context, db *sql.DB, memberID string, s *models.SyncSettings) (bool, error) {
+	result, err := db.ExecContext(ctx, `UPDATE portals SET sync_schedule = $2, sync_timezone = $3, sync_paused = $4
+			WHERE member_id = $1`, memberID, pq.Array(s.Schedule), s.TimeZone, s.Paused)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// SetSyncPaused - faqat pauza belgisini o'zgartirish; portal topilmasa false
+func SetSyncPaused(ctx context.Context, db *sql.DB, memberID string, paused bool) (bool, error) {
+	result, err := db.ExecContext(ctx, `UPDATE portals SET sync_paused = $2 WHERE member_id = $1`, memberID, paused)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// RequestSync - "hozir sinxronlash": rejalashtiruvchi keyingi tekshiruvda (pauzada ham) ishga tushiradi
+func RequestSync(ctx context.Context, db *sql.DB, memberID string) (time.Time, error) {
+	var at time.Time
+	err := db.QueryRowContext(ctx, `UPDATE portals SET sync_requested_at = COALESCE(sync_requested_at, NOW())
+			WHERE member_id = $1 RETURNING sync_requested_at`, memberID).Scan(&at)
+	return at, err
+}
+
+// MarkSyncStarted - sinxronizatsiya boshlandi: jadval hisobi shu vaqtdan, qo'lda so'rov bajarilgan deb olinadi
+func MarkSyncStarted(ctx context.Context, db *sql.DB, memberID string) error {
+	_, err := db.ExecContext(ctx, `UPDATE portals SET sync_started_at = NOW(), sync_requested_at = NULL WHERE member_id = $1`, memberID)
+	return err
+}
+
+// TryLockPortalSync - portal uchun sessiya darajasidagi advisory lock (boshqa jarayonlar, masalan CLI, bilan ham).
+// Olingan bo'lsa unlock ni chaqirish shart; band bo'lsa ok=false.
+func TryLockPortalSync(ctx context.Context, db *sql.DB, memberID string) (unlock func(), ok bool, err error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, hashtext($2))`, syncLockClass, memberID).Scan(&ok); err != nil || !ok {
+		conn.Close()
+		return nil, false, err
+	}
+	return func() {
+		// ctx bekor qilingan bo'lsa ham (to'xtatish paytida) lock bo'shatiladi
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1, hashtext($2))`, syncLockClass, memberID); err != nil {
+			// Ulanish pulga qaytmasin: yopilgan ulanish bilan lock ham bo'shaydi
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, true, nil
+}
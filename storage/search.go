@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"bitrix/models"
+	"context"
+	"database/sql"
+	"html"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ts_headline matnni escape qilmaydi: ajratish uchun maxsus belgilar ishlatib,
+// keyin Go da escape qilinadi va <mark> ga almashtiriladi
+const (
+	markStart = "\ue000"
+	markStop  = "\ue001"
+)
+
+const searchQuery = `
+WITH q AS (
+	SELECT websearch_to_tsquery('russian', $2) || websearch_to_tsquery('simple', $2) AS query
+),
+hits AS (
+	SELECT s.call_id, s.start_ms::BIGINT AS start_ms, s.text, ts_rank(s.search, q.query) AS rank, 'transcript' AS source
+	FROM transcript_segments s, q
+	WHERE s.member_id = $1 AND s.search @@ q.query
+	UNION ALL
+	SELECT c.id, NULL, c.comment, ts_rank(c.comment_search, q.query), 'comment'
+	FROM CallInfo c, q
+	WHERE c.member_id = $1 AND c.comment_search @@ q.query
+),
+best AS (
+	SELECT DISTINCT ON (call_id) call_id, start_ms, text, source
+	FROM hits ORDER BY call_id, rank DESC
+),
+scored AS (
+	SELECT call_id, SUM(rank) AS rank, COUNT(*) AS matches,
+		ARRAY_REMOVE(ARRAY_AGG(start_ms ORDER BY start_ms), NULL) AS timestamps
+	FROM hits GROUP BY call_id
+)
+SELECT b.call_id, sc.rank, sc.matches, b.source, b.start_ms, sc.timestamps,
+	ts_headline('russian', b.text, q.query, 'StartSel=` + markStart + `, StopSel=` + markStop + `, MaxWords=30, MinWords=10'),
+	c.call_start_date, COALESCE(c.phone_number, ''), COALESCE(c.portal_user_id, ''),
+	COALESCE(TRIM(u.name || ' ' || u.last_name), '')
+FROM best b
+JOIN scored sc ON sc.call_id = b.call_id
+JOIN CallInfo c ON c.member_id = $1 AND c.id = b.call_id
+LEFT JOIN users u ON u.member_id = $1 AND u.id = c.portal_user_id
+CROSS JOIN q
+ORDER BY sc.rank DESC, c.call_start_date DESC
+LIMIT $3 OFFSET $4`
+
+// SearchCalls - transkriptlar va izohlar bo'yicha qidiruv (reyting bo'yicha)
+func SearchCalls(ctx context.Context, db *sql.DB, memberID, q string, limit, offset int) ([]models.SearchResult, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	rows, err := db.QueryContext(ctx, searchQuery, memberID, q, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		var r models.SearchResult
+		var startMs sql.NullInt64
+		var timestamps pq.Int64Array
+		if err := rows.Scan(&r.CallID, &r.Rank, &r.Matches, &r.Source, &startMs, &timestamps,
+			&r.Snippet, &r.CallStartDate, &r.PhoneNumber, &r.PortalUserID, &r.UserName); err != nil {
+			return nil, err
+		}
+		if startMs.Valid {
+			r.StartMs = &startMs.Int64
+		}
+		r.Timestamps = timestamps
+		r.Snippet = highlight(r.Snippet)
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// highlight - xavfsiz HTML: matn escape qilinadi, faqat <mark> qoladi
+func highlight(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, markStart, "<mark>")
+	return strings.ReplaceAll(s, markStop, "</mark>")
+}
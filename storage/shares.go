@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// InsertCallShare - qo'ng'iroq uchun ulashiladigan havola tokeni (va hashi) ni muddati bilan saqlash
+func InsertCallShare(ctx context.Context, db *sql.DB, memberID, callID, token, tokenHash string, expiresAt time.Time) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO call_shares (token_hash, token, member_id, call_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		tokenHash, token, memberID, callID, expiresAt)
+	return err
+}
+
+// GetLiveCallShare - qo'ng'iroqning bekor qilinmagan va notBefore dan keyin ham amal qiladigan havola tokeni;
+// bo'lmasa sql.ErrNoRows
+func GetLiveCallShare(ctx context.Context, db *sql.DB, memberID, callID string, notBefore time.Time) (string, error) {
+	var token string
+	err := db.QueryRowContext(ctx, `
+		SELECT token FROM call_shares
+		WHERE member_id = $1 AND call_id = $2 AND token IS NOT NULL AND revoked_at IS NULL AND expires_at > $3
+		ORDER BY expires_at DESC LIMIT 1`, memberID, callID, notBefore).Scan(&token)
+	return token, err
+}
+
+// GetCallShare - token hashi bo'yicha portal va qo'ng'iroq (muddati o'tgan yoki bekor qilingan havola topilmaydi);
+// topilmasa sql.ErrNoRows
+func GetCallShare(ctx context.Context, db *sql.DB, tokenHash string) (memberID, callID string, err error) {
+	err = db.QueryRowContext(ctx, `
+		SELECT member_id, call_id FROM call_shares
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > $2`, tokenHash, time.Now()).
+		Scan(&memberID, &callID)
+	return memberID, callID, err
+}
+
+// RevokeCallShares - qo'ng'iroqning barcha amaldagi havolalarini bekor qilish; bekor qilinganlar sonini qaytaradi
+func RevokeCallShares(ctx context.Context, db *sql.DB, memberID, callID string) (int64, error) {
+	result, err := db.ExecContext(ctx, `
+		UPDATE call_shares SET revoked_at = $3
+		WHERE member_id = $1 AND call_id = $2 AND revoked_at IS NULL AND expires_at > $3`,
+		memberID, callID, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteExpiredCallShares - before gacha eskirgan yoki bekor qilingan havolalarni tozalash
+func DeleteExpiredCallShares(ctx context.Context, db *sql.DB, before time.Time) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM call_shares WHERE expires_at <= $1 OR revoked_at <= $1`, before)
+	return err
+}
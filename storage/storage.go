@@ -2,28 +2,31 @@ package storage
 
 import (
 	"bitrix/models"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
 	"time"
 )
 
-// CallInfo ma'lumotlarini saqlash
-func InsertCallInfo(call *models.CallInfo, db *sql.DB) error {
+// CallInfo ma'lumotlarini saqlash (portal doirasida)
+func InsertCallInfo(ctx context.Context, memberID string, call *models.CallInfo, db *sql.DB) error {
+	call.MemberID = memberID
+
 	query := `
 	INSERT INTO CallInfo (
 		id, portal_user_id, portal_number, phone_number, call_id, external_call_id,
 		call_category, call_duration, call_start_date, call_record_url, call_vote, cost,
 		cost_currency, call_failed_code, call_failed_reason, crm_entity_type, crm_entity_id,
 		crm_activity_id, rest_app_id, rest_app_name, transcript_id, transcript_pending,
-		session_id, redial_attempt, comment, record_duration, record_file_id, call_type
+		session_id, redial_attempt, comment, record_duration, record_file_id, call_type,
+		member_id
 	) VALUES (
 		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
-		$18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28
-	) ON CONFLICT (id) DO NOTHING;`
+		$18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29
+	) ON CONFLICT (member_id, id) DO NOTHING;`
 
-	result, err := db.Exec(
+	result, err := db.ExecContext(ctx,
 		query,
 		call.ID, call.PortalUserID, call.PortalNumber, call.PhoneNumber, call.CallID,
 		call.ExternalCallID, call.CallCategory, call.CallDuration, call.CallStartDate,
@@ -31,26 +34,31 @@ func InsertCallInfo(call *models.CallInfo, db *sql.DB) error {
 		call.CallFailedReason, call.CRMEntityType, call.CRMEntityID, call.CRMActivityID,
 		call.RestAppID, call.RestAppName, call.TranscriptID, call.TranscriptPending,
 		call.SessionID, call.RedialAttempt, call.Comment, call.RecordDuration,
-		call.RecordFileID, call.CallType,
+		call.RecordFileID, call.CallType, call.MemberID,
 	)
 	if err != nil {
-		log.Printf("❌ CallInfo saqlashda xatolik (ID: %s): %v", call.ID, err)
 		return fmt.Errorf("CallInfo saqlab bo‘lmadi: %w", err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		log.Printf("⚠️ CallInfo (ID: %s) allaqachon mavjud, qo‘shilmadi", call.ID)
+		dbLog.Debug("qo'ng'iroq allaqachon mavjud", "member_id", call.MemberID, "call_id", call.ID)
 	}
 
 	return nil
 }
 
-func InsertUser(user *models.User, db *sql.DB) error {
-	// Departmentni JSON formatiga o'tkazamiz
-	departmentJSON, err := json.Marshal(user.Department)
+func InsertUser(ctx context.Context, memberID string, user *models.User, db *sql.DB) error {
+	user.MemberID = memberID
+
+	// Departmentni JSON formatiga o'tkazamiz (null emas, bo'sh massiv bo'lishi kerak)
+	departments := user.Department
+	if departments == nil {
+		departments = models.FlexIDs{}
+	}
+	departmentJSON, err := json.Marshal(departments)
 	if err != nil {
-		return fmt.Errorf("❌ Department JSON serialize qilishda xatolik: %v", err)
+		return fmt.Errorf("Department JSON serialize qilishda xatolik: %v", err)
 	}
 
 	query := `
@@ -58,83 +66,83 @@ func InsertUser(user *models.User, db *sql.DB) error {
 			id, xml_id, active, name, last_name, second_name, email, last_login,
 			time_zone, time_zone_offset, personal_photo, personal_gender, personal_www,
 			personal_birthday, personal_mobile, personal_city, work_phone, work_position,
-			uf_employment_date, user_type, department_ids
+			uf_employment_date, user_type, department_ids, member_id
 		) VALUES (
-			$1, $2, $3::VARCHAR, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
-			$18, $19, $20, $21
-		) ON CONFLICT (id) DO NOTHING;`
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
+			$18, $19, $20, $21, $22
+		) ON CONFLICT (member_id, id) DO NOTHING;`
 
-	result, err := db.Exec(query, user.ID, user.XML_ID, fmt.Sprintf("%t", user.Active), user.Name, user.LastName, user.SecondName,
+	result, err := db.ExecContext(ctx, query, user.ID, user.XML_ID, user.Active, user.Name, user.LastName, user.SecondName,
 		user.Email, user.LastLogin, user.TimeZone, user.TimeZoneOffset, user.PersonalPhoto,
 		user.PersonalGender, user.PersonalWWW, user.PersonalBirthday, user.PersonalMobile,
 		user.PersonalCity, user.WorkPhone, user.WorkPosition, user.EmploymentDate,
-		user.UserType, string(departmentJSON))
+		user.UserType, string(departmentJSON), memberID)
 
 	if err != nil {
-		return fmt.Errorf("❌ User saqlashda xatolik (ID: %s): %v", user.ID, err)
+		return fmt.Errorf("User saqlashda xatolik (ID: %s): %v", user.ID, err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		log.Printf("⚠️ User (ID: %s) allaqachon mavjud, qo‘shilmadi", user.ID)
+		dbLog.Debug("xodim allaqachon mavjud", "member_id", memberID, "user_id", user.ID)
 	}
 
 	return nil
 }
 
-func InsertMonth(month *models.Month, db *sql.DB) error {
+func InsertMonth(ctx context.Context, memberID string, month *models.Month, db *sql.DB) error {
+	month.MemberID = memberID
+
 	query := `
 		INSERT INTO months (
 			id, name, code, storage_id, type, parent_id, deleted_type, 
 			global_content_version, file_id, size, create_time, update_time, delete_time, 
-			created_by, updated_by, deleted_by, download_url, detail_url
+			created_by, updated_by, deleted_by, download_url, detail_url, member_id
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, 
-			$11, $12, $13, $14, $15, $16, $17, $18
-		) ON CONFLICT (id) DO NOTHING;`
+			$11, $12, $13, $14, $15, $16, $17, $18, $19
+		) ON CONFLICT (member_id, id) DO NOTHING;`
 
-	result, err := db.Exec(query, month.ID, month.Name, month.Code, month.StorageID, month.Type, month.ParentID,
+	result, err := db.ExecContext(ctx, query, month.ID, month.Name, month.Code, month.StorageID, month.Type, month.ParentID,
 		month.DeletedType, month.GlobalContentVersion, month.FileID, month.Size,
 		month.CreateTime, month.UpdateTime, month.DeleteTime,
 		month.CreatedBy, month.UpdatedBy, month.DeletedBy,
-		month.DownloadURL, month.DetailURL)
+		month.DownloadURL, month.DetailURL, memberID)
 
 	if err != nil {
-		return fmt.Errorf("❌ Month saqlashda xatolik: %v", err)
+		return fmt.Errorf("Month saqlashda xatolik: %v", err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		log.Printf("⚠️ Month (ID: %s) allaqachon mavjud, qo‘shilmadi", month.ID)
+		dbLog.Debug("fayl allaqachon mavjud", "member_id", memberID, "file_id", month.ID)
 	}
 
 	return nil
 }
 
-func InsertTotal(total models.Total, db *sql.DB) error {
+func InsertTotal(ctx context.Context, memberID string, total models.Total, db *sql.DB) error {
 
 	query := `
-		INSERT INTO total (audio_path, call_id, user_id)
-		VALUES ($1, $2, $3)`
+		INSERT INTO total (audio_path, call_id, user_id, member_id)
+		VALUES ($1, $2, $3, $4)`
 
-	result, err := db.Exec(query, total.AudioPath, total.CallID, total.UserID)
+	result, err := db.ExecContext(ctx, query, total.AudioPath, total.CallID, total.UserID, memberID)
 
 	if err != nil {
-		return fmt.Errorf("❌ Total ma'lumotini qo'shishda xatolik: %v", err)
+		return fmt.Errorf("Total ma'lumotini qo'shishda xatolik: %v", err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		log.Println("⚠️ Total ma'lumoti allaqachon mavjud, qo‘shilmadi")
-	} else {
-		log.Println("✅ Total ma'lumoti bazaga muvaffaqiyatli qo‘shildi!")
+		dbLog.Debug("total yozuvi allaqachon mavjud", "member_id", memberID, "call_id", total.CallID)
 	}
 	return nil
 }
 
-func GetLastDownloadedFileID(db *sql.DB) (string, error) {
+func GetLastDownloadedFileID(ctx context.Context, db *sql.DB, memberID string) (string, error) {
 	var lastFileID string
-	err := db.QueryRow("SELECT call_id FROM total ORDER BY call_id DESC LIMIT 1").Scan(&lastFileID)
+	err := db.QueryRowContext(ctx, "SELECT call_id FROM total WHERE member_id = $1 ORDER BY call_id DESC LIMIT 1", memberID).Scan(&lastFileID)
 	if err == sql.ErrNoRows {
 		return "", nil // Agar hech narsa topilmasa, bo'sh string qaytarish
 	} else if err != nil {
@@ -143,11 +151,11 @@ func GetLastDownloadedFileID(db *sql.DB) (string, error) {
 	return lastFileID, nil
 }
 
-// GetAllPortals - DB'dan barcha portalni (member_id, folder_id va tokenlar) olish
-func GetAllPortals(db *sql.DB) ([]models.PortalInfo, error) {
-	query := `SELECT member_id, domain, access_token, refresh_token, expires_in, scope, last_update, client_endpoint, folder_id
-              FROM portals`
-	rows, err := db.Query(query)
+// GetAllPortals - DB'dan barcha portalni (member_id, folder_id va tokenlar) olish; o'chirilganlar ham qaytadi
+func GetAllPortals(ctx context.Context, db *sql.DB) ([]models.PortalInfo, error) {
+	query := `SELECT member_id, domain, access_token, refresh_token, expires_in, scope, last_update, client_endpoint, folder_id, disabled
+              FROM portals ORDER BY member_id`
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -158,7 +166,7 @@ func GetAllPortals(db *sql.DB) ([]models.PortalInfo, error) {
 		var p models.PortalInfo
 		var lu time.Time
 		if err := rows.Scan(
-			&p.MemberID, &p.Domain, &p.AccessToken, &p.RefreshToken, &p.ExpiresIn, &p.Scope, &lu, &p.ClientEndpoint, &p.FolderID,
+			&p.MemberID, &p.Domain, &p.AccessToken, &p.RefreshToken, &p.ExpiresIn, &p.Scope, &lu, &p.ClientEndpoint, &p.FolderID, &p.Disabled,
 		); err != nil {
 			return nil, err
 		}
@@ -168,8 +176,50 @@ func GetAllPortals(db *sql.DB) ([]models.PortalInfo, error) {
 	return portals, rows.Err()
 }
 
-func UpdatePortalFolderID(db *sql.DB, memberID, folderID string) error {
+func UpdatePortalFolderID(ctx context.Context, db *sql.DB, memberID, folderID string) error {
 	query := `UPDATE portals SET folder_id = $1 WHERE member_id = $2`
-	_, err := db.Exec(query, folderID, memberID)
+	_, err := db.ExecContext(ctx, query, folderID, memberID)
 	return err
 }
+
+// SetPortalDisabled - portalni avtomatik sinxronizatsiyadan chiqarish/qaytarish; portal topilmasa false
+func SetPortalDisabled(ctx context.Context, db *sql.DB, memberID string, disabled bool) (bool, error) {
+	result, err := db.ExecContext(ctx, `UPDATE portals SET disabled = $2 WHERE member_id = $1`, memberID, disabled)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// IsCallDownloaded - yozuv allaqachon yuklab olinib total ga yozilganmi
+func IsCallDownloaded(ctx context.Context, db *sql.DB, memberID, callID string) (bool, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM total WHERE member_id = $1 AND call_id = $2)`,
+		memberID, callID).Scan(&exists)
+	return exists, err
+}
+
+// ListDownloadedRecordings - yuklab olingan yozuvlar (fayl yo'li va Bitrix24 dagi davomiylik bilan)
+func ListDownloadedRecordings(ctx context.Context, db *sql.DB, memberID string) ([]models.DownloadedRecording, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT DISTINCT ON (t.call_id) t.call_id, t.audio_path, COALESCE(c.record_duration, 0)
+		FROM total t
+		JOIN CallInfo c ON c.member_id = t.member_id AND c.id = t.call_id
+		WHERE t.member_id = $1
+		ORDER BY t.call_id`, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.DownloadedRecording
+	for rows.Next() {
+		var r models.DownloadedRecording
+		if err := rows.Scan(&r.CallID, &r.AudioPath, &r.RecordDuration); err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"bitrix/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// SaveTalkStats - suhbat dinamikasini yozish (qayta tahlil qilinsa almashtiriladi)
+func SaveTalkStats(ctx context.Context, db *sql.DB, s *models.CallTalkStats) error {
+	silences := s.Silences
+	if silences == nil {
+		silences = []models.SilenceRange{}
+	}
+	silencesJSON, err := json.Marshal(silences)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO call_talk_stats (member_id, call_id, duration_ms, operator_talk_ms, customer_talk_ms, silence_ms,
+			overlap_ms, longest_monologue_ms, monologue_channel, silences, approximate, analyzed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (member_id, call_id) DO UPDATE SET
+			duration_ms = EXCLUDED.duration_ms,
+			operator_talk_ms = EXCLUDED.operator_talk_ms,
+			customer_talk_ms = EXCLUDED.customer_talk_ms,
+			silence_ms = EXCLUDED.silence_ms,
+			overlap_ms = EXCLUDED.overlap_ms,
+			longest_monologue_ms = EXCLUDED.longest_monologue_ms,
+			monologue_channel = EXCLUDED.monologue_channel,
+			silences = EXCLUDED.silences,
+			approximate = EXCLUDED.approximate,
+			analyzed_at = EXCLUDED.analyzed_at`,
+		s.MemberID, s.CallID, s.DurationMs, s.OperatorTalkMs, s.CustomerTalkMs, s.SilenceMs,
+		s.OverlapMs, s.LongestMonologueMs, s.MonologueChannel, silencesJSON, s.Approximate, s.AnalyzedAt)
+	return err
+}
+
+// GetTalkStats - qo'ng'iroq suhbat dinamikasi
+func GetTalkStats(ctx context.Context, db *sql.DB, memberID, callID string) (*models.CallTalkStats, error) {
+	s := models.CallTalkStats{MemberID: memberID, CallID: callID}
+	var customer, channel sql.NullInt64
+	var silences []byte
+	err := db.QueryRowContext(ctx, `
+		SELECT duration_ms, operator_talk_ms, customer_talk_ms, silence_ms, overlap_ms,
+			longest_monologue_ms, monologue_channel, silences, approximate, analyzed_at
+		FROM call_talk_stats WHERE member_id = $1 AND call_id = $2`, memberID, callID).
+		Scan(&s.DurationMs, &s.OperatorTalkMs, &customer, &s.SilenceMs, &s.OverlapMs,
+			&s.LongestMonologueMs, &channel, &silences, &s.Approximate, &s.AnalyzedAt)
+	if err != nil {
+		return nil, err
+	}
+	if customer.Valid {
+		s.CustomerTalkMs = &customer.Int64
+	}
+	if channel.Valid {
+		c := int(channel.Int64)
+		s.MonologueChannel = &c
+	}
+	if err := json.Unmarshal(silences, &s.Silences); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// IsTimelineEnabled - portal CRM timeline izohlarini yoqganmi
+func IsTimelineEnabled(ctx context.Context, db *sql.DB, memberID string) (bool, error) {
+	var enabled bool
+	err := db.QueryRowContext(ctx, `SELECT timeline_comments_enabled FROM portals WHERE member_id = $1`, memberID).Scan(&enabled)
+	return enabled, err
+}
+
+// SetTimelineEnabled - timeline izohlarini yoqish/o'chirish
+func SetTimelineEnabled(ctx context.Context, db *sql.DB, memberID string, enabled bool) error {
+	_, err := db.ExecContext(ctx, `UPDATE portals SET timeline_comments_enabled = $1 WHERE member_id = $2`, enabled, memberID)
+	return err
+}
+
+// ClaimTimelineComment - qo'ng'iroq uchun izohni "band qiladi"; allaqachon yuborilgan/yuborilayotgan bo'lsa false.
+// comment_id NULL bo'lib staleAfter dan eski qolgan yozuv (masalan jarayon to'xtab qolgan) qayta band qilinadi.
+func ClaimTimelineComment(ctx context.Context, db *sql.DB, memberID, callID, entityType, entityID string, staleAfter time.Duration) (bool, error) {
+	query := `
+		INSERT INTO crm_timeline_comments (member_id, call_id, entity_type, entity_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (member_id, call_id) DO UPDATE SET created_at = EXCLUDED.created_at
+			WHERE crm_timeline_comments.comment_id IS NULL AND crm_timeline_comments.created_at < $6`
+	now := time.Now()
+	result, err := db.ExecContext(ctx, query, memberID, callID, entityType, entityID, now, now.Add(-staleAfter))
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// CompleteTimelineComment - yuborilgan izoh ID sini yozish
+func CompleteTimelineComment(ctx context.Context, db *sql.DB, memberID, callID, commentID string) error {
+	_, err := db.ExecContext(ctx, `UPDATE crm_timeline_comments SET comment_id = $1 WHERE member_id = $2 AND call_id = $3`,
+		commentID, memberID, callID)
+	return err
+}
+
+// ReleaseTimelineComment - yuborish muvaffaqiyatsiz bo'lsa, keyingi sinxronizatsiyada qayta urinish uchun
+func ReleaseTimelineComment(ctx context.Context, db *sql.DB, memberID, callID string) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM crm_timeline_comments WHERE member_id = $1 AND call_id = $2 AND comment_id IS NULL`,
+		memberID, callID)
+	return err
+}
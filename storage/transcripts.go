@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"bitrix/logging"
+	"bitrix/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// EnqueueTranscriptionJob - yozuv uchun vazifa qo'shish (allaqachon bo'lsa, o'zgarmaydi)
+func EnqueueTranscriptionJob(ctx context.Context, db *sql.DB, memberID, callID, audioPath string) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO transcription_jobs (member_id, call_id, audio_path)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (member_id, call_id) DO NOTHING`, memberID, callID, audioPath)
+	return err
+}
+
+// ClaimTranscriptionJobs - navbatdagi vazifalarni olish (bir nechta worker bir vazifani olmaydi)
+func ClaimTranscriptionJobs(ctx context.Context, db *sql.DB, limit int) ([]models.TranscriptionJob, error) {
+	rows, err := db.QueryContext(ctx, `
+		UPDATE transcription_jobs SET status = 'running', attempts = attempts + 1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM transcription_jobs
+			WHERE status = 'queued' AND next_attempt_at <= NOW()
+			ORDER BY id LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, member_id, call_id, audio_path, status, attempts`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []models.TranscriptionJob
+	for rows.Next() {
+		var j models.TranscriptionJob
+		if err := rows.Scan(&j.ID, &j.MemberID, &j.CallID, &j.AudioPath, &j.Status, &j.Attempts); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// FinishTranscriptionJob - vazifa holatini yakunlash (done, skipped yoki failed)
+func FinishTranscriptionJob(ctx context.Context, db *sql.DB, id int, status, provider, lastError string) error {
+	_, err := db.ExecContext(ctx, `UPDATE transcription_jobs SET status = $2, provider = NULLIF($3, ''), last_error = NULLIF($4, ''),
+			updated_at = NOW() WHERE id = $1`, id, status, provider, logging.Redact(lastError))
+	return err
+}
+
+// RetryTranscriptionJob - vazifani keyinroq qayta urinish uchun navbatga qaytarish
+func RetryTranscriptionJob(ctx context.Context, db *sql.DB, id int, lastError string, after time.Duration) error {
+	_, err := db.ExecContext(ctx, `UPDATE transcription_jobs SET status = 'queued', last_error = $2, next_attempt_at = $3,
+			updated_at = NOW() WHERE id = $1`, id, logging.Redact(lastError), time.Now().Add(after))
+	return err
+}
+
+// ReleaseTranscriptionJob - olingan, lekin bajarilmagan vazifani navbatga qaytarish (urinish hisoblanmaydi)
+func ReleaseTranscriptionJob(ctx context.Context, db *sql.DB, id int) error {
+	_, err := db.ExecContext(ctx, `UPDATE transcription_jobs SET status = 'queued', attempts = GREATEST(attempts - 1, 0),
+			updated_at = NOW() WHERE id = $1 AND status = 'running'`, id)
+	return err
+}
+
+// RequeueStaleTranscriptionJobs - jarayon uzilib "running" holatda qolgan vazifalarni qaytarish
+func RequeueStaleTranscriptionJobs(ctx context.Context, db *sql.DB, olderThan time.Duration) (int, error) {
+	result, err := db.ExecContext(ctx, `UPDATE transcription_jobs SET status = 'queued', updated_at = NOW()
+			WHERE status = 'running' AND updated_at < $1`, time.Now().Add(-olderThan))
+	if err != nil {
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+// CountTranscriptionJobs - navbatdagi va bajarilayotgan vazifalar soni (barcha portallar)
+func CountTranscriptionJobs(ctx context.Context, db *sql.DB) (map[string]int, error) {
+	rows, err := db.QueryContext(ctx, `SELECT status, COUNT(*) FROM transcription_jobs
+			WHERE status IN ('queued', 'running') GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{"queued": 0, "running": 0}
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// SaveTranscript - matn va bo'laklarni saqlash (qayta o'girilsa, eskisi almashtiriladi)
+func SaveTranscript(ctx context.Context, db *sql.DB, t *models.Transcript) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO transcripts (member_id, call_id, provider, language, text, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		ON CONFLICT (member_id, call_id) DO UPDATE SET
+			provider = EXCLUDED.provider,
+			language = EXCLUDED.language,
+			text = EXCLUDED.text,
+			created_at = EXCLUDED.created_at`,
+		t.MemberID, t.CallID, t.Provider, t.Language, t.Text, t.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM transcript_segments WHERE member_id = $1 AND call_id = $2`, t.MemberID, t.CallID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO transcript_segments (member_id, call_id, idx, start_ms, end_ms, channel, speaker, text, confidence)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, s := range t.Segments {
+		var channel sql.NullInt64
+		if s.Channel != nil {
+			channel = sql.NullInt64{Int64: int64(*s.Channel), Valid: true}
+		}
+		if _, err := stmt.ExecContext(ctx, t.MemberID, t.CallID, i, s.StartMs, s.EndMs, channel, s.Speaker, s.Text, s.Confidence); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetTranscript - qo'ng'iroq matni bo'laklari bilan
+func GetTranscript(ctx context.Context, db *sql.DB, memberID, callID string) (*models.Transcript, error) {
+	t := models.Transcript{MemberID: memberID, CallID: callID}
+	err := db.QueryRowContext(ctx, `SELECT provider, COALESCE(language, ''), text, created_at
+			FROM transcripts WHERE member_id = $1 AND call_id = $2`, memberID, callID).
+		Scan(&t.Provider, &t.Language, &t.Text, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT start_ms, end_ms, channel, COALESCE(speaker, ''), text, COALESCE(confidence, 0)
+			FROM transcript_segments WHERE member_id = $1 AND call_id = $2 ORDER BY idx`, memberID, callID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s models.TranscriptSegment
+		var channel sql.NullInt64
+		if err := rows.Scan(&s.StartMs, &s.EndMs, &channel, &s.Speaker, &s.Text, &s.Confidence); err != nil {
+			return nil, err
+		}
+		if channel.Valid {
+			c := int(channel.Int64)
+			s.Channel = &c
+		}
+		t.Segments = append(t.Segments, s)
+	}
+	return &t, rows.Err()
+}
@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"bitrix/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// GetUserByID - users jadvalidan xodim ma'lumotlari (portal doirasida); per-call kesh sifatida ham ishlatiladi
+func GetUserByID(ctx context.Context, db *sql.DB, memberID, id string) (*models.User, error) {
+	query := `SELECT id, member_id, COALESCE(xml_id, ''), COALESCE(active, FALSE), COALESCE(name, ''),
+				COALESCE(last_name, ''), COALESCE(second_name, ''), COALESCE(email, ''), last_login,
+				COALESCE(time_zone, ''), COALESCE(time_zone_offset, 0), COALESCE(personal_photo, ''),
+				COALESCE(personal_gender, ''), COALESCE(personal_www, ''), COALESCE(personal_birthday, ''),
+				COALESCE(personal_mobile, ''), COALESCE(personal_city, ''), COALESCE(work_phone, ''),
+				COALESCE(work_position, ''), COALESCE(uf_employment_date, ''), COALESCE(user_type, ''),
+				COALESCE(department_ids, '[]'::JSONB), synced_at
+			  FROM users WHERE member_id = $1 AND id = $2`
+
+	var u models.User
+	var departments []byte
+	var syncedAt sql.NullTime
+	err := db.QueryRowContext(ctx, query, memberID, id).Scan(&u.ID, &u.MemberID, &u.XML_ID, &u.Active, &u.Name,
+		&u.LastName, &u.SecondName, &u.Email, &u.LastLogin,
+		&u.TimeZone, &u.TimeZoneOffset, &u.PersonalPhoto,
+		&u.PersonalGender, &u.PersonalWWW, &u.PersonalBirthday,
+		&u.PersonalMobile, &u.PersonalCity, &u.WorkPhone,
+		&u.WorkPosition, &u.EmploymentDate, &u.UserType,
+		&departments, &syncedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(departments, &u.Department); err != nil {
+		return nil, err
+	}
+	u.SyncedAt = syncedAt.Time
+	return &u, nil
+}
+
+// UpsertUser - xodimni qo'shish yoki yangilash; faollik/bo'lim/lavozim o'zgarsa tarixga yoziladi
+func UpsertUser(ctx context.Context, db *sql.DB, memberID string, user *models.User) error {
+	user.MemberID = memberID
+	departments := user.Department
+	if departments == nil {
+		departments = models.FlexIDs{}
+	}
+	departmentJSON, err := json.Marshal(departments)
+	if err != nil {
+		return fmt.Errorf("Department JSON serialize qilishda xatolik: %v", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `
+		INSERT INTO users (
+			id, xml_id, active, name, last_name, second_name, email, last_login,
+			time_zone, time_zone_offset, personal_photo, personal_gender, personal_www,
+			personal_birthday, personal_mobile, personal_city, work_phone, work_position,
+			uf_employment_date, user_type, department_ids, member_id, synced_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
+			$18, $19, $20, $21, $22, $23
+		) ON CONFLICT (member_id, id) DO UPDATE SET
+			xml_id = EXCLUDED.xml_id,
+			active = EXCLUDED.active,
+			name = EXCLUDED.name,
+			last_name = EXCLUDED.last_name,
+			second_name = EXCLUDED.second_name,
+			email = EXCLUDED.email,
+			last_login = EXCLUDED.last_login,
+			time_zone = EXCLUDED.time_zone,
+			time_zone_offset = EXCLUDED.time_zone_offset,
+			personal_photo = EXCLUDED.personal_photo,
+			personal_gender = EXCLUDED.personal_gender,
+			personal_www = EXCLUDED.personal_www,
+			personal_birthday = EXCLUDED.personal_birthday,
+			personal_mobile = EXCLUDED.personal_mobile,
+			personal_city = EXCLUDED.personal_city,
+			work_phone = EXCLUDED.work_phone,
+			work_position = EXCLUDED.work_position,
+			uf_employment_date = EXCLUDED.uf_employment_date,
+			user_type = EXCLUDED.user_type,
+			department_ids = EXCLUDED.department_ids,
+			synced_at = EXCLUDED.synced_at`
+
+	_, err = tx.ExecContext(ctx, query, user.ID, user.XML_ID, user.Active, user.Name, user.LastName, user.SecondName,
+		user.Email, user.LastLogin, user.TimeZone, user.TimeZoneOffset, user.PersonalPhoto,
+		user.PersonalGender, user.PersonalWWW, user.PersonalBirthday, user.PersonalMobile,
+		user.PersonalCity, user.WorkPhone, user.WorkPosition, user.EmploymentDate,
+		user.UserType, string(departmentJSON), memberID, now)
+	if err != nil {
+		return fmt.Errorf("User saqlashda xatolik (ID: %s): %v", user.ID, err)
+	}
+
+	if err := recordUserState(ctx, tx, memberID, user.ID, bool(user.Active), departmentJSON, user.WorkPosition, now); err != nil {
+		return err
+	}
+	user.SyncedAt = now
+	return tx.Commit()
+}
+
+// recordUserState - joriy holat o'zgargan bo'lsa, eski yozuvni yopib yangisini ochadi
+func recordUserState(ctx context.Context, tx *sql.Tx, memberID, userID string, active bool, departments []byte, position string, now time.Time) error {
+	var curActive bool
+	var curDepartments []byte
+	var curPosition string
+	err := tx.QueryRowContext(ctx, `SELECT active, department_ids, COALESCE(work_position, '')
+			FROM user_history WHERE member_id = $1 AND user_id = $2 AND valid_to IS NULL`,
+		memberID, userID).Scan(&curActive, &curDepartments, &curPosition)
+
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return fmt.Errorf("user_history o'qishda xatolik: %v", err)
+	default:
+		if curActive == active && curPosition == position && jsonEqual(curDepartments, departments) {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE user_history SET valid_to = $3
+				WHERE member_id = $1 AND user_id = $2 AND valid_to IS NULL`, memberID, userID, now); err != nil {
+			return fmt.Errorf("user_history yopishda xatolik: %v", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO user_history (member_id, user_id, active, department_ids, work_position, valid_from)
+			VALUES ($1, $2, $3, $4, $5, $6)`, memberID, userID, active, string(departments), position, now)
+	if err != nil {
+		return fmt.Errorf("user_history yozishda xatolik: %v", err)
+	}
+	return nil
+}
+
+// jsonEqual - JSONB dan qaytgan matn formatlanishi farq qilishi mumkin, shuning uchun qiymat bo'yicha solishtiramiz
+func jsonEqual(a, b []byte) bool {
+	var x, y models.FlexIDs
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil || len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// DeactivateMissingUsers - to'liq sinxronizatsiyada kelmagan xodimlarni nofaol qiladi
+func DeactivateMissingUsers(ctx context.Context, db *sql.DB, memberID string, seenIDs []string) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	rows, err := tx.QueryContext(ctx, `UPDATE users SET active = FALSE, synced_at = $3
+			WHERE member_id = $1 AND active AND NOT (id = ANY($2))
+			RETURNING id, COALESCE(department_ids, '[]'::JSONB), COALESCE(work_position, '')`,
+		memberID, pq.Array(seenIDs), now)
+	if err != nil {
+		return 0, err
+	}
+
+	type state struct {
+		id, position string
+		departments  []byte
+	}
+	var changed []state
+	for rows.Next() {
+		var s state
+		if err := rows.Scan(&s.id, &s.departments, &s.position); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		changed = append(changed, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, s := range changed {
+		if err := recordUserState(ctx, tx, memberID, s.id, false, s.departments, s.position, now); err != nil {
+			return 0, err
+		}
+	}
+	return len(changed), tx.Commit()
+}
+
+// GetUserHistory - xodim holatlari tarixi (eng eskisidan)
+func GetUserHistory(ctx context.Context, db *sql.DB, memberID, userID string) ([]models.UserHistory, error) {
+	rows, err := db.QueryContext(ctx, `SELECT user_id, active, department_ids, COALESCE(work_position, ''), valid_from, valid_to
+			FROM user_history WHERE member_id = $1 AND user_id = $2 ORDER BY valid_from`, memberID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.UserHistory
+	for rows.Next() {
+		var h models.UserHistory
+		var departments []byte
+		var validTo sql.NullTime
+		if err := rows.Scan(&h.UserID, &h.Active, &departments, &h.WorkPosition, &h.ValidFrom, &validTo); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(departments, &h.Department); err != nil {
+			return nil, err
+		}
+		if validTo.Valid {
+			h.ValidTo = &validTo.Time
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+// GetUsersSyncedAt - portal xodimlari oxirgi marta qachon to'liq sinxronlangan
+func GetUsersSyncedAt(ctx context.Context, db *sql.DB, memberID string) (time.Time, error) {
+	var t sql.NullTime
+	err := db.QueryRowContext(ctx, `SELECT users_synced_at FROM portals WHERE member_id = $1`, memberID).Scan(&t)
+	return t.Time, err
+}
+
+// SetUsersSyncedAt - to'liq sinxronizatsiya vaqtini yozish
+func SetUsersSyncedAt(ctx context.Context, db *sql.DB, memberID string, t time.Time) error {
+	_, err := db.ExecContext(ctx, `UPDATE portals SET users_synced_at = $1 WHERE member_id = $2`, t, memberID)
+	return err
+}
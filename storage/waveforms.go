@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"bitrix/models"
+	"context"
+	"database/sql"
+)
+
+// SaveWaveform - to'lqin shaklini yozish (kanallar bitta BYTEA ga ketma-ket joylanadi)
+func SaveWaveform(ctx context.Context, db *sql.DB, w *models.Waveform) error {
+	var peaks []byte
+	for _, ch := range w.Peaks {
+		peaks = append(peaks, ch...)
+	}
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO recording_waveforms (member_id, call_id, bucket_ms, channels, peaks, approximate, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (member_id, call_id) DO UPDATE SET
+			bucket_ms = EXCLUDED.bucket_ms,
+			channels = EXCLUDED.channels,
+			peaks = EXCLUDED.peaks,
+			approximate = EXCLUDED.approximate,
+			created_at = EXCLUDED.created_at`,
+		w.MemberID, w.CallID, w.BucketMs, len(w.Peaks), peaks, w.Approximate, w.CreatedAt)
+	return err
+}
+
+// GetWaveform - saqlangan to'lqin shakli
+func GetWaveform(ctx context.Context, db *sql.DB, memberID, callID string) (*models.Waveform, error) {
+	w := models.Waveform{MemberID: memberID, CallID: callID}
+	var channels int
+	var peaks []byte
+	err := db.QueryRowContext(ctx, `SELECT bucket_ms, channels, peaks, approximate, created_at
+			FROM recording_waveforms WHERE member_id = $1 AND call_id = $2`, memberID, callID).
+		Scan(&w.BucketMs, &channels, &peaks, &w.Approximate, &w.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if channels > 0 {
+		per := len(peaks) / channels
+		for c := 0; c < channels; c++ {
+			w.Peaks = append(w.Peaks, peaks[c*per:(c+1)*per])
+		}
+	}
+	return &w, nil
+}
+
+// SearchCallSegments - qo'ng'iroq transkriptida so'rovga mos bo'laklar boshlanish vaqti (ms)
+func SearchCallSegments(ctx context.Context, db *sql.DB, memberID, callID, q string) ([]int64, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT s.start_ms FROM transcript_segments s
+		WHERE s.member_id = $1 AND s.call_id = $2
+			AND s.search @@ (websearch_to_tsquery('russian', $3) || websearch_to_tsquery('simple', $3))
+		ORDER BY s.start_ms`, memberID, callID, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var starts []int64
+	for rows.Next() {
+		var ms int64
+		if err := rows.Scan(&ms); err != nil {
+			return nil, err
+		}
+		starts = append(starts, ms)
+	}
+	return starts, rows.Err()
+}
@@ -0,0 +1,49 @@
+// To'lqin shakli: /api/calls/{id}/waveform dan bo'laklar, jimlik va belgilarni chizadi;
+// bosilganda audio shu joydan ijro etiladi.
+(function () {
+  var canvas = document.getElementById("waveform");
+  if (!canvas) return;
+  var audio = document.querySelector("audio");
+  var url = canvas.dataset.src;
+  if (canvas.dataset.q) url += "?q=" + encodeURIComponent(canvas.dataset.q);
+
+  fetch(url, { credentials: "same-origin" })
+    .then(function (r) { return r.ok ? r.json() : null; })
+    .then(function (data) {
+      if (!data || !data.duration_ms) { canvas.hidden = true; return; }
+      draw(data);
+      canvas.addEventListener("click", function (e) {
+        if (!audio) return;
+        var rect = canvas.getBoundingClientRect();
+        audio.currentTime = (e.clientX - rect.left) / rect.width * data.duration_ms / 1000;
+        audio.play();
+      });
+    });
+
+  function draw(data) {
+    var ctx = canvas.getContext("2d");
+    var w = canvas.width = canvas.clientWidth * (window.devicePixelRatio || 1);
+    var h = canvas.height;
+    var x = function (ms) { return ms / data.duration_ms * w; };
+
+    ctx.fillStyle = "#f1f1f1";
+    data.silences.forEach(function (s) { ctx.fillRect(x(s.start_ms), 0, x(s.end_ms) - x(s.start_ms), h); });
+
+    // Stereo: operator yuqorida, mijoz pastda; mono: markazdan ikki tomonga
+    var colors = ["#1976d2", "#43a047"];
+    data.peaks.forEach(function (peaks, c) {
+      ctx.fillStyle = colors[c % colors.length];
+      var bw = w / peaks.length;
+      peaks.forEach(function (v, i) {
+        var ph = v / 255 * (h / 2);
+        var y = data.channels === 1 ? h / 2 - ph : (c === 0 ? h / 2 - ph : h / 2);
+        ctx.fillRect(i * bw, y, Math.max(bw - 0.5, 0.5), data.channels === 1 ? ph * 2 : ph);
+      });
+    });
+
+    data.markers.forEach(function (m) {
+      ctx.fillStyle = m.kind === "keyword" ? "#f9a825" : (m.passed ? "#2e7d32" : "#c62828");
+      ctx.fillRect(x(m.start_ms), 0, 2, h);
+    });
+  }
+})();
@@ -0,0 +1,301 @@
+package web
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bitrix/api"
+	"bitrix/logging"
+	"bitrix/models"
+	"bitrix/service"
+	"bitrix/storage"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+//go:embed static
+var staticFS embed.FS
+
+var funcs = template.FuncMap{
+	"duration": formatSeconds,
+	"datetime": formatTime,
+	"callType": models.CallTypeName,
+	"crmURL":   crmURL,
+	"clock":    func(ms int64) string { return formatSeconds(ms / 1000) },
+	"percent":  func(f float64) string { return fmt.Sprintf("%.0f%%", f*100) },
+	"seconds":  func(ms int64) int64 { return ms / 1000 },
+	// storage.SearchCalls matnni escape qilgan, faqat <mark> teglari qoladi
+	"snippet": func(s string) template.HTML { return template.HTML(s) },
+}
+
+var webLog = logging.For("web")
+
+var pages = map[string]*template.Template{}
+
+func init() {
+	for _, name := range []string{"welcome.html", "calls.html", "call.html", "analytics.html", "search.html", "share.html"} {
+		pages[name] = template.Must(template.New("").Funcs(funcs).ParseFS(templateFS, "templates/layout.html", "templates/"+name))
+	}
+}
+
+// RegisterRoutes - dashboard sahifalari va statik fayllar
+func RegisterRoutes(mux *http.ServeMux, db *sql.DB) {
+	static, _ := fs.Sub(staticFS, "static")
+	mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServer(http.FS(static))))
+
+	mux.HandleFunc("GET /{$}", page(db, handleCalls))
+	mux.HandleFunc("GET /calls/{id}", page(db, handleCall))
+	mux.HandleFunc("GET /analytics", page(db, handleAnalytics))
+	mux.HandleFunc("GET /search", page(db, handleSearch))
+
+	// Ulashilgan havolalar (CRM timeline, ogohlantirishlar): sessiyasiz, faqat bitta qo'ng'iroq
+	mux.HandleFunc("GET /share/{token}", shared(db, handleShare))
+	mux.HandleFunc("GET /share/{token}/recording", shared(db, handleShareRecording))
+}
+
+// shared - havola tokeni bo'yicha qo'ng'iroqni topadi; token URL da bo'lgani uchun Referer ga chiqmasin
+func shared(db *sql.DB, next func(http.ResponseWriter, *http.Request, *sql.DB, *models.CallListItem)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.Header().Set("X-Robots-Tag", "noindex")
+		w.Header().Set("Cache-Control", "private, no-store")
+
+		memberID, callID, err := service.AuthenticateCallShare(r.Context(), db, r.PathValue("token"))
+		if err == nil {
+			var call *models.CallListItem
+			if call, err = storage.GetCall(r.Context(), db, memberID, callID); err == nil {
+				next(w, r, db, call)
+				return
+			}
+		}
+		if err == sql.ErrNoRows {
+			http.NotFound(w, r)
+			return
+		}
+		webLog.Error("ulashilgan havola xatolik", "err", err)
+		http.Error(w, "Qo'ng'iroqni olishda xatolik", http.StatusInternalServerError)
+	}
+}
+
+// page - HTML sahifalar uchun auth: sessiya bo'lmasa, Bitrix24 orqali ochish haqida sahifa
+func page(db *sql.DB, next func(http.ResponseWriter, *http.Request, *sql.DB, *api.Principal)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := api.Authenticate(db, r)
+		if p == nil || !service.HasScope(p.Scopes, models.ScopeCallsRead) {
+			render(w, http.StatusUnauthorized, "welcome.html", nil)
+			return
+		}
+
+		// Bitrix24 placement iframe ichida ochilishi uchun faqat o'z portaliga ruxsat
+		if t, err := storage.GetTokenByMemberID(r.Context(), db, p.MemberID); err == nil && t.PortalDomain != "" {
+			w.Header().Set("Content-Security-Policy", "frame-ancestors 'self' https://"+t.PortalDomain)
+		}
+		next(w, r, db, p)
+	}
+}
+
+func render(w http.ResponseWriter, status int, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := pages[name].ExecuteTemplate(w, "layout", data); err != nil {
+		webLog.Error("template xatolik", "template", name, "err", err)
+	}
+}
+
+func handleCalls(w http.ResponseWriter, r *http.Request, db *sql.DB, p *api.Principal) {
+	filter := api.ParseCallFilter(r)
+	calls, err := storage.ListCalls(r.Context(), db, p.MemberID, filter)
+	if err != nil {
+		webLog.Error("ListCalls xatolik", "member_id", p.MemberID, "err", err)
+		http.Error(w, "Qo'ng'iroqlarni olishda xatolik", http.StatusInternalServerError)
+		return
+	}
+	status, err := storage.GetSyncStatus(r.Context(), db, p.MemberID)
+	if err != nil && err != sql.ErrNoRows {
+		webLog.Error("GetSyncStatus xatolik", "member_id", p.MemberID, "err", err)
+	}
+
+	render(w, http.StatusOK, "calls.html", map[string]interface{}{
+		"Calls":  calls,
+		"Status": status,
+		"Query":  r.URL.Query(),
+		"Next":   filter.Offset + len(calls),
+		"More":   len(calls) > 0 && len(calls) == effectiveLimit(filter.Limit),
+	})
+}
+
+func handleCall(w http.ResponseWriter, r *http.Request, db *sql.DB, p *api.Principal) {
+	call, err := storage.GetCall(r.Context(), db, p.MemberID, r.PathValue("id"))
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		webLog.Error("GetCall xatolik", "member_id", p.MemberID, "err", err)
+		http.Error(w, "Qo'ng'iroqni olishda xatolik", http.StatusInternalServerError)
+		return
+	}
+
+	var user *models.User
+	if call.PortalUserID != "" {
+		if user, err = storage.GetUserByID(r.Context(), db, p.MemberID, call.PortalUserID); err != nil && err != sql.ErrNoRows {
+			webLog.Error("GetUserByID xatolik", "member_id", p.MemberID, "err", err)
+		}
+	}
+	domain := ""
+	if t, err := storage.GetTokenByMemberID(r.Context(), db, p.MemberID); err == nil {
+		domain = t.PortalDomain
+	}
+	transcript, err := storage.GetTranscript(r.Context(), db, p.MemberID, call.ID)
+	if err != nil && err != sql.ErrNoRows {
+		webLog.Error("GetTranscript xatolik", "member_id", p.MemberID, "err", err)
+	}
+	recording, err := storage.GetRecordingMeta(r.Context(), db, p.MemberID, call.ID)
+	if err != nil && err != sql.ErrNoRows {
+		webLog.Error("GetRecordingMeta xatolik", "member_id", p.MemberID, "err", err)
+	}
+	talk, err := storage.GetTalkStats(r.Context(), db, p.MemberID, call.ID)
+	if err != nil && err != sql.ErrNoRows {
+		webLog.Error("GetTalkStats xatolik", "member_id", p.MemberID, "err", err)
+	}
+	compliance, err := storage.GetCallCompliance(r.Context(), db, p.MemberID, call.ID)
+	if err != nil && err != sql.ErrNoRows {
+		webLog.Error("GetCallCompliance xatolik", "member_id", p.MemberID, "err", err)
+	}
+
+	// Qidiruvdan kelganda yozuvni topilgan joydan boshlash (media fragment)
+	recordingURL := "/api/calls/" + call.ID + "/recording"
+	if t, err := strconv.Atoi(r.URL.Query().Get("t")); err == nil && t > 0 {
+		recordingURL += "#t=" + strconv.Itoa(t)
+	}
+
+	render(w, http.StatusOK, "call.html", map[string]interface{}{
+		"Call":         call,
+		"User":         user,
+		"Transcript":   transcript,
+		"Compliance":   compliance,
+		"Recording":    recording,
+		"Talk":         talk,
+		"Search":       r.URL.Query().Get("q"),
+		"Domain":       domain,
+		"CanListen":    service.HasScope(p.Scopes, models.ScopeRecordingsRead) && call.AudioPath != "",
+		"RecordingURL": recordingURL,
+	})
+}
+
+// handleShare - qo'ng'iroqning qisqa sahifasi: ma'lumotlar, yozuv va transkript
+func handleShare(w http.ResponseWriter, r *http.Request, db *sql.DB, call *models.CallListItem) {
+	transcript, err := storage.GetTranscript(r.Context(), db, call.MemberID, call.ID)
+	if err != nil && err != sql.ErrNoRows {
+		webLog.Error("GetTranscript xatolik", "member_id", call.MemberID, "err", err)
+	}
+	render(w, http.StatusOK, "share.html", map[string]interface{}{
+		"Call":         call,
+		"Transcript":   transcript,
+		"RecordingURL": r.URL.Path + "/recording",
+	})
+}
+
+func handleShareRecording(w http.ResponseWriter, r *http.Request, db *sql.DB, call *models.CallListItem) {
+	api.ServeRecording(w, r, call.MemberID, call.AudioPath)
+}
+
+func handleAnalytics(w http.ResponseWriter, r *http.Request, db *sql.DB, p *api.Principal) {
+	aq := api.ParseAnalyticsQuery(r)
+	stats, err := storage.CallStats(r.Context(), db, p.MemberID, aq)
+	if errors.Is(err, storage.ErrAnalyticsQuery) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		webLog.Error("CallStats xatolik", "member_id", p.MemberID, "err", err)
+		http.Error(w, "Statistikani olishda xatolik", http.StatusInternalServerError)
+		return
+	}
+
+	render(w, http.StatusOK, "analytics.html", map[string]interface{}{
+		"Stats": stats,
+		"From":  aq.From.Format("2006-01-02"),
+		"To":    aq.To.AddDate(0, 0, -1).Format("2006-01-02"),
+		"Query": r.URL.Query(),
+	})
+}
+
+func handleSearch(w http.ResponseWriter, r *http.Request, db *sql.DB, p *api.Principal) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	var results []models.SearchResult
+	if q != "" {
+		var err error
+		if results, err = storage.SearchCalls(r.Context(), db, p.MemberID, q, searchPageSize, offset); err != nil {
+			webLog.Error("SearchCalls xatolik", "member_id", p.MemberID, "err", err)
+			http.Error(w, "Qidiruvda xatolik", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	render(w, http.StatusOK, "search.html", map[string]interface{}{
+		"Query":   q,
+		"Results": results,
+		"Next":    offset + len(results),
+		"More":    len(results) == searchPageSize,
+	})
+}
+
+const searchPageSize = 20
+
+func effectiveLimit(limit int) int {
+	if limit <= 0 || limit > 500 {
+		return 50
+	}
+	return limit
+}
+
+// formatSeconds - soniyalar (FlexInt, int64, float64 yoki *float64) -> "m:ss"
+func formatSeconds(v interface{}) string {
+	var sec int64
+	switch n := v.(type) {
+	case models.FlexInt:
+		if !n.Valid {
+			return ""
+		}
+		sec = n.Int64
+	case int64:
+		sec = n
+	case float64:
+		sec = int64(n)
+	case *float64:
+		if n == nil {
+			return ""
+		}
+		sec = int64(*n)
+	}
+	if sec < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%02d", sec/60, sec%60)
+}
+
+func formatTime(t models.FlexTime) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// crmURL - CRM obyektining Bitrix24 dagi sahifasi (LEAD, CONTACT, COMPANY, DEAL)
+func crmURL(domain, entityType, entityID string) string {
+	if domain == "" || entityType == "" || entityID == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://%s/crm/%s/details/%s/", domain, strings.ToLower(entityType), entityID)
+}